@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+ALTER TABLE api_keys ADD COLUMN resources TEXT; -- Optional folder prefixes the key is restricted to
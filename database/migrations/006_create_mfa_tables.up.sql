@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id CHAR(36) PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL, -- Base32 TOTP secret
+    enabled BOOLEAN NOT NULL DEFAULT FALSE, -- Only true once a code has been verified
+    enabled_at TIMESTAMP NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- Last accepted TOTP time step, prevents code replay
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN is_anonymous BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN expires_at TIMESTAMP NULL; -- Anonymous users are deleted, with their files, after this time
+
+CREATE INDEX idx_users_anonymous_expires_at ON users (is_anonymous, expires_at);
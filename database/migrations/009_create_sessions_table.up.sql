@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id CHAR(36) PRIMARY KEY, -- Carried in the token's jti claim
+    user_id VARCHAR(255) NOT NULL, -- Subject of the token
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(512),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
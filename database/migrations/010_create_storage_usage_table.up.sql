@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS storage_usage (
+    user_id CHAR(36) PRIMARY KEY,
+    bytes_used BIGINT NOT NULL DEFAULT 0,
+    file_count BIGINT NOT NULL DEFAULT 0,
+    quota_bytes BIGINT NULL, -- Per-user override of the role default, NULL to use the default
+    quota_files BIGINT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Start the counters from the files that already exist
+INSERT INTO storage_usage (user_id, bytes_used, file_count)
+SELECT owner_id, SUM(size), COUNT(*) FROM files GROUP BY owner_id;
@@ -0,0 +1,4 @@
+-- Files stay quarantined until a scan marks them clean. Existing files have never been
+-- scanned, so they start out pending as well until the scan retry job gets to them.
+ALTER TABLE files ADD COLUMN scan_status VARCHAR(16) NOT NULL DEFAULT 'pending'; -- "pending", "clean" or "infected"
+ALTER TABLE files ADD COLUMN scanned_at TIMESTAMP NULL;
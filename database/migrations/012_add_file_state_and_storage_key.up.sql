@@ -0,0 +1,10 @@
+-- Files are created and deleted in two phases: a row is written as "pending" before the upload
+-- and becomes "active" once the object is stored, and is marked "deleting" before the object is
+-- removed. The reconciliation job finishes or rolls back rows left in between.
+ALTER TABLE files ADD COLUMN state VARCHAR(16) NOT NULL DEFAULT 'active'; -- "pending", "active" or "deleting"
+ALTER TABLE files ADD COLUMN storage_key VARCHAR(1024) NULL; -- Object key in the bucket
+
+-- Objects used to be stored under the uploaded file name
+UPDATE files SET storage_key = name WHERE storage_key IS NULL;
+
+CREATE INDEX idx_files_state ON files (state, updated_at);
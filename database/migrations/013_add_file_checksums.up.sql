@@ -0,0 +1,7 @@
+ALTER TABLE files ADD COLUMN checksum_sha256 CHAR(64) NULL;
+ALTER TABLE files ADD COLUMN checksum_md5 CHAR(32) NULL;
+ALTER TABLE files ADD COLUMN checksum_crc32c BIGINT NULL;
+ALTER TABLE files ADD COLUMN integrity_status VARCHAR(16) NOT NULL DEFAULT 'unverified'; -- "unverified", "ok" or "corrupted"
+ALTER TABLE files ADD COLUMN verified_at TIMESTAMP NULL;
+
+CREATE INDEX idx_files_verified_at ON files (verified_at);
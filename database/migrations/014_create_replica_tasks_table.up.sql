@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS replica_tasks (
+    id CHAR(36) PRIMARY KEY,
+    storage_key VARCHAR(255) NOT NULL,
+    backend VARCHAR(32) NOT NULL, -- Replica name from STORAGE_REPLICAS
+    operation VARCHAR(16) NOT NULL, -- "write" or "delete"
+    size BIGINT NOT NULL DEFAULT -1,
+    checksum_sha256 CHAR(64) NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (storage_key, backend) -- The latest operation on an object supersedes earlier ones
+);
+
+CREATE INDEX idx_replica_tasks_next_attempt_at ON replica_tasks (next_attempt_at);
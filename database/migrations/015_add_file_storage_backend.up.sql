@@ -0,0 +1,4 @@
+-- Provider holding each file's object, NULL for files stored before this was recorded
+ALTER TABLE files ADD COLUMN storage_backend VARCHAR(32) NULL;
+
+CREATE INDEX idx_files_storage_backend ON files (storage_backend);
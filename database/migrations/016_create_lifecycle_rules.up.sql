@@ -0,0 +1,26 @@
+ALTER TABLE files ADD COLUMN last_accessed_at TIMESTAMP NULL; -- Last download, NULL if never downloaded
+ALTER TABLE files ADD COLUMN storage_class VARCHAR(32) NULL; -- Set by lifecycle rules, NULL for the bucket default
+
+CREATE TABLE IF NOT EXISTS file_tags (
+    file_id CHAR(36) NOT NULL,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (file_id, tag),
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_file_tags_tag ON file_tags (tag);
+
+CREATE TABLE IF NOT EXISTS lifecycle_rules (
+    id CHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    action VARCHAR(32) NOT NULL, -- "move_to_cold", "set_storage_class" or "expire"
+    storage_class VARCHAR(32) NULL, -- Target class for "set_storage_class"
+    older_than_days INT NULL,
+    not_accessed_days INT NULL,
+    min_size BIGINT NULL,
+    folder VARCHAR(1024) NULL,
+    tag VARCHAR(64) NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT TRUE, -- Only report matching files
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+ALTER TABLE files ADD COLUMN thumbnail_status VARCHAR(16) NOT NULL DEFAULT 'none'; -- "none", "pending", "ready" or "failed"
+
+-- Queue thumbnails for images uploaded before they were generated
+UPDATE files SET thumbnail_status = 'pending'
+WHERE content_type IN ('image/jpeg', 'image/png', 'image/gif', 'image/webp');
+
+CREATE INDEX idx_files_thumbnail_pending ON files (created_at) WHERE thumbnail_status = 'pending';
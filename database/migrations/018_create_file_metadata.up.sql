@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS file_metadata (
+    file_id CHAR(36) PRIMARY KEY,
+    width INT NULL, -- Pixels, for images and video
+    height INT NULL,
+    camera_make VARCHAR(255) NULL,
+    camera_model VARCHAR(255) NULL,
+    captured_at TIMESTAMP NULL,
+    orientation SMALLINT NULL, -- EXIF orientation, 1 to 8
+    has_location BOOLEAN NOT NULL DEFAULT FALSE, -- Whether the stored content records where it was taken
+    location_stripped BOOLEAN NOT NULL DEFAULT FALSE, -- Whether a location was removed before the content was stored
+    page_count INT NULL,
+    duration_seconds DOUBLE PRECISION NULL,
+    extracted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
+);
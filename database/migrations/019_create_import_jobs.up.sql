@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id CHAR(36) PRIMARY KEY,
+    owner_id CHAR(36) NOT NULL,
+    url TEXT NOT NULL,
+    path VARCHAR(1024) NOT NULL, -- Path of the file to create
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- "pending", "running", "completed" or "failed"
+    bytes_downloaded BIGINT NOT NULL DEFAULT 0,
+    total_bytes BIGINT NULL, -- From Content-Length, NULL if unknown
+    file_id CHAR(36) NULL, -- The stored file, once completed
+    error TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Running jobs not updated for too long are retried
+    completed_at TIMESTAMP NULL,
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_import_jobs_status ON import_jobs (status, updated_at);
+CREATE INDEX idx_import_jobs_owner_id ON import_jobs (owner_id, created_at);
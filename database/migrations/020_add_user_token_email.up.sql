@@ -0,0 +1,3 @@
+-- Emailed tokens are bound to the address they were sent to, so a token cannot verify an address
+-- set after it was issued. Tokens issued before this have no address and can no longer be redeemed.
+ALTER TABLE user_tokens ADD COLUMN email VARCHAR(255) NOT NULL DEFAULT '';
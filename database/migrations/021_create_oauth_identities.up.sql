@@ -0,0 +1,13 @@
+-- Identities at OAuth providers that users can sign in with. Each identity belongs to one user.
+CREATE TABLE IF NOT EXISTS oauth_identities (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    provider VARCHAR(64) NOT NULL, -- Name of the provider in OAUTH_PROVIDERS
+    subject VARCHAR(255) NOT NULL, -- The provider's stable ID for the user
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_oauth_identities_user_id ON oauth_identities (user_id);
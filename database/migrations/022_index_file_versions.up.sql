@@ -0,0 +1,3 @@
+-- Uploads to the same owner and path are versions of one file. Lifecycle rules with the
+-- "delete_old_versions" action look up the newer versions of each file through this index.
+CREATE INDEX idx_files_owner_path ON files (owner_id, path, created_at);
@@ -0,0 +1,271 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Entry types reported when reading an archive.
+const (
+	TypeFile  = "file"
+	TypeDir   = "dir"
+	TypeOther = "other" // Links, devices and anything else that is not extracted
+)
+
+// ratioThreshold is how much data must be expanded before the compression ratio is judged, so
+// small, highly repetitive files are not mistaken for bombs.
+const ratioThreshold = 1 << 20
+
+// Errors reading untrusted archives.
+var (
+	ErrTooManyEntries   = errors.New("archive has too many entries")
+	ErrTooLarge         = errors.New("archive expands beyond the size limit")
+	ErrCompressionRatio = errors.New("archive is compressed beyond the ratio limit")
+	ErrNotFile          = errors.New("archive entry is not a regular file")
+	ErrDamaged          = errors.New("archive is damaged")
+)
+
+// Limits bound what reading an untrusted archive may cost, so an archive bomb is rejected
+// instead of filling memory or disk.
+type Limits struct {
+	MaxEntries int64 // Most entries, zero for no limit
+	MaxSize    int64 // Most uncompressed bytes in total, zero for no limit
+	MaxRatio   int64 // Most uncompressed bytes per compressed byte, zero for no limit
+}
+
+// EntryInfo describes an entry read from an archive.
+type EntryInfo struct {
+	Name     string    `json:"name"` // Cleaned by CleanName
+	Type     string    `json:"type"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Unsafe   bool      `json:"unsafe,omitempty"` // The stored name is absolute or climbs out with ".."
+}
+
+// Reader reads an archive's entries in order, checking its limits as it goes.
+type Reader interface {
+	// Next advances to the next entry, returning io.EOF after the last.
+	Next() (*EntryInfo, error)
+	// Open returns the content of the current entry, which must be a file.
+	Open() (io.ReadCloser, error)
+}
+
+// FormatOf returns the archive format of a content type, or "" if it is not an archive that can
+// be read. Gzip content is taken to be a tar archive, and fails to read if it is not.
+func FormatOf(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "application/zip", "application/x-zip-compressed":
+		return FormatZip
+	case "application/gzip", "application/x-gzip":
+		return FormatTarGz
+	default:
+		return ""
+	}
+}
+
+// SafeName cleans an entry's stored name, reporting false if the name is empty, absolute, has a
+// drive letter or climbs out of the directory it is extracted into.
+func SafeName(name string) (string, bool) {
+	cleaned := CleanName(name)
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	if cleaned == "" || strings.HasPrefix(slashed, "/") {
+		return cleaned, false
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." || len(part) == 2 && part[1] == ':' {
+			return cleaned, false
+		}
+	}
+	return cleaned, true
+}
+
+// zipReader reads ZIP archives. The central directory gives every entry's sizes up front, so
+// limits are checked from those, and archive/zip fails reads of more data than an entry declares.
+type zipReader struct {
+	files    []*zip.File
+	next     int
+	current  *zip.File
+	limits   Limits
+	expanded int64 // Declared uncompressed size of the entries so far
+}
+
+// NewZipReader reads a ZIP archive of size bytes from r.
+func NewZipReader(r io.ReaderAt, size int64, limits Limits) (Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if limits.MaxEntries > 0 && int64(len(zr.File)) > limits.MaxEntries {
+		return nil, ErrTooManyEntries
+	}
+	return &zipReader{files: zr.File, limits: limits}, nil
+}
+
+// Next advances to the next entry.
+func (r *zipReader) Next() (*EntryInfo, error) {
+	if r.next >= len(r.files) {
+		r.current = nil
+		return nil, io.EOF
+	}
+	f := r.files[r.next]
+	r.next++
+	r.current = f
+
+	uncompressed := int64(f.UncompressedSize64)
+	if uncompressed < 0 {
+		return nil, ErrTooLarge
+	}
+	r.expanded += uncompressed
+	if r.limits.MaxSize > 0 && r.expanded > r.limits.MaxSize {
+		return nil, ErrTooLarge
+	}
+	if r.limits.MaxRatio > 0 && uncompressed > ratioThreshold && uncompressed/max(int64(f.CompressedSize64), 1) > r.limits.MaxRatio {
+		return nil, ErrCompressionRatio
+	}
+
+	name, safe := SafeName(f.Name)
+	entry := &EntryInfo{Name: name, Type: TypeOther, Size: uncompressed, Modified: f.Modified, Unsafe: !safe}
+	switch mode := f.Mode(); {
+	case mode.IsDir():
+		entry.Type = TypeDir
+	case mode.IsRegular():
+		entry.Type = TypeFile
+	}
+	return entry, nil
+}
+
+// Open returns the current entry's content.
+func (r *zipReader) Open() (io.ReadCloser, error) {
+	if r.current == nil || !r.current.Mode().IsRegular() {
+		return nil, ErrNotFile
+	}
+	content, err := r.current.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDamaged, err)
+	}
+	return &entryReader{r: content, c: content}, nil
+}
+
+// tarGzReader reads gzip-compressed tar archives as a stream. Sizes are only known once the
+// content is expanded, so limits are checked against the data as it is decompressed.
+type tarGzReader struct {
+	tr      *tar.Reader
+	current *tar.Header
+	limits  Limits
+	entries int64
+}
+
+// NewTarGzReader reads a gzip-compressed tar archive from r.
+func NewTarGzReader(r io.Reader, limits Limits) (Reader, error) {
+	compressed := &countingReader{r: r}
+	gz, err := gzip.NewReader(compressed)
+	if err != nil {
+		return nil, err
+	}
+	guard := &expansionGuard{r: gz, compressed: compressed, limits: limits}
+	return &tarGzReader{tr: tar.NewReader(guard), limits: limits}, nil
+}
+
+// Next advances to the next entry, skipping the rest of the current one.
+func (r *tarGzReader) Next() (*EntryInfo, error) {
+	r.current = nil
+	header, err := r.tr.Next()
+	if err != nil {
+		if err != io.EOF && !IsInvalid(err) {
+			err = fmt.Errorf("%w: %v", ErrDamaged, err)
+		}
+		return nil, err
+	}
+	r.entries++
+	if r.limits.MaxEntries > 0 && r.entries > r.limits.MaxEntries {
+		return nil, ErrTooManyEntries
+	}
+	r.current = header
+
+	name, safe := SafeName(header.Name)
+	entry := &EntryInfo{Name: name, Type: TypeOther, Size: header.Size, Modified: header.ModTime, Unsafe: !safe}
+	switch mode := header.FileInfo().Mode(); {
+	case mode.IsDir():
+		entry.Type = TypeDir
+	case mode.IsRegular():
+		entry.Type = TypeFile
+	}
+	return entry, nil
+}
+
+// Open returns the current entry's content, which is only readable until Next is called.
+func (r *tarGzReader) Open() (io.ReadCloser, error) {
+	if r.current == nil || !r.current.FileInfo().Mode().IsRegular() {
+		return nil, ErrNotFile
+	}
+	return &entryReader{r: r.tr, c: io.NopCloser(nil)}, nil
+}
+
+// IsInvalid reports whether an error reading an archive is the archive's own fault: it is
+// damaged, or breaks the limits.
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrDamaged) || errors.Is(err, ErrTooManyEntries) || errors.Is(err, ErrTooLarge) ||
+		errors.Is(err, ErrCompressionRatio)
+}
+
+// entryReader reads an entry's content, marking errors other than the limits as damage, so they
+// can be told apart from errors writing the content elsewhere.
+type entryReader struct {
+	r io.Reader
+	c io.Closer
+}
+
+// Read reads the entry's content.
+func (e *entryReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && !IsInvalid(err) {
+		err = fmt.Errorf("%w: %v", ErrDamaged, err)
+	}
+	return n, err
+}
+
+// Close releases the entry's content.
+func (e *entryReader) Close() error {
+	return e.c.Close()
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// expansionGuard fails reads of decompressed data once it grows beyond the size or ratio limit.
+type expansionGuard struct {
+	r          io.Reader
+	compressed *countingReader
+	expanded   int64
+	limits     Limits
+}
+
+// Read reads decompressed data, checking the limits after every read.
+func (g *expansionGuard) Read(p []byte) (int, error) {
+	n, err := g.r.Read(p)
+	g.expanded += int64(n)
+	if g.limits.MaxSize > 0 && g.expanded > g.limits.MaxSize {
+		return n, ErrTooLarge
+	}
+	if g.limits.MaxRatio > 0 && g.expanded > ratioThreshold && g.expanded/max(g.compressed.n, 1) > g.limits.MaxRatio {
+		return n, ErrCompressionRatio
+	}
+	return n, err
+}
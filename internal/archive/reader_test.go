@@ -0,0 +1,219 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestSafeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		cleaned string
+		safe    bool
+	}{
+		{"docs/readme.txt", "docs/readme.txt", true},
+		{"./docs/./readme.txt", "docs/readme.txt", true},
+		{"docs/..readme", "docs/..readme", true},
+		{"../etc/passwd", "etc/passwd", false},
+		{"docs/../../etc/passwd", "docs/etc/passwd", false},
+		{"docs/..", "docs", false},
+		{"/etc/passwd", "etc/passwd", false},
+		{"//etc/passwd", "etc/passwd", false},
+		{`..\windows\system.ini`, "windows/system.ini", false},
+		{`\windows\system.ini`, "windows/system.ini", false},
+		{"C:/windows/system.ini", "windows/system.ini", false},
+		{`C:\windows\system.ini`, "windows/system.ini", false},
+		{"", "", false},
+		{"..", "", false},
+	}
+	for _, tt := range tests {
+		cleaned, safe := SafeName(tt.name)
+		if cleaned != tt.cleaned || safe != tt.safe {
+			t.Errorf("SafeName(%q) = %q, %v, want %q, %v", tt.name, cleaned, safe, tt.cleaned, tt.safe)
+		}
+	}
+}
+
+// testEntry is a file to put in a test archive.
+type testEntry struct {
+	name    string
+	content []byte
+}
+
+// repeated returns n bytes of zeros, which compress to almost nothing.
+func repeated(n int) []byte {
+	return make([]byte, n)
+}
+
+// buildZip builds a ZIP archive of compressed entries.
+func buildZip(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(entry.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// buildTarGz builds a gzip-compressed tar archive of regular files.
+func buildTarGz(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(entry.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readAll reads every entry and its content, returning the entries read and the first error.
+func readAll(r Reader) ([]*EntryInfo, error) {
+	var entries []*EntryInfo
+	for {
+		entry, err := r.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+		if entry.Type != TypeFile {
+			continue
+		}
+		content, err := r.Open()
+		if err != nil {
+			return entries, err
+		}
+		_, err = io.Copy(io.Discard, content)
+		content.Close()
+		if err != nil {
+			return entries, err
+		}
+	}
+}
+
+// openFormat opens a test archive with the reader for its format.
+func openFormat(t *testing.T, format string, entries []testEntry, limits Limits) (Reader, error) {
+	t.Helper()
+	if format == FormatZip {
+		data := buildZip(t, entries)
+		return NewZipReader(bytes.NewReader(data), int64(len(data)), limits)
+	}
+	return NewTarGzReader(bytes.NewReader(buildTarGz(t, entries)), limits)
+}
+
+func TestReaderLimits(t *testing.T) {
+	many := make([]testEntry, 11)
+	for i := range many {
+		many[i] = testEntry{name: fmt.Sprintf("file-%d.txt", i), content: []byte("x")}
+	}
+	bomb := []testEntry{{name: "zeros.bin", content: repeated(8 << 20)}}
+	small := []testEntry{{name: "small-zeros.bin", content: repeated(ratioThreshold / 2)}}
+	large := []testEntry{{name: "a.txt", content: []byte("0123456789")}, {name: "b.txt", content: []byte("0123456789")}}
+
+	tests := []struct {
+		name    string
+		entries []testEntry
+		limits  Limits
+		wantErr error
+	}{
+		{"within limits", many, Limits{MaxEntries: 11, MaxSize: 1 << 20, MaxRatio: 100}, nil},
+		{"too many entries", many, Limits{MaxEntries: 10}, ErrTooManyEntries},
+		{"too large in total", large, Limits{MaxSize: 15}, ErrTooLarge},
+		{"compression bomb", bomb, Limits{MaxRatio: 100}, ErrCompressionRatio},
+		{"bomb without a ratio limit", bomb, Limits{}, nil},
+		{"small repetitive file", small, Limits{MaxRatio: 100}, nil},
+	}
+	for _, format := range []string{FormatZip, FormatTarGz} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				r, err := openFormat(t, format, tt.entries, tt.limits)
+				if err == nil {
+					_, err = readAll(r)
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("reading the archive failed with %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != nil && !IsInvalid(err) {
+					t.Errorf("IsInvalid(%v) = false, want true", err)
+				}
+			})
+		}
+	}
+}
+
+func TestReaderMarksUnsafeNames(t *testing.T) {
+	entries := []testEntry{
+		{name: "docs/readme.txt", content: []byte("hello")},
+		{name: "../../etc/cron.d/evil", content: []byte("* * * * * root sh")},
+		{name: "/etc/passwd", content: []byte("root:x:0:0")},
+	}
+	want := []struct {
+		name   string
+		unsafe bool
+	}{
+		{"docs/readme.txt", false},
+		{"etc/cron.d/evil", true},
+		{"etc/passwd", true},
+	}
+
+	for _, format := range []string{FormatZip, FormatTarGz} {
+		r, err := openFormat(t, format, entries, Limits{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := readAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: read %d entries, want %d", format, len(got), len(want))
+		}
+		for i, entry := range got {
+			if entry.Name != want[i].name || entry.Unsafe != want[i].unsafe {
+				t.Errorf("%s: entry %d is %q unsafe=%v, want %q unsafe=%v", format, i, entry.Name, entry.Unsafe, want[i].name, want[i].unsafe)
+			}
+		}
+	}
+}
+
+func TestTarGzReaderDamaged(t *testing.T) {
+	data := buildTarGz(t, []testEntry{{name: "file.txt", content: bytes.Repeat([]byte("content "), 4096)}})
+	r, err := NewTarGzReader(bytes.NewReader(data[:len(data)/2]), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readAll(r); !IsInvalid(err) {
+		t.Errorf("reading a truncated archive failed with %v, want a damaged archive", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Package archive writes ZIP and gzip-compressed tar archives as streams, so archives of stored
+// files never have to be held in memory or on disk, and reads uploaded archives within limits
+// that stop archive bombs and entries that would escape their folder.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Archive formats.
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+)
+
+// ErrUnsupportedFormat is returned for archive formats other than FormatZip and FormatTarGz.
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// Entry describes a file added to an archive.
+type Entry struct {
+	Name        string // Slash-separated path inside the archive
+	Size        int64
+	ModTime     time.Time
+	ContentType string // Used to skip compressing content that is already compressed
+}
+
+// Writer streams files into an archive. Entries are written as they are added, and Close
+// writes whatever the format needs at the end.
+type Writer interface {
+	Add(entry Entry, content io.Reader) error
+	Close() error
+}
+
+// NewWriter creates a Writer for a format that writes to w.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatZip:
+		return newZipWriter(w), nil
+	case FormatTarGz:
+		return newTarGzWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// ContentType returns the content type of an archive format.
+func ContentType(format string) string {
+	if format == FormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// zipWriter writes ZIP archives. Sizes are written after each entry's data, and entries or
+// offsets beyond the 32-bit limits switch the archive to ZIP64.
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+// newZipWriter creates a zipWriter that compresses with the fastest deflate level.
+func newZipWriter(w io.Writer) *zipWriter {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, flate.BestSpeed)
+	})
+	return &zipWriter{zw: zw}
+}
+
+// Add writes an entry, storing content that is already compressed as it is.
+func (w *zipWriter) Add(entry Entry, content io.Reader) error {
+	header := &zip.FileHeader{
+		Name:     entry.Name,
+		Method:   zip.Deflate,
+		Modified: entry.ModTime,
+	}
+	if !Compressible(entry.ContentType) {
+		header.Method = zip.Store
+	}
+
+	out, err := w.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	return copyExactly(out, content, entry.Size)
+}
+
+// Close writes the central directory.
+func (w *zipWriter) Close() error {
+	return w.zw.Close()
+}
+
+// tarGzWriter writes gzip-compressed tar archives.
+type tarGzWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+// newTarGzWriter creates a tarGzWriter that compresses with the fastest gzip level.
+func newTarGzWriter(w io.Writer) *tarGzWriter {
+	gz, _ := gzip.NewWriterLevel(w, gzip.BestSpeed) // The level is valid, so there is no error
+	return &tarGzWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+// Add writes an entry. Tar headers hold the size up front, so content must match it.
+func (w *tarGzWriter) Add(entry Entry, content io.Reader) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Name,
+		Size:     entry.Size,
+		Mode:     0o644,
+		ModTime:  entry.ModTime,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	return copyExactly(w.tw, content, entry.Size)
+}
+
+// Close writes the tar trailer and flushes the compressor.
+func (w *tarGzWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// copyExactly copies size bytes, failing if the content is shorter or longer, so an object that
+// changed since its size was recorded cannot produce a silently wrong entry.
+func copyExactly(dst io.Writer, src io.Reader, size int64) error {
+	n, err := io.Copy(dst, io.LimitReader(src, size))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("content is %d bytes, expected %d", n, size)
+	}
+	if extra, _ := src.Read(make([]byte, 1)); extra > 0 {
+		return fmt.Errorf("content is longer than the expected %d bytes", size)
+	}
+	return nil
+}
+
+// Compressible reports whether content of a type is worth compressing. Images, audio, video and
+// archives are already compressed, with a few uncompressed exceptions.
+func Compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	switch mediaType {
+	case "image/bmp", "image/svg+xml", "image/tiff", "audio/wav", "audio/x-wav", "audio/wave":
+		return true
+	case "application/zip", "application/gzip", "application/x-gzip", "application/x-7z-compressed",
+		"application/x-rar-compressed", "application/x-bzip2", "application/x-xz", "application/zstd", "application/pdf":
+		return false
+	}
+	kind, _, _ := strings.Cut(mediaType, "/")
+	return kind != "image" && kind != "audio" && kind != "video"
+}
+
+// Names hands out entry names that are safe to extract and unique within one archive.
+type Names struct {
+	used map[string]bool
+}
+
+// NewNames creates an empty set of entry names.
+func NewNames() *Names {
+	return &Names{used: map[string]bool{}}
+}
+
+// Unique cleans a slash-separated name so it cannot escape the directory it is extracted into,
+// then numbers it as "name (2).ext" if the archive already has an entry by that name.
+func (n *Names) Unique(name string) string {
+	name = CleanName(name)
+	if name == "" {
+		name = "file"
+	}
+
+	candidate := name
+	ext := path.Ext(name)
+	if ext == name[strings.LastIndex(name, "/")+1:] {
+		ext = "" // Dotfiles such as ".env" have no extension
+	}
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; n.used[strings.ToLower(candidate)]; i++ {
+		candidate = base + " (" + strconv.Itoa(i) + ")" + ext
+	}
+
+	// Names that differ only in case clash on case-insensitive file systems
+	n.used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// CleanName turns a path into a relative, slash-separated entry name with no "." or ".."
+// elements and no drive letter, or "" if nothing is left. Backslashes count as separators, as
+// Windows tools treat them that way.
+func CleanName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+
+	parts := make([]string, 0, strings.Count(name, "/")+1)
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." || len(part) == 2 && part[1] == ':' {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+)
+
+// GenerateActionToken creates a random single-use token for an emailed action such as
+// verifying an address or resetting a password. It returns the token to send and the
+// signature to store.
+func GenerateActionToken(purpose string) (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	signature, err := SignActionToken(purpose, token)
+	if err != nil {
+		return "", "", err
+	}
+	return token, signature, nil
+}
+
+// SignActionToken computes the HMAC-SHA256 of a token bound to its purpose, keyed by the
+// JWT secret. A token issued for one purpose can therefore never be redeemed for another,
+// and stored signatures cannot be turned back into usable tokens.
+func SignActionToken(purpose, token string) (string, error) {
+	secret := os.Getenv("JWT_SECRET_KEY")
+	if secret == "" {
+		return "", errors.New("JWT secret key not set in environment variables")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + ":" + token))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix marks a bearer credential as an API key rather than a JWT.
+const APIKeyPrefix = "gs_"
+
+// apiKeyPrefixLength is the number of random characters kept as the key's visible prefix.
+const apiKeyPrefixLength = 8
+
+// GenerateAPIKey creates a new random API key and returns the plaintext key,
+// its displayable prefix and the hash to persist.
+func GenerateAPIKey() (string, string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	prefix := APIKeyPrefix + encoded[:apiKeyPrefixLength]
+	key := prefix + "_" + encoded[apiKeyPrefixLength:]
+
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash of an API key.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsAPIKey reports whether a credential looks like an API key issued by GenerateAPIKey.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -34,16 +34,50 @@ func GenerateDynamicSalt(ctx *gin.Context) (string, error) {
 	return base64.StdEncoding.EncodeToString(salt), nil
 }
 
-// GenerateToken creates a JWT with a dynamic component.
+// GenerateToken creates a JWT with a dynamic component and the default scopes.
 func GenerateToken(ctx *gin.Context, username string) (string, string, error) {
-	salt, err := GenerateDynamicSalt(ctx)
-	if err != nil {
+	return GenerateScopedToken(ctx, username, DefaultScopes(), nil)
+}
+
+// GenerateScopedToken creates a JWT limited to the given scopes and, optionally, file path prefixes.
+// The token is recorded as a session so it can be listed and revoked.
+func GenerateScopedToken(ctx *gin.Context, subject string, scopes, resources []string) (string, string, error) {
+	now := time.Now()
+	claims := &Claims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(time.Hour * 24).Unix(),
+			IssuedAt:  now.Unix(),
+			Subject:   subject,
+		},
+		Scopes:    scopes,
+		Resources: resources,
+	}
+
+	if err := startSession(ctx, claims); err != nil {
+		merrors.InternalServer(ctx, "Failed to record session")
 		return "", "", err
 	}
+	return signClaims(ctx, claims)
+}
 
-	claims := &jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
-		Subject:   username,
+// GenerateMFAPendingToken creates a short-lived JWT proving the password step of a login
+// succeeded. It only carries the mfa:pending scope, so it cannot be used to access the API.
+func GenerateMFAPendingToken(ctx *gin.Context, subject string) (string, string, error) {
+	claims := &Claims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Minute * 5).Unix(),
+			Subject:   subject,
+		},
+		Scopes: []string{ScopeMFAPending},
+	}
+	return signClaims(ctx, claims)
+}
+
+// signClaims signs claims with a freshly salted secret and returns the token and salt.
+func signClaims(ctx *gin.Context, claims *Claims) (string, string, error) {
+	salt, err := GenerateDynamicSalt(ctx)
+	if err != nil {
+		return "", "", err
 	}
 
 	signingSecret, err := GetSigningSecret(ctx, salt)
@@ -62,13 +96,13 @@ func GenerateToken(ctx *gin.Context, username string) (string, string, error) {
 }
 
 // ValidateToken checks the validity of a JWT using the provided salt.
-func ValidateToken(ctx *gin.Context, tokenString string, salt string) (*jwt.StandardClaims, error) {
+func ValidateToken(ctx *gin.Context, tokenString string, salt string) (*Claims, error) {
 	signingSecret, err := GetSigningSecret(ctx, salt)
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return signingSecret, nil
 	})
 
@@ -81,7 +115,7 @@ func ValidateToken(ctx *gin.Context, tokenString string, salt string) (*jwt.Stan
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*jwt.StandardClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
 
@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
 )
 
 // CORSMiddleware handles Cross-Origin Resource Sharing (CORS) settings.
@@ -13,7 +17,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-API-Key")
 		ctx.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type")
 		ctx.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 
@@ -35,9 +39,21 @@ func RequestLogger() gin.HandlerFunc {
 	}
 }
 
-// JWTAuthMiddleware verifies the JWT token and extracts claims.
-func JWTAuthMiddleware() gin.HandlerFunc {
+// APIKeyStore is the subset of the API key repository the auth middleware depends on.
+type APIKeyStore interface {
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	TouchAPIKey(id string, usedAt time.Time) error
+}
+
+// AuthMiddleware authenticates the request with either a JWT or an API key and extracts claims.
+// API keys are accepted in the X-API-Key header or as a bearer token.
+func AuthMiddleware(apiKeys APIKeyStore) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if apiKey := ctx.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(ctx, apiKeys, apiKey)
+			return
+		}
+
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
 			merrors.Unauthorized(ctx, "Authorization header is missing")
@@ -52,6 +68,11 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		}
 		tokenString := tokenParts[1]
 
+		if IsAPIKey(tokenString) {
+			authenticateAPIKey(ctx, apiKeys, tokenString)
+			return
+		}
+
 		// Extract the salt (assuming it's sent as a query parameter, header, or some other way)
 		salt := ctx.Query("salt")
 		if salt == "" {
@@ -66,9 +87,59 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if !checkSession(ctx, claims) {
+			return
+		}
+
 		// Attach the claims to the context for use in the handlers
 		ctx.Set("claims", claims)
 
 		ctx.Next()
 	}
 }
+
+// authenticateAPIKey looks up an API key by its hash and attaches equivalent claims to the context.
+func authenticateAPIKey(ctx *gin.Context, apiKeys APIKeyStore, apiKey string) {
+	key, err := apiKeys.GetAPIKeyByHash(HashAPIKey(apiKey))
+	if err != nil {
+		merrors.Unauthorized(ctx, "Invalid API key")
+		return
+	}
+
+	if !key.IsActive() {
+		merrors.Unauthorized(ctx, "API key is expired or revoked")
+		return
+	}
+
+	now := time.Now()
+	if err := apiKeys.TouchAPIKey(key.ID, now); err != nil {
+		log.Printf("Failed to record API key usage for %s: %v", key.ID, err)
+	}
+
+	claims := &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:  key.UserID,
+			IssuedAt: key.CreatedAt.Unix(),
+		},
+		Scopes:    key.Scopes,
+		Resources: key.Resources,
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = key.ExpiresAt.Unix()
+	}
+
+	// Attach the claims and the key to the context for use in the handlers
+	ctx.Set("claims", claims)
+	ctx.Set("api_key", key)
+
+	ctx.Next()
+}
+
+// CurrentUserID returns the subject of the authenticated request, or an empty string.
+func CurrentUserID(ctx *gin.Context) string {
+	claims := GetClaims(ctx)
+	if claims == nil {
+		return ""
+	}
+	return claims.Subject
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+)
+
+// Scopes limit which operations a credential may perform.
+const (
+	ScopeFilesRead  = "files:read"
+	ScopeFilesWrite = "files:write"
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeUsersAdmin = "users:admin"
+
+	// ScopeMFAPending is only carried by the interim token issued between the password
+	// and second-factor login steps. It is never grantable.
+	ScopeMFAPending = "mfa:pending"
+)
+
+// Roles determine the widest set of scopes a user's credentials may carry.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Claims are the JWT claims issued by Go-Store. Scopes restrict the operations
+// the token allows, and Resources optionally restrict it to file path prefixes.
+type Claims struct {
+	jwt.StandardClaims
+	Scopes    []string `json:"scopes,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// DefaultScopes are granted to credentials that do not ask for specific scopes.
+func DefaultScopes() []string {
+	return []string{ScopeFilesRead, ScopeFilesWrite, ScopeUsersRead, ScopeUsersWrite}
+}
+
+// ScopesForRole returns every scope a user with the given role may be granted.
+func ScopesForRole(role string) []string {
+	if role == RoleAdmin {
+		return append(DefaultScopes(), ScopeUsersAdmin)
+	}
+	return DefaultScopes()
+}
+
+// IsValidScope checks if a scope is one Go-Store knows about.
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeFilesRead, ScopeFilesWrite, ScopeUsersRead, ScopeUsersWrite, ScopeUsersAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasScope checks if the claims grant a scope. Claims without any scopes predate
+// scoped credentials and are treated as carrying the default scopes.
+func (c *Claims) HasScope(scope string) bool {
+	return containsScope(c.EffectiveScopes(), scope)
+}
+
+// EffectiveScopes returns the scopes the claims grant, applying the default for unscoped claims.
+func (c *Claims) EffectiveScopes() []string {
+	if len(c.Scopes) == 0 {
+		return DefaultScopes()
+	}
+	return c.Scopes
+}
+
+// AllowsResource checks if the claims allow access to a file path.
+// Claims without resource constraints allow every path.
+func (c *Claims) AllowsResource(filePath string) bool {
+	if len(c.Resources) == 0 {
+		return true
+	}
+	return PathWithinPrefixes(filePath, c.Resources)
+}
+
+// NarrowScopes validates requested scopes against the scopes a credential is allowed to hold.
+// An empty request yields every allowed scope. It returns an error naming the first scope
+// that is unknown or not allowed.
+func NarrowScopes(requested, allowed []string) ([]string, error) {
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+	for _, scope := range requested {
+		if !IsValidScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !containsScope(allowed, scope) {
+			return nil, fmt.Errorf("scope %q cannot be granted", scope)
+		}
+	}
+	return requested, nil
+}
+
+// IntersectScopes returns the scopes present in both lists, preserving the order of the first.
+func IntersectScopes(a, b []string) []string {
+	scopes := []string{}
+	for _, scope := range a {
+		if containsScope(b, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// NarrowResources validates requested folder prefixes against the prefixes a credential is
+// restricted to. An empty request inherits the existing restriction.
+func NarrowResources(requested, allowed []string) ([]string, error) {
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+	if len(allowed) == 0 {
+		return requested, nil
+	}
+	for _, resource := range requested {
+		if !PathWithinPrefixes(resource, allowed) {
+			return nil, fmt.Errorf("resource %q is outside the allowed paths", resource)
+		}
+	}
+	return requested, nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PathWithinPrefixes reports whether a path equals or sits below one of the given folder prefixes.
+func PathWithinPrefixes(filePath string, prefixes []string) bool {
+	cleaned := path.Clean("/" + filePath)
+	for _, prefix := range prefixes {
+		folder := path.Clean("/" + prefix)
+		if folder == "/" || cleaned == folder || strings.HasPrefix(cleaned, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClaims returns the claims attached by AuthMiddleware, or nil.
+func GetClaims(ctx *gin.Context) *Claims {
+	value, ok := ctx.Get("claims")
+	if !ok {
+		return nil
+	}
+	claims, ok := value.(*Claims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// RequireScope checks that the authenticated credential carries a scope and
+// responds with 403 Forbidden if it does not.
+func RequireScope(ctx *gin.Context, scope string) bool {
+	claims := GetClaims(ctx)
+	if claims == nil {
+		merrors.Unauthorized(ctx, "Authentication is required")
+		return false
+	}
+	if !claims.HasScope(scope) {
+		merrors.Forbidden(ctx, "Credential is missing the "+scope+" scope")
+		return false
+	}
+	return true
+}
+
+// RequireResource checks that the authenticated credential may touch a file path
+// and responds with 403 Forbidden if it may not.
+func RequireResource(ctx *gin.Context, filePath string) bool {
+	claims := GetClaims(ctx)
+	if claims == nil {
+		merrors.Unauthorized(ctx, "Authentication is required")
+		return false
+	}
+	if !claims.AllowsResource(filePath) {
+		merrors.Forbidden(ctx, "Credential is not allowed to access this path")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNarrowScopes(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		allowed   []string
+		want      []string
+		wantErr   bool
+	}{
+		{"empty request gets every allowed scope", nil, DefaultScopes(), DefaultScopes(), false},
+		{"subset", []string{ScopeFilesRead}, DefaultScopes(), []string{ScopeFilesRead}, false},
+		{"admin scope for an admin", []string{ScopeUsersAdmin}, ScopesForRole(RoleAdmin), []string{ScopeUsersAdmin}, false},
+		{"admin scope for a user", []string{ScopeUsersAdmin}, ScopesForRole(RoleUser), nil, true},
+		{"scope the credential lacks", []string{ScopeFilesWrite}, []string{ScopeFilesRead}, nil, true},
+		{"unknown scope", []string{"files:everything"}, DefaultScopes(), nil, true},
+		{"mfa pending is never grantable", []string{ScopeMFAPending}, []string{ScopeMFAPending}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NarrowScopes(tt.requested, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NarrowScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NarrowScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNarrowResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		allowed   []string
+		want      []string
+		wantErr   bool
+	}{
+		{"empty request inherits", nil, []string{"builds"}, []string{"builds"}, false},
+		{"unrestricted credential", []string{"builds/app1"}, nil, []string{"builds/app1"}, false},
+		{"narrower path", []string{"builds/app1"}, []string{"builds"}, []string{"builds/app1"}, false},
+		{"sibling path", []string{"logs"}, []string{"builds"}, nil, true},
+		{"shared name prefix", []string{"builds-old"}, []string{"builds"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NarrowResources(tt.requested, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NarrowResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NarrowResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		claims *Claims
+		scope  string
+		ok     bool
+		status int
+	}{
+		{"granted", &Claims{Scopes: []string{ScopeFilesRead}}, ScopeFilesRead, true, http.StatusOK},
+		{"missing", &Claims{Scopes: []string{ScopeFilesRead}}, ScopeFilesWrite, false, http.StatusForbidden},
+		{"unscoped claims get the defaults", &Claims{}, ScopeFilesWrite, true, http.StatusOK},
+		{"unscoped claims are not admins", &Claims{}, ScopeUsersAdmin, false, http.StatusForbidden},
+		{"mfa pending token", &Claims{Scopes: []string{ScopeMFAPending}}, ScopeFilesRead, false, http.StatusForbidden},
+		{"unauthenticated", nil, ScopeFilesRead, false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			if tt.claims != nil {
+				ctx.Set("claims", tt.claims)
+			}
+
+			if ok := RequireScope(ctx, tt.scope); ok != tt.ok {
+				t.Errorf("RequireScope() = %v, want %v", ok, tt.ok)
+			}
+			if recorder.Code != tt.status {
+				t.Errorf("RequireScope() responded %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestRequireResource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &Claims{Resources: []string{"builds/app1"}}
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"builds/app1", true},
+		{"builds/app1/bin/tool", true},
+		{"/builds/app1/bin/tool", true},
+		{"builds/app2/bin/tool", false},
+		{"builds/app1-old/tool", false},
+		{"builds/app1/../app2/tool", false},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("claims", claims)
+
+		if ok := RequireResource(ctx, tt.path); ok != tt.ok {
+			t.Errorf("RequireResource(%q) = %v, want %v", tt.path, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// sessionTouchInterval limits how often a session's last-seen time is written.
+const sessionTouchInterval = time.Minute
+
+// SessionStore is the subset of the session repository used to record and check sessions.
+type SessionStore interface {
+	CreateSession(session *models.Session) error
+	GetSessionByID(id string) (*models.Session, error)
+	TouchSession(id string, seenAt time.Time) error
+}
+
+var sessionStore SessionStore
+
+// SetSessionStore configures where issued tokens are recorded as sessions. Without a store,
+// tokens carry no session and cannot be revoked before they expire.
+func SetSessionStore(store SessionStore) {
+	sessionStore = store
+}
+
+// CurrentSessionID returns the session of the authenticated request, or an empty string
+// when it was authenticated with an API key or a token issued without a session.
+func CurrentSessionID(ctx *gin.Context) string {
+	claims := GetClaims(ctx)
+	if claims == nil {
+		return ""
+	}
+	return claims.Id
+}
+
+// startSession records a session for the claims and stores its ID in the jti claim.
+func startSession(ctx *gin.Context, claims *Claims) error {
+	if sessionStore == nil {
+		return nil
+	}
+
+	session := models.NewSession(uuid.New().String(), claims.Subject, ctx.ClientIP(), ctx.Request.UserAgent(), time.Unix(claims.ExpiresAt, 0))
+	if err := sessionStore.CreateSession(session); err != nil {
+		return err
+	}
+
+	claims.Id = session.ID
+	return nil
+}
+
+// checkSession rejects tokens whose session has been revoked and records session activity.
+func checkSession(ctx *gin.Context, claims *Claims) bool {
+	if claims.Id == "" || sessionStore == nil {
+		return true
+	}
+
+	session, err := sessionStore.GetSessionByID(claims.Id)
+	if err != nil || session.UserID != claims.Subject || !session.IsActive() {
+		merrors.Unauthorized(ctx, "Session has been revoked")
+		return false
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := sessionStore.TouchSession(session.ID, now); err != nil {
+			log.Printf("Failed to record session activity for %s: %v", session.ID, err)
+		}
+	}
+	return true
+}
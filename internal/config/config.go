@@ -3,27 +3,199 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/souvik03-136/Go-Store/internal/models"
 )
 
 type Config struct {
-	StorageProvider string // Determines the storage provider (e.g., "s3" or "gcs")
+	StorageProvider string // Determines the storage provider (e.g., "s3", "gcs", "local", "memory" or "replicated")
+	AppBaseURL      string // Public URL of the frontend, used to build links in emails
 	GoogleCloud     GoogleCloudConfig
 	AWS             AWSConfig
+	Local           LocalStorageConfig
+	Replication     ReplicationConfig
+	Migration       MigrationConfig
+	Lifecycle       LifecycleConfig
+	Mail            MailConfig
+	Anonymous       AnonymousConfig
+	Quota           QuotaConfig
+	Upload          UploadConfig
+	Scanner         ScannerConfig
+	Reconcile       ReconcileConfig
+	Scrub           ScrubConfig
+	Thumbnail       ThumbnailConfig
+	Image           ImageConfig
+	Archive         ArchiveConfig
+	Import          ImportConfig
+	OAuth           []OAuthProviderConfig // OpenID Connect providers users can sign in with
 }
 
 type GoogleCloudConfig struct {
 	ProjectID      string
 	BucketName     string
-	CredentialsKey string
+	CredentialsKey string // Service account JSON file, empty for application default credentials
+	EmulatorHost   string // Host of a GCS emulator such as fake-gcs-server, for testing
+	ChunkSize      int64  // Bytes buffered per upload request, zero to upload in a single request
 }
 
 type AWSConfig struct {
-	Region          string
-	BucketName      string
-	AccessKeyID     string
-	SecretAccessKey string
+	Region             string
+	BucketName         string
+	AccessKeyID        string // Empty to use the default credential chain
+	SecretAccessKey    string
+	Endpoint           string // Custom endpoint for S3-compatible services, e.g. "https://minio.internal:9000"
+	ForcePathStyle     bool   // Address buckets as endpoint/bucket instead of bucket.endpoint
+	InsecureSkipVerify bool   // Skip TLS certificate verification, for development only
+}
+
+type LocalStorageConfig struct {
+	Dir     string // Directory objects are stored under
+	BaseURL string // URL the directory is served from, empty for file:// URLs
+}
+
+// ReplicationConfig lists the backends the "replicated" provider writes every object to.
+type ReplicationConfig struct {
+	Backends      []string      // Providers in read preference order, e.g. "s3,gcs" or "s3,local"
+	WriteQuorum   int64         // Replicas that must store an object for a write to succeed, zero for all
+	RetryInterval time.Duration // How often writes and deletes a replica missed are retried
+}
+
+// MigrationConfig moves objects from Source to STORAGE_PROVIDER while the API stays live.
+type MigrationConfig struct {
+	Source         string        // Provider objects are moved from, empty when no migration is running
+	Interval       time.Duration // How often a batch of files is moved
+	BatchSize      int64         // Files moved per run
+	Concurrency    int64         // Files copied in parallel
+	BytesPerSecond int64         // Combined copy rate limit, zero for unlimited
+	DeleteSource   bool          // Remove the old copy once a file has moved, instead of keeping it for rollback
+}
+
+// LifecycleConfig controls how administrators' lifecycle rules are applied.
+type LifecycleConfig struct {
+	ColdProvider   string        // Provider files are moved to by move_to_cold rules, empty for none
+	Interval       time.Duration // How often the rules are evaluated
+	BatchSize      int64         // Files each rule is applied to per run
+	BytesPerSecond int64         // Rate limit for copies to the cold tier, zero for unlimited
+}
+
+type MailConfig struct {
+	Provider     string // "smtp" or "log"
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	LogFile      string // File the log mailer appends to, empty for the application log
+}
+
+type AnonymousConfig struct {
+	UserTTL     time.Duration // How long an anonymous user and their files are kept without registering
+	MaxFileSize int64         // Largest single upload allowed, in bytes
+}
+
+// OAuthProviderConfig is an OpenID Connect provider whose ID tokens identify users. It is read
+// from OAUTH_<NAME>_ISSUER, OAUTH_<NAME>_CLIENT_ID and OAUTH_<NAME>_JWKS_URL for every name
+// listed in OAUTH_PROVIDERS.
+type OAuthProviderConfig struct {
+	Name     string // Name clients select the provider by, e.g. "google"
+	Issuer   string // Expected iss claim, e.g. "https://accounts.google.com"
+	ClientID string // Expected aud claim, the client ID registered with the provider
+	JWKSURL  string // Where the provider publishes its signing keys
+}
+
+// UploadConfig holds the default upload policy. Role and folder overrides are read from
+// the JSON file at PolicyFile, if set.
+type UploadConfig struct {
+	MaxSize           int64    // Largest upload allowed, in bytes, zero for no limit
+	AllowedTypes      []string // MIME types that may be uploaded, empty for all
+	BlockedTypes      []string // MIME types that may never be uploaded
+	AllowedExtensions []string // File extensions that may be uploaded, empty for all
+	BlockedExtensions []string // File extensions that may never be uploaded
+	StripLocation     bool     // Remove the location from photos' metadata before storing them
+	PolicyFile        string
+	BatchMaxFiles     int64 // Most files in one bulk upload
+	BatchConcurrency  int64 // Files of a bulk upload stored at once
+}
+
+type ScannerConfig struct {
+	Provider       string        // "clamd" or "noop"
+	ClamdAddress   string        // e.g., "tcp://localhost:3310" or "unix:///var/run/clamav/clamd.ctl"
+	Timeout        time.Duration // Limit for scanning a single file
+	RetryInterval  time.Duration // How often files still pending a scan are scanned again
+	RetryBatchSize int64         // Pending files scanned per run
+}
+
+type ReconcileConfig struct {
+	Interval      time.Duration // How often the bucket is compared with the files table
+	GracePeriod   time.Duration // Files and objects younger than this are assumed to be mid-upload
+	DeleteOrphans bool          // Delete objects without a file row instead of only reporting them
+}
+
+// ThumbnailConfig controls the background generation of image thumbnails.
+type ThumbnailConfig struct {
+	Sizes     []int         // Longest side of each thumbnail, in pixels, e.g. "128,256,512"
+	Interval  time.Duration // How often new images are looked for
+	BatchSize int64         // Files processed per run
+	MaxPixels int64         // Largest source image decoded, in pixels, to guard against decompression bombs
+}
+
+// ImageConfig limits on-the-fly image transformations.
+type ImageConfig struct {
+	MaxPixels      int64 // Largest source image decoded, in pixels, to guard against decompression bombs
+	MaxDimension   int64 // Largest width or height that can be requested
+	Concurrency    int64 // Transformations run at once, further requests wait their turn
+	DefaultQuality int64 // JPEG quality used when none is requested
+}
+
+// ArchiveConfig limits archives built for download, and uploaded archives that are browsed or
+// extracted.
+type ArchiveConfig struct {
+	MaxFiles       int64 // Most files in one archive
+	MaxSize        int64 // Largest total size of the files in one archive, in bytes, zero for no limit
+	ExtractMaxSize int64 // Most bytes an uploaded archive may expand to, zero for no limit
+	MaxRatio       int64 // Most an uploaded archive may expand per compressed byte, zero for no limit
+}
+
+// ImportConfig limits imports of files from remote URLs. Private, loopback and other internal
+// addresses are refused unless they are in AllowedNetworks.
+type ImportConfig struct {
+	Interval        time.Duration // How often pending imports are looked for
+	Concurrency     int64         // Imports downloaded at once
+	MaxSize         int64         // Largest file imported, in bytes, zero for no limit
+	Timeout         time.Duration // Limit for downloading a single file
+	MaxRedirects    int64         // Redirects followed before giving up
+	MaxActive       int64         // Imports a user may have waiting or running, zero for no limit
+	AllowedNetworks []string      // CIDR ranges that may be fetched from despite being internal, e.g. "10.1.0.0/16"
+}
+
+type ScrubConfig struct {
+	Interval       time.Duration // How often a batch of files is verified
+	BatchSize      int64         // Files verified per run
+	BytesPerSecond int64         // Read rate limit, zero for unlimited
+}
+
+// QuotaConfig holds the default storage quota for each kind of user. Individual users
+// can be given overrides by an administrator.
+type QuotaConfig struct {
+	User      models.Quota // Default for the "user" role
+	Admin     models.Quota // Default for the "admin" role
+	Anonymous models.Quota // Anonymous users, whatever their role
+}
+
+// ForUser returns the default quota that applies to a user.
+func (q QuotaConfig) ForUser(user *models.User) models.Quota {
+	switch {
+	case user.IsAnonymous:
+		return q.Anonymous
+	case user.Role == "admin":
+		return q.Admin
+	default:
+		return q.User
+	}
 }
 
 func LoadConfig() (*Config, error) {
@@ -38,25 +210,274 @@ func LoadConfig() (*Config, error) {
 		ProjectID:      os.Getenv("GOOGLE_CLOUD_PROJECT_ID"),
 		BucketName:     os.Getenv("GOOGLE_CLOUD_BUCKET_NAME"),
 		CredentialsKey: os.Getenv("GOOGLE_CLOUD_CREDENTIALS_KEY"),
+		EmulatorHost:   os.Getenv("STORAGE_EMULATOR_HOST"),
+		ChunkSize:      getEnvInt64("GCS_CHUNK_SIZE", 16<<20),
 	}
 
 	// Populate AWS config
 	awsConfig := AWSConfig{
-		Region:          os.Getenv("AWS_REGION"),
-		BucketName:      os.Getenv("AWS_BUCKET_NAME"),
-		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
-		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		Region:             os.Getenv("AWS_REGION"),
+		BucketName:         os.Getenv("AWS_BUCKET_NAME"),
+		AccessKeyID:        os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		Endpoint:           os.Getenv("AWS_S3_ENDPOINT"),
+		ForcePathStyle:     getEnvBool("AWS_S3_FORCE_PATH_STYLE", false),
+		InsecureSkipVerify: getEnvBool("AWS_S3_INSECURE_SKIP_VERIFY", false),
+	}
+
+	// Populate local disk storage config
+	localConfig := LocalStorageConfig{
+		Dir:     getEnvDefault("LOCAL_STORAGE_DIR", "./data/objects"),
+		BaseURL: os.Getenv("LOCAL_STORAGE_BASE_URL"),
+	}
+
+	// Populate storage replication config
+	replicationConfig := ReplicationConfig{
+		Backends:      getEnvList("STORAGE_REPLICAS"),
+		WriteQuorum:   getEnvInt64("STORAGE_WRITE_QUORUM", 0),
+		RetryInterval: getEnvDuration("STORAGE_REPLICA_RETRY_INTERVAL", time.Minute),
+	}
+
+	// Populate storage migration config
+	migrationConfig := MigrationConfig{
+		Source:         os.Getenv("STORAGE_MIGRATE_FROM"),
+		Interval:       getEnvDuration("MIGRATION_INTERVAL", time.Minute),
+		BatchSize:      getEnvInt64("MIGRATION_BATCH_SIZE", 100),
+		Concurrency:    getEnvInt64("MIGRATION_CONCURRENCY", 4),
+		BytesPerSecond: getEnvInt64("MIGRATION_BYTES_PER_SECOND", 50<<20),
+		DeleteSource:   getEnvBool("MIGRATION_DELETE_SOURCE", false),
+	}
+
+	// Populate lifecycle rule config
+	lifecycleConfig := LifecycleConfig{
+		ColdProvider:   os.Getenv("LIFECYCLE_COLD_PROVIDER"),
+		Interval:       getEnvDuration("LIFECYCLE_INTERVAL", 24*time.Hour),
+		BatchSize:      getEnvInt64("LIFECYCLE_BATCH_SIZE", 500),
+		BytesPerSecond: getEnvInt64("LIFECYCLE_BYTES_PER_SECOND", 20<<20),
+	}
+
+	// Populate mail config
+	mailConfig := MailConfig{
+		Provider:     os.Getenv("MAIL_PROVIDER"),
+		From:         os.Getenv("MAIL_FROM"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getEnvDefault("SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		LogFile:      os.Getenv("MAIL_LOG_FILE"),
+	}
+
+	// Populate anonymous user limits
+	anonymousConfig := AnonymousConfig{
+		UserTTL:     getEnvDuration("ANONYMOUS_USER_TTL", 30*24*time.Hour),
+		MaxFileSize: getEnvInt64("ANONYMOUS_MAX_FILE_SIZE", 10<<20),
 	}
 
-	// Read the storage provider (e.g., "s3" or "gcs")
+	// Populate default storage quotas, zero means unlimited
+	quotaConfig := QuotaConfig{
+		User: models.Quota{
+			MaxBytes: getEnvInt64("QUOTA_USER_BYTES", 5<<30),
+			MaxFiles: getEnvInt64("QUOTA_USER_FILES", 10000),
+		},
+		Admin: models.Quota{
+			MaxBytes: getEnvInt64("QUOTA_ADMIN_BYTES", 0),
+			MaxFiles: getEnvInt64("QUOTA_ADMIN_FILES", 0),
+		},
+		Anonymous: models.Quota{
+			MaxBytes: getEnvInt64("QUOTA_ANONYMOUS_BYTES", 100<<20),
+			MaxFiles: getEnvInt64("QUOTA_ANONYMOUS_FILES", 20),
+		},
+	}
+
+	// Populate the default upload policy
+	uploadConfig := UploadConfig{
+		MaxSize:           getEnvInt64("UPLOAD_MAX_SIZE", 1<<30),
+		AllowedTypes:      getEnvList("UPLOAD_ALLOWED_TYPES"),
+		BlockedTypes:      getEnvList("UPLOAD_BLOCKED_TYPES"),
+		AllowedExtensions: getEnvList("UPLOAD_ALLOWED_EXTENSIONS"),
+		BlockedExtensions: getEnvList("UPLOAD_BLOCKED_EXTENSIONS"),
+		StripLocation:     getEnvBool("UPLOAD_STRIP_LOCATION", false),
+		PolicyFile:        os.Getenv("UPLOAD_POLICY_FILE"),
+		BatchMaxFiles:     getEnvInt64("UPLOAD_BATCH_MAX_FILES", 1000),
+		BatchConcurrency:  getEnvInt64("UPLOAD_BATCH_CONCURRENCY", 4),
+	}
+
+	// Populate malware scanner config
+	scannerConfig := ScannerConfig{
+		Provider:       os.Getenv("SCANNER_PROVIDER"),
+		ClamdAddress:   getEnvDefault("CLAMD_ADDRESS", "tcp://localhost:3310"),
+		Timeout:        getEnvDuration("SCANNER_TIMEOUT", time.Minute),
+		RetryInterval:  getEnvDuration("SCANNER_RETRY_INTERVAL", 5*time.Minute),
+		RetryBatchSize: getEnvInt64("SCANNER_RETRY_BATCH_SIZE", 50),
+	}
+
+	// Populate storage reconciliation config
+	reconcileConfig := ReconcileConfig{
+		Interval:      getEnvDuration("RECONCILE_INTERVAL", 6*time.Hour),
+		GracePeriod:   getEnvDuration("RECONCILE_GRACE_PERIOD", time.Hour),
+		DeleteOrphans: getEnvBool("RECONCILE_DELETE_ORPHANS", false),
+	}
+
+	// Populate storage scrubber config
+	scrubConfig := ScrubConfig{
+		Interval:       getEnvDuration("SCRUB_INTERVAL", time.Hour),
+		BatchSize:      getEnvInt64("SCRUB_BATCH_SIZE", 100),
+		BytesPerSecond: getEnvInt64("SCRUB_BYTES_PER_SECOND", 10<<20),
+	}
+
+	// Populate thumbnail generation config
+	thumbnailConfig := ThumbnailConfig{
+		Sizes:     getEnvIntList("THUMBNAIL_SIZES", []int{128, 256, 512}),
+		Interval:  getEnvDuration("THUMBNAIL_INTERVAL", 30*time.Second),
+		BatchSize: getEnvInt64("THUMBNAIL_BATCH_SIZE", 20),
+		MaxPixels: getEnvInt64("THUMBNAIL_MAX_PIXELS", 50_000_000),
+	}
+
+	// Populate on-the-fly image transformation config
+	imageConfig := ImageConfig{
+		MaxPixels:      getEnvInt64("IMAGE_MAX_PIXELS", 50_000_000),
+		MaxDimension:   getEnvInt64("IMAGE_MAX_DIMENSION", 4096),
+		Concurrency:    getEnvInt64("IMAGE_CONCURRENCY", 4),
+		DefaultQuality: getEnvInt64("IMAGE_DEFAULT_QUALITY", 85),
+	}
+
+	archiveConfig := ArchiveConfig{
+		MaxFiles:       getEnvInt64("ARCHIVE_MAX_FILES", 10000),
+		MaxSize:        getEnvInt64("ARCHIVE_MAX_SIZE", 0),
+		ExtractMaxSize: getEnvInt64("ARCHIVE_EXTRACT_MAX_SIZE", 4294967296),
+		MaxRatio:       getEnvInt64("ARCHIVE_MAX_RATIO", 100),
+	}
+
+	// Populate remote URL import config
+	importConfig := ImportConfig{
+		Interval:        getEnvDuration("IMPORT_INTERVAL", 5*time.Second),
+		Concurrency:     getEnvInt64("IMPORT_CONCURRENCY", 4),
+		MaxSize:         getEnvInt64("IMPORT_MAX_SIZE", 1<<30),
+		Timeout:         getEnvDuration("IMPORT_TIMEOUT", 10*time.Minute),
+		MaxRedirects:    getEnvInt64("IMPORT_MAX_REDIRECTS", 5),
+		MaxActive:       getEnvInt64("IMPORT_MAX_ACTIVE", 10),
+		AllowedNetworks: getEnvList("IMPORT_ALLOWED_NETWORKS"),
+	}
+
+	// Populate OpenID Connect providers
+	var oauthProviders []OAuthProviderConfig
+	for _, name := range getEnvList("OAUTH_PROVIDERS") {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		oauthProviders = append(oauthProviders, OAuthProviderConfig{
+			Name:     name,
+			Issuer:   os.Getenv(prefix + "ISSUER"),
+			ClientID: os.Getenv(prefix + "CLIENT_ID"),
+			JWKSURL:  os.Getenv(prefix + "JWKS_URL"),
+		})
+	}
+
+	// Read the storage provider (e.g., "s3", "gcs", "local", "memory" or "replicated")
 	storageProvider := os.Getenv("STORAGE_PROVIDER")
 
 	// Combine into main config
 	config := &Config{
 		StorageProvider: storageProvider,
+		AppBaseURL:      getEnvDefault("APP_BASE_URL", "http://localhost:8080"),
 		GoogleCloud:     googleCloudConfig,
 		AWS:             awsConfig,
+		Local:           localConfig,
+		Replication:     replicationConfig,
+		Migration:       migrationConfig,
+		Lifecycle:       lifecycleConfig,
+		Mail:            mailConfig,
+		Anonymous:       anonymousConfig,
+		Quota:           quotaConfig,
+		Upload:          uploadConfig,
+		Scanner:         scannerConfig,
+		Reconcile:       reconcileConfig,
+		Scrub:           scrubConfig,
+		Thumbnail:       thumbnailConfig,
+		Image:           imageConfig,
+		Archive:         archiveConfig,
+		Import:          importConfig,
+		OAuth:           oauthProviders,
 	}
 
 	return config, nil
 }
+
+// getEnvDefault reads an environment variable, falling back to a default when it is unset.
+func getEnvDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration reads a duration such as "720h", falling back to a default when it is unset or invalid.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s, using default %s", key, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// getEnvInt64 reads an integer, falling back to a default when it is unset or invalid.
+func getEnvInt64(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Invalid number for %s, using default %d", key, fallback)
+		return fallback
+	}
+	return number
+}
+
+// getEnvBool reads a boolean such as "true" or "0", falling back to a default when it is unset or invalid.
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s, using default %t", key, fallback)
+		return fallback
+	}
+	return b
+}
+
+// getEnvList reads a comma-separated list, skipping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvIntList reads a comma-separated list of positive integers, falling back to a default
+// when it is unset or any entry is invalid.
+func getEnvIntList(key string, fallback []int) []int {
+	items := getEnvList(key)
+	if len(items) == 0 {
+		return fallback
+	}
+
+	list := make([]int, 0, len(items))
+	for _, item := range items {
+		number, err := strconv.Atoi(item)
+		if err != nil || number <= 0 {
+			log.Printf("Invalid list of numbers for %s, using default %v", key, fallback)
+			return fallback
+		}
+		list = append(list, number)
+	}
+	return list
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/mailer"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// How long emailed tokens stay valid.
+const (
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+// AccountController handles the emailed account flows: email verification and password reset.
+type AccountController struct {
+	userRepo    repository.UserStore
+	tokenRepo   repository.UserTokenStore
+	sessionRepo repository.SessionStore
+	mailer      mailer.Mailer
+	appBaseURL  string
+}
+
+// NewAccountController creates a new instance of AccountController.
+func NewAccountController(userRepo repository.UserStore, tokenRepo repository.UserTokenStore, sessionRepo repository.SessionStore, mail mailer.Mailer, appBaseURL string) *AccountController {
+	return &AccountController{userRepo: userRepo, tokenRepo: tokenRepo, sessionRepo: sessionRepo, mailer: mail, appBaseURL: appBaseURL}
+}
+
+// tokenRequest is the payload for redeeming an emailed token.
+type tokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// forgotPasswordRequest is the payload for requesting a password reset email.
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// resetPasswordRequest is the payload for setting a new password with a reset token.
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RequestEmailVerification emails the authenticated user a link to verify their address.
+func (c *AccountController) RequestEmailVerification(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(userID)
+	if err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+
+	if user.IsAnonymous {
+		merrors.Forbidden(ctx, "Register your account before verifying an email address")
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		merrors.Conflict(ctx, "Email is already verified")
+		return
+	}
+
+	body := "Confirm your email address for Go-Store by opening the link below. It expires in 24 hours.\n\n%s\n"
+	if err := c.sendToken(ctx, user, models.TokenPurposeVerifyEmail, verifyEmailTokenTTL, "/verify-email", "Verify your email address", body); err != nil {
+		merrors.InternalServer(ctx, "Failed to send verification email")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// VerifyEmail redeems an email verification token.
+func (c *AccountController) VerifyEmail(ctx *gin.Context) {
+	var req tokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	user, ok := c.consumeToken(ctx, models.TokenPurposeVerifyEmail, req.Token)
+	if !ok {
+		return
+	}
+
+	if err := c.userRepo.MarkEmailVerified(user.ID, time.Now()); err != nil {
+		merrors.InternalServer(ctx, "Error verifying email")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ForgotPassword emails a password reset link. It responds the same way whether or not
+// the address belongs to a user, so it cannot be used to discover accounts.
+func (c *AccountController) ForgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	if user, err := c.userRepo.GetUserByEmail(req.Email); err == nil {
+		body := "A password reset was requested for your Go-Store account. Open the link below to choose a new password. It expires in 1 hour.\n\n%s\n\nIf you did not request this, you can ignore this email.\n"
+		if err := c.sendToken(ctx, user, models.TokenPurposeResetPassword, resetPasswordTokenTTL, "/reset-password", "Reset your password", body); err != nil {
+			log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "If the email belongs to an account, a reset link has been sent"})
+}
+
+// ResetPassword redeems a password reset token, sets a new password and signs out all sessions.
+func (c *AccountController) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	// The token is single-use, so a password that cannot be set must not use it up
+	if err := models.ValidatePassword(req.Password); err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	user, ok := c.consumeToken(ctx, models.TokenPurposeResetPassword, req.Token)
+	if !ok {
+		return
+	}
+
+	if err := user.ResetPassword(req.Password); err != nil {
+		merrors.InternalServer(ctx, "Failed to reset password")
+		return
+	}
+
+	// Receiving the reset email proves ownership of the address
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := c.userRepo.UpdateUser(user); err != nil {
+		merrors.InternalServer(ctx, "Error saving new password")
+		return
+	}
+
+	// Sign out every device, since the old password may have been compromised
+	if err := c.sessionRepo.RevokeUserSessions(user.ID); err != nil {
+		log.Printf("Failed to revoke sessions of user %s after password reset: %v", user.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// sendToken issues a single-use token and emails it to the user as a link to the frontend.
+// The body must contain a single %s where the link goes.
+func (c *AccountController) sendToken(ctx *gin.Context, user *models.User, purpose string, ttl time.Duration, linkPath, subject, body string) error {
+	token, signature, err := auth.GenerateActionToken(purpose)
+	if err != nil {
+		return err
+	}
+
+	if err := c.tokenRepo.CreateUserToken(models.NewUserToken(uuid.New().String(), user.ID, purpose, user.Email, signature, ttl)); err != nil {
+		return err
+	}
+
+	link := c.appBaseURL + linkPath + "?token=" + url.QueryEscape(token)
+	return c.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(body, link),
+	})
+}
+
+// consumeToken redeems a token for a purpose and returns the user it was issued to. Tokens only
+// prove ownership of the address they were sent to, so they are refused once the user's email
+// has changed.
+func (c *AccountController) consumeToken(ctx *gin.Context, purpose, token string) (*models.User, bool) {
+	signature, err := auth.SignActionToken(purpose, token)
+	if err != nil {
+		merrors.InternalServer(ctx, "Failed to verify token")
+		return nil, false
+	}
+
+	userToken, err := c.tokenRepo.ConsumeUserToken(purpose, signature)
+	if err != nil {
+		merrors.Validation(ctx, "Token is invalid, expired or already used")
+		return nil, false
+	}
+
+	user, err := c.userRepo.GetUserByID(userToken.UserID)
+	if err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return nil, false
+	}
+	if !strings.EqualFold(user.Email, userToken.Email) {
+		merrors.Validation(ctx, "Token was sent to an email address the account no longer uses")
+		return nil, false
+	}
+	return user, true
+}
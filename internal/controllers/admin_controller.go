@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// AdminController serves operational endpoints for administrators.
+type AdminController struct {
+	fileRepo repository.FileStore
+}
+
+// NewAdminController creates a new instance of AdminController.
+func NewAdminController(fileRepo repository.FileStore) *AdminController {
+	return &AdminController{fileRepo: fileRepo}
+}
+
+// ListCorruptedFiles returns the files the storage scrubber found to be corrupted.
+func (c *AdminController) ListCorruptedFiles(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	files, err := c.fileRepo.ListCorruptedFiles()
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving corrupted files")
+		return
+	}
+	for _, file := range files {
+		withholdURL(file)
+	}
+
+	ctx.JSON(http.StatusOK, files)
+}
+
+// Metrics serves the application's counters, such as those of the storage scrubber.
+func (c *AdminController) Metrics(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	metrics.Handler().ServeHTTP(ctx.Writer, ctx.Request)
+}
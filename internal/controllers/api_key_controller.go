@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+type APIKeyController struct {
+	apiKeyRepo repository.APIKeyStore
+	userRepo   repository.UserStore
+}
+
+// NewAPIKeyController creates a new instance of APIKeyController.
+func NewAPIKeyController(apiKeyRepo repository.APIKeyStore, userRepo repository.UserStore) *APIKeyController {
+	return &APIKeyController{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+// createAPIKeyRequest is the payload accepted when creating an API key.
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes"`
+	Resources []string   `json:"resources"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKey issues a new API key for the authenticated user.
+// The plaintext key is only returned in this response. A key can never carry more scopes or
+// wider paths than the credential creating it, nor scopes beyond the user's role.
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if userID == "" {
+		merrors.Unauthorized(ctx, "Authentication is required")
+		return
+	}
+
+	// Managing credentials is account management, so file-only credentials cannot mint new keys
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		merrors.Validation(ctx, "Expiry must be in the future")
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(userID)
+	if err != nil {
+		merrors.Forbidden(ctx, "User account not found")
+		return
+	}
+	if user.IsAnonymous {
+		merrors.Forbidden(ctx, "Register your account before creating API keys")
+		return
+	}
+
+	role := auth.RoleUser
+	if user.Role != "" {
+		role = user.Role
+	}
+
+	claims := auth.GetClaims(ctx)
+	allowed := auth.IntersectScopes(claims.EffectiveScopes(), auth.ScopesForRole(role))
+	scopes, err := auth.NarrowScopes(req.Scopes, allowed)
+	if err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	resources, err := auth.NarrowResources(req.Resources, claims.Resources)
+	if err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	plaintext, prefix, keyHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		merrors.InternalServer(ctx, "Failed to generate API key")
+		return
+	}
+
+	key := models.NewAPIKey(uuid.New().String(), userID, req.Name, prefix, keyHash, scopes, resources, req.ExpiresAt)
+	if err := c.apiKeyRepo.CreateAPIKey(key); err != nil {
+		merrors.InternalServer(ctx, "Error saving API key")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"api_key": key,
+		"key":     plaintext,
+	})
+}
+
+// ListAPIKeys returns the authenticated user's API keys without their secrets.
+func (c *APIKeyController) ListAPIKeys(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if userID == "" {
+		merrors.Unauthorized(ctx, "Authentication is required")
+		return
+	}
+
+	if !auth.RequireScope(ctx, auth.ScopeUsersRead) {
+		return
+	}
+
+	keys, err := c.apiKeyRepo.ListAPIKeysByUser(userID)
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving API keys")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys.
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if userID == "" {
+		merrors.Unauthorized(ctx, "Authentication is required")
+		return
+	}
+
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	keyID := ctx.Param("id")
+	if keyID == "" {
+		merrors.BadRequest(ctx, "API key ID is required")
+		return
+	}
+
+	if err := c.apiKeyRepo.RevokeAPIKey(keyID, userID); err != nil {
+		merrors.NotFound(ctx, "API key not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
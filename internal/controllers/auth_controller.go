@@ -1,79 +1,231 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/config"
 	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/oauth"
+	"github.com/souvik03-136/Go-Store/internal/repository"
 )
 
-// RegisterOAuthUser handles user registration via OAuth.
-func RegisterOAuthUser(ctx *gin.Context) {
-	username := ctx.PostForm("username")
-	if username == "" {
-		merrors.BadRequest(ctx, "Username is required")
+// ValidateTokenHandler validates the provided JWT token.
+func ValidateTokenHandler(ctx *gin.Context) {
+	token := ctx.Query("token")
+	salt := ctx.Query("salt")
+
+	if token == "" || salt == "" {
+		merrors.BadRequest(ctx, "Token and salt are required")
 		return
 	}
 
-	token, salt, err := auth.GenerateToken(ctx, username)
+	claims, err := auth.ValidateToken(ctx, token, salt)
 	if err != nil {
-		merrors.InternalServer(ctx, "Failed to generate token")
+		merrors.Unauthorized(ctx, "Invalid token")
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"salt":  salt,
+		"claims": claims,
 	})
 }
 
-// LoginOAuthUser handles user login via OAuth.
-func LoginOAuthUser(ctx *gin.Context) {
-	username := ctx.PostForm("username")
-	if username == "" {
-		merrors.BadRequest(ctx, "Username is required")
+// AuthController handles password and OAuth logins, including the second MFA step, and anonymous users.
+type AuthController struct {
+	userRepo     repository.UserStore
+	mfaRepo      repository.MFAStore
+	identityRepo repository.OAuthIdentityStore
+	verifier     *oauth.Verifier
+	anonymousTTL time.Duration
+}
+
+// NewAuthController creates a new instance of AuthController.
+func NewAuthController(userRepo repository.UserStore, mfaRepo repository.MFAStore, identityRepo repository.OAuthIdentityStore,
+	verifier *oauth.Verifier, cfg *config.Config) *AuthController {
+	return &AuthController{
+		userRepo:     userRepo,
+		mfaRepo:      mfaRepo,
+		identityRepo: identityRepo,
+		verifier:     verifier,
+		anonymousTTL: cfg.Anonymous.UserTTL,
+	}
+}
+
+// tokenScopeRequest holds the optional fields of a login that ask for a narrower token than the
+// user's role allows.
+type tokenScopeRequest struct {
+	Scope    string `json:"scope"`    // Space-separated scopes, every scope of the role by default
+	Resource string `json:"resource"` // Space-separated folder prefixes the token is restricted to
+}
+
+// loginRequest is the payload accepted for a password login.
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	tokenScopeRequest
+}
+
+// oauthLoginRequest is the payload accepted for a login with an ID token from an OAuth provider.
+type oauthLoginRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	IDToken  string `json:"id_token" binding:"required"`
+	tokenScopeRequest
+}
+
+// mfaLoginRequest is the payload accepted for the second step of an MFA login.
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Salt     string `json:"salt" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP code or recovery code
+	tokenScopeRequest
+}
+
+// Login checks a username and password. Users without MFA receive a token straight away;
+// users with MFA receive a short-lived "mfa pending" token to exchange at /v1/auth/mfa/verify.
+func (c *AuthController) Login(ctx *gin.Context) {
+	var req loginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	user, err := c.userRepo.GetUserByUsername(req.Username)
+	if err != nil || !user.CheckPassword(req.Password) {
+		merrors.Unauthorized(ctx, "Invalid username or password")
+		return
+	}
+
+	c.completeLogin(ctx, user, req.tokenScopeRequest)
+}
+
+// OAuthLogin signs in the user an OAuth identity is linked to, given an ID token the provider
+// issued for it. Like a password login, users with MFA must still complete the second step.
+func (c *AuthController) OAuthLogin(ctx *gin.Context) {
+	var req oauthLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	identity, ok := c.verifyIdentity(ctx, req.Provider, req.IDToken)
+	if !ok {
 		return
 	}
 
-	token, salt, err := auth.GenerateToken(ctx, username)
+	link, err := c.identityRepo.GetOAuthIdentity(identity.Provider, identity.Subject)
 	if err != nil {
-		merrors.InternalServer(ctx, "Failed to generate token")
+		merrors.Unauthorized(ctx, "OAuth identity is not linked to an account")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"salt":  salt,
-	})
+	user, err := c.userRepo.GetUserByID(link.UserID)
+	if err != nil {
+		merrors.Unauthorized(ctx, "User not found")
+		return
+	}
+
+	c.completeLogin(ctx, user, req.tokenScopeRequest)
 }
 
-// ValidateTokenHandler validates the provided JWT token.
-func ValidateTokenHandler(ctx *gin.Context) {
-	token := ctx.Query("token")
-	salt := ctx.Query("salt")
+// completeLogin finishes a login for a user who has proven their identity. Users without MFA
+// receive a token straight away; users with MFA receive a short-lived "mfa pending" token.
+func (c *AuthController) completeLogin(ctx *gin.Context, user *models.User, req tokenScopeRequest) {
+	if _, err := auth.NarrowScopes(strings.Fields(req.Scope), auth.ScopesForRole(user.Role)); err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
 
-	if token == "" || salt == "" {
-		merrors.BadRequest(ctx, "Token and salt are required")
+	settings, err := c.mfaRepo.GetMFA(user.ID)
+	if err != nil && err != repository.ErrMFANotConfigured {
+		merrors.InternalServer(ctx, "Error checking MFA settings")
 		return
 	}
 
-	claims, err := auth.ValidateToken(ctx, token, salt)
+	if settings != nil && settings.Enabled {
+		mfaToken, salt, err := auth.GenerateMFAPendingToken(ctx, user.ID)
+		if err != nil {
+			merrors.InternalServer(ctx, "Failed to generate token")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"salt":         salt,
+		})
+		return
+	}
+
+	c.issueToken(ctx, user, req)
+}
+
+// CompleteMFALogin exchanges an "mfa pending" token and a TOTP or recovery code for a real token.
+func (c *AuthController) CompleteMFALogin(ctx *gin.Context) {
+	var req mfaLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	claims, err := auth.ValidateToken(ctx, req.MFAToken, req.Salt)
 	if err != nil {
-		merrors.Unauthorized(ctx, "Invalid token")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"claims": claims,
-	})
+	if !claims.HasScope(auth.ScopeMFAPending) {
+		merrors.Unauthorized(ctx, "Token is not an MFA login token")
+		return
+	}
+
+	settings, err := c.mfaRepo.GetMFA(claims.Subject)
+	if err != nil || !settings.Enabled {
+		merrors.Unauthorized(ctx, "MFA is not enabled for this user")
+		return
+	}
+
+	if !verifyMFACode(c.mfaRepo, settings, req.Code) {
+		merrors.Unauthorized(ctx, "Invalid verification code")
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(claims.Subject)
+	if err != nil {
+		merrors.Unauthorized(ctx, "User not found")
+		return
+	}
+
+	c.issueToken(ctx, user, req.tokenScopeRequest)
+}
+
+// upgradeRequest is the payload for turning an anonymous user into a registered account. The
+// account is secured either by a password or by an OAuth identity proven with an ID token.
+type upgradeRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email"` // Defaults to the OAuth identity's address when one is attached
+	Password string `json:"password"`
+	Provider string `json:"provider"`
+	IDToken  string `json:"id_token"`
 }
 
-// RegisterAnonymousUser handles the registration of an anonymous user.
-func RegisterAnonymousUser(ctx *gin.Context) {
-	anonymousID := auth.GenerateAnonymousID() // Assuming this function generates a unique ID
+// RegisterAnonymousUser creates an anonymous user that expires unless upgraded, so that
+// anything they upload has a real owner.
+func (c *AuthController) RegisterAnonymousUser(ctx *gin.Context) {
+	anonymousID := auth.GenerateAnonymousID()
 
-	token, salt, err := auth.GenerateToken(ctx, anonymousID)
+	user := models.NewAnonymousUser(anonymousID, c.anonymousTTL)
+	if err := c.userRepo.CreateUser(user); err != nil {
+		merrors.InternalServer(ctx, "Error creating anonymous user")
+		return
+	}
+
+	token, salt, err := auth.GenerateScopedToken(ctx, anonymousID, auth.ScopesForRole(user.Role), nil)
 	if err != nil {
 		merrors.InternalServer(ctx, "Failed to generate token")
 		return
@@ -83,14 +235,139 @@ func RegisterAnonymousUser(ctx *gin.Context) {
 		"anonymous_id": anonymousID,
 		"token":        token,
 		"salt":         salt,
+		"expires_at":   user.ExpiresAt,
 	})
 }
 
-// LogoutUser handles user logout and token invalidation (if applicable).
-func LogoutUser(ctx *gin.Context) {
-	// Invalidate the user's token if necessary (e.g., by adding it to a blacklist)
+// UpgradeAnonymousUser attaches a username, email and either a password or an OAuth identity to
+// the authenticated anonymous user. The user keeps the same ID, so their files and shares carry
+// over unchanged.
+func (c *AuthController) UpgradeAnonymousUser(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	var req upgradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(auth.CurrentUserID(ctx))
+	if err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+
+	if !user.IsAnonymous {
+		merrors.Conflict(ctx, "Account is already registered")
+		return
+	}
+
+	withIdentity := req.Provider != "" || req.IDToken != ""
+	if withIdentity == (req.Password != "") {
+		merrors.Validation(ctx, "Either a password or an OAuth provider and id_token are required")
+		return
+	}
+	if withIdentity {
+		c.upgradeWithIdentity(ctx, user, req)
+		return
+	}
+
+	if err := user.Register(req.Username, req.Email, req.Password); err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	if err := c.userRepo.UpdateUser(user); err != nil {
+		merrors.Conflict(ctx, "Username or email is already taken")
+		return
+	}
+
+	c.issueToken(ctx, user, tokenScopeRequest{})
+}
+
+// upgradeWithIdentity registers an anonymous user with the OAuth identity an ID token proves.
+// The identity is linked first, so an identity that already belongs to an account is refused
+// before the user changes.
+func (c *AuthController) upgradeWithIdentity(ctx *gin.Context, user *models.User, req upgradeRequest) {
+	if req.Provider == "" || req.IDToken == "" {
+		merrors.Validation(ctx, "Both provider and id_token are required")
+		return
+	}
+
+	identity, ok := c.verifyIdentity(ctx, req.Provider, req.IDToken)
+	if !ok {
+		return
+	}
+
+	email := req.Email
+	if email == "" {
+		email = identity.Email
+	}
+	if err := user.RegisterIdentity(req.Username, email); err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+	if identity.EmailVerified && strings.EqualFold(email, identity.Email) {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	link := models.NewOAuthIdentity(uuid.New().String(), user.ID, identity.Provider, identity.Subject, identity.Email)
+	if err := c.identityRepo.CreateOAuthIdentity(link); err != nil {
+		merrors.Conflict(ctx, "OAuth identity is already linked to an account")
+		return
+	}
+
+	if err := c.userRepo.UpdateUser(user); err != nil {
+		if err := c.identityRepo.DeleteOAuthIdentity(link.ID); err != nil {
+			merrors.InternalServer(ctx, "Error unlinking OAuth identity")
+			return
+		}
+		merrors.Conflict(ctx, "Username or email is already taken")
+		return
+	}
+
+	c.issueToken(ctx, user, tokenScopeRequest{})
+}
+
+// verifyIdentity checks an ID token from a provider and returns the identity it proves. It
+// responds with an error and returns false if the token is not accepted.
+func (c *AuthController) verifyIdentity(ctx *gin.Context, provider, idToken string) (*oauth.Identity, bool) {
+	identity, err := c.verifier.Verify(ctx, provider, idToken)
+	switch {
+	case errors.Is(err, oauth.ErrUnknownProvider):
+		merrors.Validation(ctx, "Unknown OAuth provider")
+		return nil, false
+	case errors.Is(err, oauth.ErrProviderUnavailable):
+		merrors.ServiceUnavailable(ctx, "OAuth provider is unavailable")
+		return nil, false
+	case err != nil:
+		merrors.Unauthorized(ctx, "Invalid ID token")
+		return nil, false
+	}
+	return identity, true
+}
+
+// issueToken responds with a token carrying the requested scopes and resources, or every scope
+// the user's role allows if none were requested.
+func (c *AuthController) issueToken(ctx *gin.Context, user *models.User, req tokenScopeRequest) {
+	scopes, err := auth.NarrowScopes(strings.Fields(req.Scope), auth.ScopesForRole(user.Role))
+	if err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	token, salt, err := auth.GenerateScopedToken(ctx, user.ID, scopes, strings.Fields(req.Resource))
+	if err != nil {
+		merrors.InternalServer(ctx, "Failed to generate token")
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "User logged out successfully",
+		"token":  token,
+		"salt":   salt,
+		"scopes": scopes,
 	})
 }
@@ -1,45 +1,443 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/auth"
 	"github.com/souvik03-136/Go-Store/internal/config"
+	"github.com/souvik03-136/Go-Store/internal/imaging"
 	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/metadata"
 	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/policy"
 	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/scanner"
 	"github.com/souvik03-136/Go-Store/internal/storage"
+	"github.com/souvik03-136/Go-Store/internal/utils"
+)
+
+// Limits on file tags, and how often downloads update a file's last access time.
+const (
+	maxFileTags         = 20
+	maxTagLength        = 64
+	accessTouchInterval = time.Hour
 )
 
 type FileController struct {
-	fileRepo *repository.FileRepository
-	storage  storage.Storage // This will be either S3 or Google Cloud Storage
+	fileRepo       repository.FileStore
+	permissionRepo repository.PermissionStore
+	userRepo       repository.UserStore
+	storage        storage.Storage // This will be either S3 or Google Cloud Storage
+	backend        string          // STORAGE_PROVIDER, recorded on each file stored
+	uploadPolicy   *policy.Policy
+	scanner        scanner.Scanner
+	anonymous      config.AnonymousConfig
+	batch          config.UploadConfig // Limits on bulk uploads
+	quotas         config.QuotaConfig
+	thumbnails     config.ThumbnailConfig
+	images         config.ImageConfig
+	imageSlots     chan struct{} // Bounds how many images are transformed at once
 }
 
-// NewFileController creates a new FileController with the specified repository and configuration
-func NewFileController(fileRepo *repository.FileRepository, cfg *config.Config) (*FileController, error) {
-	var store storage.Storage
-	var err error
+// NewFileController creates a new FileController with the specified repositories, storage and configuration
+func NewFileController(fileRepo repository.FileStore, permissionRepo repository.PermissionStore, userRepo repository.UserStore, store storage.Storage, uploadPolicy *policy.Policy, fileScanner scanner.Scanner, cfg *config.Config) *FileController {
+	return &FileController{
+		fileRepo:       fileRepo,
+		permissionRepo: permissionRepo,
+		userRepo:       userRepo,
+		storage:        store,
+		backend:        cfg.StorageProvider,
+		uploadPolicy:   uploadPolicy,
+		scanner:        fileScanner,
+		anonymous:      cfg.Anonymous,
+		batch:          cfg.Upload,
+		quotas:         cfg.Quota,
+		thumbnails:     cfg.Thumbnail,
+		images:         cfg.Image,
+		imageSlots:     make(chan struct{}, max(1, cfg.Image.Concurrency)),
+	}
+}
+
+// authorizeFile checks that the credential carries the scope and path access for an operation,
+// and that the user either owns the file or has been granted the matching per-file permission.
+func (c *FileController) authorizeFile(ctx *gin.Context, file *models.File, scope, permissionType string) bool {
+	if !auth.RequireScope(ctx, scope) || !auth.RequireResource(ctx, file.Path) {
+		return false
+	}
+
+	userID := auth.CurrentUserID(ctx)
+	if file.IsOwner(userID) {
+		return true
+	}
+
+	permission, err := c.permissionRepo.GetPermission(userID, file.ID)
+	if err != nil || !permission.CanAccess(permissionType) {
+		merrors.Forbidden(ctx, "You do not have permission to access this file")
+		return false
+	}
+	return true
+}
+
+// requireClean stops files that have not passed the malware scan from being downloaded or shared.
+func requireClean(ctx *gin.Context, file *models.File) bool {
+	if !file.IsClean() {
+		merrors.Forbidden(ctx, fmt.Sprintf("File is not available while its malware scan is %s", file.ScanStatus))
+		return false
+	}
+	return true
+}
+
+// withholdURL blanks the URL of a file that has not passed the malware scan before it is sent
+// in a response. The URL is how the content is fetched, so it would bypass the quarantine.
+func withholdURL(file *models.File) *models.File {
+	if file != nil && !file.IsClean() {
+		file.Url = ""
+	}
+	return file
+}
+
+// scanFile scans a freshly uploaded file and records the verdict. If the scanner fails the
+// file stays pending, and therefore quarantined, until the scan retry job gets a verdict for it,
+// rather than failing the upload.
+func (c *FileController) scanFile(ctx context.Context, upload uploadContent, file *models.File) {
+	content, err := upload.Open()
+	if err != nil {
+		log.Printf("Failed to open file %s for scanning: %v", file.ID, err)
+		return
+	}
+	defer content.Close()
+
+	result, err := c.scanner.Scan(ctx, content)
+	if err != nil {
+		log.Printf("Failed to scan file %s, leaving it quarantined: %v", file.ID, err)
+		return
+	}
+
+	status := models.ScanStatusClean
+	if !result.Clean {
+		status = models.ScanStatusInfected
+		log.Printf("File %s uploaded by %s is infected with %s", file.ID, file.OwnerID, result.Signature)
+	}
+
+	now := time.Now()
+	if err := c.fileRepo.UpdateScanStatus(file.ID, status, now); err != nil {
+		log.Printf("Failed to record scan result for file %s: %v", file.ID, err)
+		return
+	}
+	file.ScanStatus = status
+	file.ScannedAt = &now
+}
+
+// normalizeTags trims tags and checks they fit in the file_tags table.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > maxFileTags {
+		return nil, fmt.Errorf("a file can have at most %d tags", maxFileTags)
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be between 1 and %d characters", maxTagLength)
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// extractMetadata reads a freshly stored file's technical metadata and records it. Content that
+// cannot be parsed only loses its metadata; it does not fail the upload.
+func (c *FileController) extractMetadata(upload uploadContent, file *models.File, locationStripped bool) {
+	content, err := upload.Open()
+	if err != nil {
+		log.Printf("Failed to open file %s for metadata extraction: %v", file.ID, err)
+		return
+	}
+	defer content.Close()
+
+	meta, err := metadata.Extract(content, file.Size)
+	if err != nil {
+		log.Printf("Failed to read metadata of file %s: %v", file.ID, err)
+		if meta == nil {
+			return
+		}
+	}
+
+	meta.FileID = file.ID
+	meta.LocationStripped = locationStripped
+	if err := c.fileRepo.SetFileMetadata(meta); err != nil {
+		log.Printf("Failed to record metadata of file %s: %v", file.ID, err)
+		return
+	}
+	file.Metadata = meta
+}
+
+// uploadContent is where an upload's content is read from: the multipart file as received, or
+// a copy rewritten in memory by the upload policy.
+type uploadContent interface {
+	Open() (multipart.File, error)
+}
+
+// rewrittenUpload is upload content changed in memory before it is stored.
+type rewrittenUpload []byte
+
+// Open returns a reader over the rewritten content.
+func (u rewrittenUpload) Open() (multipart.File, error) {
+	return bytesFile{bytes.NewReader(u)}, nil
+}
+
+// bytesFile is an in-memory multipart.File.
+type bytesFile struct {
+	*bytes.Reader
+}
+
+// Close does nothing, as there is nothing to release.
+func (bytesFile) Close() error {
+	return nil
+}
+
+// spooledUpload is content copied to a temporary file before it is stored, such as an entry
+// extracted from an archive.
+type spooledUpload string
+
+// Open opens the temporary file.
+func (u spooledUpload) Open() (multipart.File, error) {
+	return os.Open(string(u))
+}
+
+// spool copies content to a temporary file, which the caller must close and remove.
+func spool(content io.Reader) (*os.File, int64, error) {
+	f, err := os.CreateTemp("", "go-store-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := io.Copy(f, content)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, err
+	}
+	return f, n, nil
+}
+
+// stripUploadLocation removes the location from a photo's metadata, returning the rewritten
+// content, or false if the photo records no location.
+func stripUploadLocation(file uploadContent) ([]byte, bool, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, false, err
+	}
+	return metadata.StripLocation(data)
+}
+
+// checksumUpload computes the checksums of an uploaded file's content.
+func checksumUpload(file uploadContent) (storage.Checksums, error) {
+	f, err := file.Open()
+	if err != nil {
+		return storage.Checksums{}, err
+	}
+	defer f.Close()
+
+	sums, _, err := storage.ComputeChecksums(f)
+	return sums, err
+}
+
+// abortCreate rolls back a file whose upload failed. Anything left behind is removed by reconciliation.
+func (c *FileController) abortCreate(ctx context.Context, file *models.File) {
+	if err := c.storage.DeleteFile(ctx, file.StorageKey); err != nil {
+		log.Printf("Failed to remove partial upload for file %s: %v", file.ID, err)
+		return
+	}
+	if err := c.fileRepo.DeleteFile(file.ID); err != nil {
+		log.Printf("Failed to remove pending file %s: %v", file.ID, err)
+	}
+}
+
+// newFile is content to be stored as a new file: an upload as received, an entry extracted from
+// an archive or a file imported from a URL.
+type newFile struct {
+	name     string
+	path     string
+	size     int64
+	content  uploadContent
+	checksum string // SHA-256 the client expects the content to have, empty to skip the check
+}
+
+// storeNewFile checks content against the user's limits and the upload policy, stores it and
+// records it as a file, then scans it and reads its metadata. Failures are returned as the error
+// to respond with, so callers storing several files can report each one.
+func (c *FileController) storeNewFile(ctx context.Context, user *models.User, upload newFile) (*models.File, *utils.Error) {
+	// Anonymous users have a stricter per-file limit until they register
+	if user.IsAnonymous && upload.size > c.anonymous.MaxFileSize {
+		return nil, merrors.NewError(http.StatusForbidden, fmt.Sprintf("Anonymous users can upload files up to %d bytes, register to upload larger files", c.anonymous.MaxFileSize), nil)
+	}
+
+	// Judge the file by its content, not by the type the client claims
+	contentType, err := policy.DetectContentType(upload.content)
+	if err != nil {
+		return nil, merrors.NewError(http.StatusBadRequest, "Could not read uploaded file", nil)
+	}
+
+	violations := c.uploadPolicy.Evaluate(policy.Upload{
+		Name:        upload.name,
+		Path:        upload.path,
+		Role:        user.Role,
+		Size:        upload.size,
+		ContentType: contentType,
+	})
+	if len(violations) > 0 {
+		return nil, merrors.NewError(http.StatusUnprocessableEntity, "File violates the upload policy", violations)
+	}
 
-	if cfg.StorageProvider == "s3" {
-		// Initialize AWS S3 Storage
-		store, err = storage.NewS3Storage(cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.Region, cfg.AWS.BucketName)
-	} else if cfg.StorageProvider == "gcs" {
-		// Initialize Google Cloud Storage
-		ctx := context.Background()
-		store, err = storage.NewGC3Storage(ctx, cfg.GoogleCloud.CredentialsKey, cfg.GoogleCloud.BucketName)
+	// Checksum the content, so the backend can verify the upload and the scrubber can verify it later
+	sums, err := checksumUpload(upload.content)
+	if err != nil {
+		return nil, merrors.NewError(http.StatusBadRequest, "Could not read uploaded file", nil)
+	}
+	if upload.checksum != "" && !strings.EqualFold(upload.checksum, sums.SHA256) {
+		return nil, merrors.NewError(http.StatusUnprocessableEntity, "File content does not match checksum_sha256", nil)
+	}
+
+	// Remove where a photo was taken before anything is stored, when the policy asks for it
+	content := upload.content
+	size := upload.size
+	locationStripped := false
+	if c.uploadPolicy.RulesFor(user.Role, upload.path).StripLocation && metadata.CanStripLocation(contentType) {
+		stripped, changed, err := stripUploadLocation(upload.content)
+		if errors.Is(err, metadata.ErrInvalid) {
+			return nil, merrors.NewError(http.StatusUnprocessableEntity, "Could not remove the location from the photo's metadata", nil)
+		}
+		if err != nil {
+			return nil, merrors.NewError(http.StatusBadRequest, "Could not read uploaded file", nil)
+		}
+		if changed {
+			content, size, locationStripped = rewrittenUpload(stripped), int64(len(stripped)), true
+			if sums, err = checksumUpload(content); err != nil {
+				return nil, merrors.NewError(http.StatusInternalServerError, "Error processing uploaded file", nil)
+			}
+		}
+	}
+
+	// Record the file as pending first. This reserves its quota before any bytes are written,
+	// and lets reconciliation clean up if the upload never completes.
+	fileModel := models.NewFile(uuid.New().String(), upload.name, upload.path, "", contentType, user.ID, size)
+	fileModel.ChecksumSHA256 = sums.SHA256
+	fileModel.ChecksumMD5 = sums.MD5
+	fileModel.ChecksumCRC32C = sums.CRC32C
+	if imaging.IsSupported(contentType) {
+		fileModel.ThumbnailStatus = models.ThumbnailPending
+	}
+	if err := c.fileRepo.CreateFile(fileModel, c.quotas.ForUser(user)); err != nil {
+		if errors.Is(err, repository.ErrQuotaExceeded) {
+			return nil, merrors.NewError(http.StatusForbidden, "Storage quota exceeded", nil)
+		}
+		return nil, merrors.NewError(http.StatusInternalServerError, "Error saving file metadata", nil)
+	}
+
+	// Upload file to cloud storage
+	reader, err := content.Open()
+	if err != nil {
+		c.abortCreate(ctx, fileModel)
+		return nil, merrors.NewError(http.StatusBadRequest, "Could not read uploaded file", nil)
+	}
+	stored, err := c.storage.Put(ctx, fileModel.StorageKey, reader, storage.PutOptions{Size: size, ContentType: contentType, Checksums: sums})
+	reader.Close()
+	if err != nil {
+		c.abortCreate(ctx, fileModel)
+		return nil, merrors.NewError(http.StatusInternalServerError, "Error uploading file to storage", nil)
+	}
+	fileURL := stored.URL
+
+	// Commit the file now that its content is stored
+	if err := c.fileRepo.ActivateFile(fileModel.ID, fileURL, c.backend); err != nil {
+		c.abortCreate(ctx, fileModel)
+		return nil, merrors.NewError(http.StatusInternalServerError, "Error saving file metadata", nil)
+	}
+	fileModel.Url = fileURL
+	fileModel.Backend = c.backend
+	fileModel.State = models.FileStateActive
+
+	// The file stays quarantined until the scanner finds it clean
+	c.scanFile(ctx, content, fileModel)
+	c.extractMetadata(content, fileModel, locationStripped)
+	return fileModel, nil
+}
+
+// fileResult is the outcome of storing one of several files.
+type fileResult struct {
+	Name  string       `json:"name"` // Name the file was submitted under
+	File  *models.File `json:"file,omitempty"`
+	Error *utils.Error `json:"error,omitempty"`
+}
+
+// fileResults reports storing several files, each of which is created or fails on its own.
+type fileResults struct {
+	Created int          `json:"created"`
+	Failed  int          `json:"failed"`
+	Results []fileResult `json:"results"`
+}
+
+// add records the outcome of storing one file.
+func (r *fileResults) add(name string, file *models.File, failure *utils.Error) {
+	if failure != nil {
+		r.Failed++
 	} else {
-		// Return a custom error message
-		return nil, errors.New("unsupported storage provider")
+		r.Created++
 	}
+	r.Results = append(r.Results, fileResult{Name: name, File: withholdURL(file), Error: failure})
+}
+
+// status returns 201 if every file was created, and 207 Multi-Status if any failed.
+func (r *fileResults) status() int {
+	if r.Failed > 0 {
+		return http.StatusMultiStatus
+	}
+	return http.StatusCreated
+}
 
+// IngestFile stores a file downloaded in the background, such as an import from a URL, as a new
+// file of a user, with the same checks as an upload. The error says why the file was refused.
+func (c *FileController) IngestFile(ctx context.Context, ownerID, filePath, contentPath string, size int64) (*models.File, error) {
+	user, err := c.userRepo.GetUserByID(ownerID)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("User account not found")
 	}
 
-	return &FileController{fileRepo: fileRepo, storage: store}, nil
+	file, failure := c.storeNewFile(ctx, user, newFile{
+		name:    path.Base(filePath),
+		path:    filePath,
+		size:    size,
+		content: spooledUpload(contentPath),
+	})
+	if failure != nil {
+		return nil, errors.New(failure.Message)
+	}
+	return file, nil
 }
 
 // CreateFile handles the creation of a new file, uploads to storage, and saves metadata in the repository.
@@ -51,34 +449,125 @@ func (c *FileController) CreateFile(ctx *gin.Context) {
 		return
 	}
 
-	// Upload file to cloud storage
-	fileURL, err := c.storage.UploadFile(ctx, file, file.Filename)
+	// The target path decides which resource-constrained credentials may upload here
+	filePath := ctx.DefaultPostForm("path", file.Filename)
+	if !auth.RequireScope(ctx, auth.ScopeFilesWrite) || !auth.RequireResource(ctx, filePath) {
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(auth.CurrentUserID(ctx))
 	if err != nil {
-		merrors.InternalServer(ctx, "Error uploading file to storage")
+		merrors.Forbidden(ctx, "User account not found")
 		return
 	}
 
-	// Create file metadata
-	var fileModel models.File
-	if err := ctx.ShouldBindJSON(&fileModel); err != nil {
-		merrors.BadRequest(ctx, "Invalid request payload")
+	fileModel, failure := c.storeNewFile(ctx, user, newFile{
+		name:     file.Filename,
+		path:     filePath,
+		size:     file.Size,
+		content:  file,
+		checksum: ctx.PostForm("checksum_sha256"),
+	})
+	if failure != nil {
+		merrors.Respond(ctx, failure)
 		return
 	}
 
-	fileModel.Url = fileURL // Save the URL returned by cloud storage
+	ctx.JSON(http.StatusCreated, withholdURL(fileModel))
+}
 
-	// Save the file metadata in the repository
-	if err := c.fileRepo.CreateFile(&fileModel); err != nil {
-		merrors.InternalServer(ctx, "Error saving file metadata")
+// CreateFiles uploads several files in one multipart request, such as the contents of a folder.
+// Each file is sent as a file part, and an optional path part per file, in the same order, gives
+// its path below the optional folder field; without one the file's name is used. Files are stored
+// a few at a time, and each is created or fails on its own.
+func (c *FileController) CreateFiles(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeFilesWrite) {
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		merrors.BadRequest(ctx, "File upload failed")
+		return
+	}
+	files := form.File["file"]
+	paths := form.Value["path"]
+	if len(files) == 0 {
+		merrors.Validation(ctx, "Send at least one file")
+		return
+	}
+	if int64(len(files)) > c.batch.BatchMaxFiles {
+		merrors.Validation(ctx, fmt.Sprintf("A bulk upload can hold at most %d files, %d were sent", c.batch.BatchMaxFiles, len(files)))
+		return
+	}
+	if len(paths) > 0 && len(paths) != len(files) {
+		merrors.Validation(ctx, "Send one path per file, or none")
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, fileModel)
+	user, err := c.userRepo.GetUserByID(auth.CurrentUserID(ctx))
+	if err != nil {
+		merrors.Forbidden(ctx, "User account not found")
+		return
+	}
+
+	// Storing, scanning and reading every file can outlast the server's write timeout
+	clearWriteDeadline(ctx)
+
+	// Names and paths are settled up front, so the workers only store files
+	folder := ctx.PostForm("folder")
+	claims := auth.GetClaims(ctx)
+	names := make([]string, len(files))
+	uploads := make([]newFile, len(files))
+	stored := make([]*models.File, len(files))
+	failures := make([]*utils.Error, len(files))
+	for i, file := range files {
+		names[i] = file.Filename
+		if len(paths) > 0 && paths[i] != "" {
+			names[i] = paths[i]
+		}
+
+		// Relative paths cannot climb out of the folder
+		filePath := names[i]
+		if folder != "" {
+			filePath = path.Join(folder, path.Clean("/"+names[i]))
+		}
+		if claims == nil || !claims.AllowsResource(filePath) {
+			failures[i] = merrors.NewError(http.StatusForbidden, "Credential is not allowed to access this path", nil)
+			continue
+		}
+		uploads[i] = newFile{name: file.Filename, path: filePath, size: file.Size, content: file}
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := int64(0); w < min(max(1, c.batch.BatchConcurrency), int64(len(files))); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				stored[i], failures[i] = c.storeNewFile(ctx, user, uploads[i])
+			}
+		}()
+	}
+	for i := range files {
+		if failures[i] == nil {
+			work <- i
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	results := &fileResults{Results: make([]fileResult, 0, len(files))}
+	for i := range files {
+		results.add(names[i], stored[i], failures[i])
+	}
+	ctx.JSON(results.status(), results)
 }
 
 // GetFileByID handles fetching a file's metadata by ID from the repository.
 func (c *FileController) GetFileByID(ctx *gin.Context) {
-	fileID := ctx.Query("id")
+	fileID := ctx.Param("id")
 
 	if fileID == "" {
 		merrors.BadRequest(ctx, "File ID is required")
@@ -91,36 +580,486 @@ func (c *FileController) GetFileByID(ctx *gin.Context) {
 		return
 	}
 
+	if !c.authorizeFile(ctx, file, auth.ScopeFilesRead, "read") {
+		return
+	}
+
+	withholdURL(file)
+
+	if file.Tags, err = c.fileRepo.ListFileTags(file.ID); err != nil {
+		merrors.InternalServer(ctx, "Error retrieving file tags")
+		return
+	}
+	if file.Metadata, err = c.fileRepo.GetFileMetadata(file.ID); err != nil {
+		merrors.InternalServer(ctx, "Error retrieving file metadata")
+		return
+	}
+
 	ctx.JSON(http.StatusOK, file)
 }
 
+// DownloadFile streams a file's content once it has passed the malware scan. It is read through
+// the storage layer, so replicas fall back to the next healthy backend and files moved to the
+// cold tier are read from there.
+func (c *FileController) DownloadFile(ctx *gin.Context) {
+	fileID := ctx.Param("id")
+
+	if fileID == "" {
+		merrors.BadRequest(ctx, "File ID is required")
+		return
+	}
+
+	file, err := c.fileRepo.GetFileByID(fileID)
+	if err != nil {
+		merrors.NotFound(ctx, "File not found")
+		return
+	}
+
+	if !c.authorizeFile(ctx, file, auth.ScopeFilesRead, "read") || !requireClean(ctx, file) {
+		return
+	}
+
+	// Lifecycle rules can act on files that have not been downloaded for a while
+	if err := c.fileRepo.TouchFileAccess(file.ID, time.Now(), accessTouchInterval); err != nil {
+		log.Printf("Failed to record access to file %s: %v", file.ID, err)
+	}
+
+	content, err := c.storage.Open(ctx, file.StorageKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		merrors.NotFound(ctx, "File content not found in storage")
+		return
+	} else if err != nil {
+		merrors.InternalServer(ctx, "Error reading file from storage")
+		return
+	}
+	defer content.Close()
+
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Large files take longer to send than the server's write timeout allows
+	clearWriteDeadline(ctx)
+	ctx.DataFromReader(http.StatusOK, file.Size, contentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// GetThumbnail streams a scaled-down copy of a clean image. The size query parameter picks one
+// of the configured sizes and defaults to the smallest. Thumbnails are generated in the
+// background, so a recently uploaded image answers 202 until they are ready.
+func (c *FileController) GetThumbnail(ctx *gin.Context) {
+	fileID := ctx.Param("id")
+
+	if fileID == "" {
+		merrors.BadRequest(ctx, "File ID is required")
+		return
+	}
+
+	file, err := c.fileRepo.GetFileByID(fileID)
+	if err != nil {
+		merrors.NotFound(ctx, "File not found")
+		return
+	}
+
+	if !c.authorizeFile(ctx, file, auth.ScopeFilesRead, "read") || !requireClean(ctx, file) {
+		return
+	}
+
+	size, ok := c.thumbnailSize(ctx.Query("size"))
+	if !ok {
+		merrors.Validation(ctx, fmt.Sprintf("Thumbnail size must be one of %v", c.thumbnails.Sizes))
+		return
+	}
+
+	switch file.ThumbnailStatus {
+	case models.ThumbnailReady:
+	case models.ThumbnailPending:
+		c.thumbnailPending(ctx)
+		return
+	default:
+		merrors.NotFound(ctx, "File has no thumbnail")
+		return
+	}
+
+	key := models.ThumbnailKeyFor(file.StorageKey, size)
+	info, err := c.storage.Stat(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		// Thumbnails left behind by a storage migration, or sizes added since they were generated,
+		// are missing; generate them again
+		if err := c.fileRepo.UpdateThumbnailStatus(file.ID, models.ThumbnailPending); err != nil {
+			merrors.InternalServer(ctx, "Error queueing thumbnail generation")
+			return
+		}
+		c.thumbnailPending(ctx)
+		return
+	} else if err != nil {
+		merrors.InternalServer(ctx, "Error reading thumbnail from storage")
+		return
+	}
+
+	content, err := c.storage.Open(ctx, key)
+	if err != nil {
+		merrors.InternalServer(ctx, "Error reading thumbnail from storage")
+		return
+	}
+	defer content.Close()
+
+	ctx.Header("Cache-Control", "private, max-age=86400")
+	ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, content, nil)
+}
+
+// thumbnailSize parses the requested thumbnail size, which must be one of the configured sizes.
+// An empty size selects the smallest.
+func (c *FileController) thumbnailSize(value string) (int, bool) {
+	if len(c.thumbnails.Sizes) == 0 {
+		return 0, false
+	}
+
+	if value == "" {
+		smallest := c.thumbnails.Sizes[0]
+		for _, size := range c.thumbnails.Sizes {
+			smallest = min(smallest, size)
+		}
+		return smallest, true
+	}
+
+	requested, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	for _, size := range c.thumbnails.Sizes {
+		if size == requested {
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+// thumbnailPending tells the client to retry once the thumbnail generator has run.
+func (c *FileController) thumbnailPending(ctx *gin.Context) {
+	ctx.Header("Retry-After", strconv.Itoa(int(c.thumbnails.Interval.Seconds())))
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Thumbnail is being generated"})
+}
+
+// imageRequest is a parsed on-the-fly image transformation.
+type imageRequest struct {
+	options imaging.Options
+	format  string
+	quality int
+}
+
+// TransformImage streams a clean image resized, cropped or converted as the query asks:
+// w and h in pixels, fit ("contain", "cover" or "fill"), crop ("x,y,width,height" in source
+// pixels), fmt ("jpeg", "png" or "gif") and q (JPEG quality, 1 to 100). Results are cached in
+// storage next to the file, keyed by its content and the parameters.
+func (c *FileController) TransformImage(ctx *gin.Context) {
+	fileID := ctx.Param("id")
+
+	if fileID == "" {
+		merrors.BadRequest(ctx, "File ID is required")
+		return
+	}
+
+	file, err := c.fileRepo.GetFileByID(fileID)
+	if err != nil {
+		merrors.NotFound(ctx, "File not found")
+		return
+	}
+
+	if !c.authorizeFile(ctx, file, auth.ScopeFilesRead, "read") || !requireClean(ctx, file) {
+		return
+	}
+
+	if !imaging.IsSupported(file.ContentType) {
+		merrors.Validation(ctx, "File is not a supported image")
+		return
+	}
+
+	req, err := c.parseImageRequest(ctx, file)
+	if err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	// The content of a file never changes, so its checksum versions the cache
+	version := file.ChecksumSHA256
+	if version == "" {
+		version = file.ID + "@" + file.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s|w=%d|h=%d|fit=%s|crop=%v|fmt=%s|q=%d", version,
+		req.options.Width, req.options.Height, req.options.Fit, req.options.Crop, req.format, req.quality)))
+	etag := hex.EncodeToString(digest[:16])
+	cacheHeaders := func() {
+		ctx.Header("ETag", `"`+etag+`"`)
+		ctx.Header("Cache-Control", "private, max-age=86400")
+	}
+
+	if ctx.GetHeader("If-None-Match") == `"`+etag+`"` {
+		cacheHeaders()
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	key := models.DerivedKeyFor(file.StorageKey, "img-"+etag)
+	if content, err := c.storage.Open(ctx, key); err == nil {
+		defer content.Close()
+		cacheHeaders()
+		ctx.DataFromReader(http.StatusOK, -1, imaging.ContentType(req.format), content, nil)
+		return
+	} else if !errors.Is(err, storage.ErrObjectNotFound) {
+		log.Printf("Failed to read cached image %s, transforming again: %v", key, err)
+	}
+
+	data, err := c.transformImage(ctx, file, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			merrors.ServiceUnavailable(ctx, "Request cancelled while waiting to transform the image")
+		case imaging.Unprocessable(err):
+			merrors.Validation(ctx, err.Error())
+		default:
+			merrors.InternalServer(ctx, "Error transforming image")
+		}
+		return
+	}
+
+	// Caching is best effort; the next request simply transforms the image again
+	opts := storage.PutOptions{Size: int64(len(data)), ContentType: imaging.ContentType(req.format)}
+	if _, err := c.storage.Put(ctx, key, bytes.NewReader(data), opts); err != nil {
+		log.Printf("Failed to cache transformed image %s: %v", key, err)
+	}
+
+	cacheHeaders()
+	ctx.Data(http.StatusOK, imaging.ContentType(req.format), data)
+}
+
+// transformImage decodes a file's image, transforms it and returns it encoded. Only a limited
+// number of images are decoded at once, since each can take a lot of memory.
+func (c *FileController) transformImage(ctx *gin.Context, file *models.File, req imageRequest) ([]byte, error) {
+	select {
+	case c.imageSlots <- struct{}{}:
+		defer func() { <-c.imageSlots }()
+	case <-ctx.Request.Context().Done():
+		return nil, context.Canceled
+	}
+
+	content, err := c.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	img, _, err := imaging.Decode(content, c.images.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	if img, err = imaging.Transform(img, req.options); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, req.format, req.quality); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseImageRequest reads and validates the transformation parameters of a request. Parameters
+// that make no difference to the output are normalized, so equivalent requests share a cache entry.
+func (c *FileController) parseImageRequest(ctx *gin.Context, file *models.File) (imageRequest, error) {
+	var req imageRequest
+
+	dimension := func(name string) (int, error) {
+		value := ctx.Query(name)
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || int64(n) > c.images.MaxDimension {
+			return 0, fmt.Errorf("%s must be between 1 and %d", name, c.images.MaxDimension)
+		}
+		return n, nil
+	}
+
+	var err error
+	if req.options.Width, err = dimension("w"); err != nil {
+		return req, err
+	}
+	if req.options.Height, err = dimension("h"); err != nil {
+		return req, err
+	}
+
+	req.options.Fit = ctx.DefaultQuery("fit", imaging.FitContain)
+	switch req.options.Fit {
+	case imaging.FitContain, imaging.FitCover, imaging.FitFill:
+	default:
+		return req, errors.New("fit must be one of contain, cover or fill")
+	}
+
+	if crop := ctx.Query("crop"); crop != "" {
+		var x, y, width, height int
+		if _, err := fmt.Sscanf(crop, "%d,%d,%d,%d", &x, &y, &width, &height); err != nil || x < 0 || y < 0 || width < 1 || height < 1 {
+			return req, errors.New("crop must be x,y,width,height with a positive width and height")
+		}
+		req.options.Crop = image.Rect(x, y, x+width, y+height)
+	}
+
+	// Without an explicit format the source's is kept where it can be encoded
+	req.format = strings.TrimPrefix(strings.ToLower(ctx.Query("fmt")), "image/")
+	switch req.format {
+	case "":
+		req.format = strings.TrimPrefix(file.ContentType, "image/")
+		if req.format == "webp" {
+			req.format = imaging.FormatPNG
+		}
+	case "jpg":
+		req.format = imaging.FormatJPEG
+	case imaging.FormatJPEG, imaging.FormatPNG, imaging.FormatGIF:
+	default:
+		return req, errors.New("fmt must be one of jpeg, png or gif")
+	}
+
+	if req.format == imaging.FormatJPEG {
+		req.quality = int(c.images.DefaultQuality)
+		if value := ctx.Query("q"); value != "" {
+			if req.quality, err = strconv.Atoi(value); err != nil || req.quality < 1 || req.quality > 100 {
+				return req, errors.New("q must be between 1 and 100")
+			}
+		}
+	}
+	return req, nil
+}
+
+// shareFileRequest is the payload for granting another user access to a file.
+type shareFileRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	CanRead   bool   `json:"can_read"`
+	CanWrite  bool   `json:"can_write"`
+	CanDelete bool   `json:"can_delete"`
+}
+
+// ShareFile lets the owner of a clean file grant another user access to it.
+func (c *FileController) ShareFile(ctx *gin.Context) {
+	fileID := ctx.Param("id")
+
+	if fileID == "" {
+		merrors.BadRequest(ctx, "File ID is required")
+		return
+	}
+
+	var req shareFileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	file, err := c.fileRepo.GetFileByID(fileID)
+	if err != nil {
+		merrors.NotFound(ctx, "File not found")
+		return
+	}
+
+	if !auth.RequireScope(ctx, auth.ScopeFilesWrite) || !auth.RequireResource(ctx, file.Path) {
+		return
+	}
+	if !file.IsOwner(auth.CurrentUserID(ctx)) {
+		merrors.Forbidden(ctx, "Only the owner can share this file")
+		return
+	}
+	if !requireClean(ctx, file) {
+		return
+	}
+
+	if _, err := c.userRepo.GetUserByID(req.UserID); err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+
+	permission := models.NewPermission(uuid.New().String(), file.ID, req.UserID, req.CanRead, req.CanWrite, req.CanDelete)
+	if err := c.permissionRepo.GrantPermission(permission); err != nil {
+		merrors.Conflict(ctx, "File is already shared with this user")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, permission)
+}
+
+// updateFileRequest is the payload for renaming, moving or tagging a file. Empty fields are left
+// unchanged; tags replace the file's tags when present.
+type updateFileRequest struct {
+	Name string    `json:"name"`
+	Path string    `json:"path"`
+	Tags *[]string `json:"tags"`
+}
+
 // UpdateFile handles updating an existing file's metadata in the repository.
 func (c *FileController) UpdateFile(ctx *gin.Context) {
-	fileID := ctx.Query("id")
+	fileID := ctx.Param("id")
 
 	if fileID == "" {
 		merrors.BadRequest(ctx, "File ID is required")
 		return
 	}
 
-	var file models.File
-	if err := ctx.ShouldBindJSON(&file); err != nil {
+	existing, err := c.fileRepo.GetFileByID(fileID)
+	if err != nil {
+		merrors.NotFound(ctx, "File not found")
+		return
+	}
+
+	if !c.authorizeFile(ctx, existing, auth.ScopeFilesWrite, "write") {
+		return
+	}
+
+	var req updateFileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		merrors.BadRequest(ctx, "Invalid request payload")
 		return
 	}
 
-	file.ID = fileID
-	if err := c.fileRepo.UpdateFile(&file); err != nil {
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	if req.Path != "" {
+		existing.Path = req.Path
+	}
+
+	// Moving a file must also stay within the credential's allowed paths
+	if !auth.RequireResource(ctx, existing.Path) {
+		return
+	}
+
+	var tags []string
+	if req.Tags != nil {
+		if tags, err = normalizeTags(*req.Tags); err != nil {
+			merrors.Validation(ctx, err.Error())
+			return
+		}
+	}
+
+	if err := c.fileRepo.UpdateFile(existing); err != nil {
 		merrors.InternalServer(ctx, "Error updating file metadata")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, file)
+	if req.Tags != nil {
+		if err := c.fileRepo.SetFileTags(existing.ID, tags); err != nil {
+			merrors.InternalServer(ctx, "Error updating file tags")
+			return
+		}
+		existing.Tags = tags
+	}
+
+	ctx.JSON(http.StatusOK, withholdURL(existing))
 }
 
 // DeleteFile handles the deletion of a file by ID, deletes the file from cloud storage, and removes metadata from the repository.
 func (c *FileController) DeleteFile(ctx *gin.Context) {
-	fileID := ctx.Query("id")
+	fileID := ctx.Param("id")
 
 	if fileID == "" {
 		merrors.BadRequest(ctx, "File ID is required")
@@ -134,10 +1073,26 @@ func (c *FileController) DeleteFile(ctx *gin.Context) {
 		return
 	}
 
-	// Delete the file from cloud storage
-	err = c.storage.DeleteFile(ctx, file.Url)
-	if err != nil {
-		merrors.InternalServer(ctx, "Error deleting file from storage")
+	if !c.authorizeFile(ctx, file, auth.ScopeFilesWrite, "delete") {
+		return
+	}
+
+	// Hide the file first, so a failure below never leaves a visible row without content
+	if err := c.fileRepo.MarkFileDeleting(fileID); err != nil {
+		merrors.InternalServer(ctx, "Error deleting file metadata")
+		return
+	}
+
+	// Delete the file and its thumbnails from cloud storage, thumbnails first so a retry by
+	// reconciliation finds them
+	if err := storage.DeletePrefix(ctx, c.storage, models.DerivedKeyPrefix(file.StorageKey)); err != nil {
+		log.Printf("Failed to delete derived objects of file %s from storage, leaving them for reconciliation: %v", fileID, err)
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "File deleted, storage cleanup is pending"})
+		return
+	}
+	if err := c.storage.DeleteFile(ctx, file.StorageKey); err != nil {
+		log.Printf("Failed to delete file %s from storage, leaving it for reconciliation: %v", fileID, err)
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "File deleted, storage cleanup is pending"})
 		return
 	}
 
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/config"
+	"github.com/souvik03-136/Go-Store/internal/fetch"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// importListLimit is how many recent import jobs are listed.
+const importListLimit = 100
+
+// ImportController queues imports of files from remote URLs and reports on them. The files are
+// downloaded in the background by jobs.URLImporter.
+type ImportController struct {
+	importRepo repository.ImportJobStore
+	client     *fetch.Client
+	limits     config.ImportConfig
+}
+
+// NewImportController creates a new instance of ImportController. The client only checks URLs
+// here; it is the importer's client that downloads them.
+func NewImportController(importRepo repository.ImportJobStore, client *fetch.Client, limits config.ImportConfig) *ImportController {
+	return &ImportController{importRepo: importRepo, client: client, limits: limits}
+}
+
+// importRequest is the payload for importing a file from a URL.
+type importRequest struct {
+	URL  string `json:"url" binding:"required"`
+	Path string `json:"path"` // Path of the new file, the last segment of the URL's path by default
+}
+
+// CreateImport queues a file to be downloaded from a URL and stored as a new file. It answers 202
+// with the import job, whose status and progress can be followed at the Location it returns.
+func (c *ImportController) CreateImport(ctx *gin.Context) {
+	var req importRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	u, err := c.client.CheckURL(req.URL)
+	if errors.Is(err, fetch.ErrBlockedAddress) {
+		merrors.Validation(ctx, "The URL leads to an address that imports are not allowed to reach")
+		return
+	}
+	if err != nil {
+		merrors.Validation(ctx, "Only http and https URLs without credentials can be imported")
+		return
+	}
+
+	filePath := req.Path
+	if filePath == "" {
+		filePath = importName(u.Path)
+	}
+	if !auth.RequireScope(ctx, auth.ScopeFilesWrite) || !auth.RequireResource(ctx, filePath) {
+		return
+	}
+
+	userID := auth.CurrentUserID(ctx)
+	if c.limits.MaxActive > 0 {
+		active, err := c.importRepo.CountActiveImportJobs(userID)
+		if err != nil {
+			merrors.InternalServer(ctx, "Error retrieving import jobs")
+			return
+		}
+		if int64(active) >= c.limits.MaxActive {
+			merrors.Forbidden(ctx, fmt.Sprintf("At most %d imports can be waiting or running at once", c.limits.MaxActive))
+			return
+		}
+	}
+
+	job := models.NewImportJob(uuid.New().String(), userID, u.String(), filePath)
+	if err := c.importRepo.CreateImportJob(job); err != nil {
+		merrors.InternalServer(ctx, "Error saving import job")
+		return
+	}
+
+	ctx.Header("Location", "/v1/imports/"+job.ID)
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+// GetImport reports the status and progress of one of the caller's import jobs.
+func (c *ImportController) GetImport(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeFilesRead) {
+		return
+	}
+
+	job, err := c.importRepo.GetImportJob(ctx.Param("id"))
+	if err != nil || job.OwnerID != auth.CurrentUserID(ctx) {
+		merrors.NotFound(ctx, "Import job not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
+
+// ListImports lists the caller's most recent import jobs, newest first.
+func (c *ImportController) ListImports(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeFilesRead) {
+		return
+	}
+
+	jobs, err := c.importRepo.ListImportJobsByOwner(auth.CurrentUserID(ctx), importListLimit)
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving import jobs")
+		return
+	}
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+// importName names an imported file after the last segment of its URL's path.
+func importName(urlPath string) string {
+	name := path.Base(urlPath)
+	if name == "." || name == "/" {
+		return "download"
+	}
+	return name
+}
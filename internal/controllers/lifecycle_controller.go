@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// lifecycleReportLimit bounds how many files the dry-run report lists per rule.
+const lifecycleReportLimit = 100
+
+// LifecycleController lets administrators manage the rules that move old files to cheaper
+// storage or expire them.
+type LifecycleController struct {
+	ruleRepo    repository.LifecycleRuleStore
+	fileRepo    repository.FileStore
+	coldBackend string
+}
+
+// NewLifecycleController creates a new instance of LifecycleController. The cold backend is
+// LIFECYCLE_COLD_PROVIDER, empty if move_to_cold rules cannot be used.
+func NewLifecycleController(ruleRepo repository.LifecycleRuleStore, fileRepo repository.FileStore, coldBackend string) *LifecycleController {
+	return &LifecycleController{ruleRepo: ruleRepo, fileRepo: fileRepo, coldBackend: coldBackend}
+}
+
+// lifecycleRuleRequest is the payload for creating a lifecycle rule. Rules are dry runs unless
+// dry_run is explicitly false.
+type lifecycleRuleRequest struct {
+	Name            string `json:"name" binding:"required"`
+	Action          string `json:"action" binding:"required"`
+	StorageClass    string `json:"storage_class"`
+	OlderThanDays   int    `json:"older_than_days"`
+	NotAccessedDays int    `json:"not_accessed_days"`
+	MinSize         int64  `json:"min_size"`
+	Folder          string `json:"folder"`
+	Tag             string `json:"tag"`
+	DryRun          *bool  `json:"dry_run"`
+}
+
+// lifecycleReportFile is a file listed in the dry-run report.
+type lifecycleReportFile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// lifecycleReportEntry lists the files a rule would act on.
+type lifecycleReportEntry struct {
+	Rule      *models.LifecycleRule `json:"rule"`
+	Files     []lifecycleReportFile `json:"files"`
+	Bytes     int64                 `json:"bytes"`
+	Truncated bool                  `json:"truncated"` // More files match than are listed
+}
+
+// CreateRule adds a lifecycle rule.
+func (c *LifecycleController) CreateRule(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	var req lifecycleRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	rule := models.NewLifecycleRule(uuid.New().String(), req.Name, req.Action, auth.CurrentUserID(ctx))
+	rule.StorageClass = req.StorageClass
+	rule.OlderThanDays = req.OlderThanDays
+	rule.NotAccessedDays = req.NotAccessedDays
+	rule.MinSize = req.MinSize
+	rule.Folder = req.Folder
+	rule.Tag = req.Tag
+	rule.DryRun = req.DryRun == nil || *req.DryRun
+
+	if err := rule.Validate(); err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+	if rule.Action == models.LifecycleMoveToCold && c.coldBackend == "" {
+		merrors.Validation(ctx, "No cold storage provider is configured")
+		return
+	}
+
+	if err := c.ruleRepo.CreateLifecycleRule(rule); err != nil {
+		merrors.InternalServer(ctx, "Error creating lifecycle rule")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+// ListRules returns every lifecycle rule.
+func (c *LifecycleController) ListRules(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	rules, err := c.ruleRepo.ListLifecycleRules()
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving lifecycle rules")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rules)
+}
+
+// DeleteRule removes a lifecycle rule.
+func (c *LifecycleController) DeleteRule(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	if err := c.ruleRepo.DeleteLifecycleRule(ctx.Param("id")); err != nil {
+		merrors.NotFound(ctx, "Lifecycle rule not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Lifecycle rule deleted successfully"})
+}
+
+// Report lists the files each rule would act on if it ran now, without changing anything.
+func (c *LifecycleController) Report(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	rules, err := c.ruleRepo.ListLifecycleRules()
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving lifecycle rules")
+		return
+	}
+
+	now := time.Now()
+	report := make([]lifecycleReportEntry, 0, len(rules))
+	for _, rule := range rules {
+		files, err := c.fileRepo.ListFilesForLifecycleRule(rule, c.coldBackend, now, lifecycleReportLimit+1)
+		if err != nil {
+			merrors.InternalServer(ctx, "Error evaluating lifecycle rules")
+			return
+		}
+
+		entry := lifecycleReportEntry{Rule: rule, Files: []lifecycleReportFile{}, Truncated: len(files) > lifecycleReportLimit}
+		if entry.Truncated {
+			files = files[:lifecycleReportLimit]
+		}
+		for _, file := range files {
+			entry.Files = append(entry.Files, lifecycleReportFile{ID: file.ID, Name: file.Name, Path: file.Path, Size: file.Size})
+			entry.Bytes += file.Size
+		}
+		report = append(report, entry)
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
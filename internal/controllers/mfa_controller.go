@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+type MFAController struct {
+	userRepo repository.UserStore
+	mfaRepo  repository.MFAStore
+}
+
+// NewMFAController creates a new instance of MFAController.
+func NewMFAController(userRepo repository.UserStore, mfaRepo repository.MFAStore) *MFAController {
+	return &MFAController{userRepo: userRepo, mfaRepo: mfaRepo}
+}
+
+// mfaCodeRequest is the payload for operations confirmed with a TOTP or recovery code.
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// EnrollMFA generates a new TOTP secret for the authenticated user.
+// MFA stays disabled until a code from the secret is confirmed with EnableMFA.
+func (c *MFAController) EnrollMFA(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(userID)
+	if err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+	if user.IsAnonymous {
+		merrors.Forbidden(ctx, "Register your account before enabling MFA")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		merrors.InternalServer(ctx, "Failed to generate MFA secret")
+		return
+	}
+
+	if err := c.mfaRepo.SaveMFASecret(models.NewMFASettings(userID, secret)); err != nil {
+		merrors.Conflict(ctx, "MFA is already enabled")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(user.Username, secret),
+	})
+}
+
+// EnableMFA verifies a code from the enrolled secret, turns MFA on and returns
+// single-use recovery codes. The recovery codes are only shown in this response.
+func (c *MFAController) EnableMFA(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	settings, err := c.mfaRepo.GetMFA(userID)
+	if err != nil {
+		merrors.NotFound(ctx, "MFA enrollment not found")
+		return
+	}
+	if settings.Enabled {
+		merrors.Conflict(ctx, "MFA is already enabled")
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(settings.Secret, req.Code, time.Now())
+	if !ok {
+		merrors.Validation(ctx, "Invalid verification code")
+		return
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		merrors.InternalServer(ctx, "Failed to generate recovery codes")
+		return
+	}
+
+	if err := c.mfaRepo.EnableMFA(userID, step, hashes); err != nil {
+		merrors.InternalServer(ctx, "Error enabling MFA")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "MFA enabled successfully",
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the authenticated user's recovery codes after verifying a TOTP code.
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	settings, err := c.mfaRepo.GetMFA(userID)
+	if err != nil || !settings.Enabled {
+		merrors.NotFound(ctx, "MFA is not enabled")
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(settings.Secret, req.Code, time.Now())
+	if !ok || c.mfaRepo.ConsumeTOTPStep(userID, step) != nil {
+		merrors.Validation(ctx, "Invalid verification code")
+		return
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		merrors.InternalServer(ctx, "Failed to generate recovery codes")
+		return
+	}
+
+	if err := c.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		merrors.InternalServer(ctx, "Error saving recovery codes")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA turns MFA off for the authenticated user after verifying a TOTP or recovery code.
+func (c *MFAController) DisableMFA(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	settings, err := c.mfaRepo.GetMFA(userID)
+	if err != nil || !settings.Enabled {
+		merrors.NotFound(ctx, "MFA is not enabled")
+		return
+	}
+
+	if !verifyMFACode(c.mfaRepo, settings, req.Code) {
+		merrors.Validation(ctx, "Invalid verification code")
+		return
+	}
+
+	if err := c.mfaRepo.DeleteMFA(userID); err != nil {
+		merrors.InternalServer(ctx, "Error disabling MFA")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "MFA disabled successfully"})
+}
+
+// ResetMFA lets an administrator remove another user's MFA, e.g. after a lost device.
+func (c *MFAController) ResetMFA(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	userID := ctx.Param("id")
+	if userID == "" {
+		merrors.BadRequest(ctx, "User ID is required")
+		return
+	}
+
+	if err := c.mfaRepo.DeleteMFA(userID); err != nil {
+		merrors.InternalServer(ctx, "Error resetting MFA")
+		return
+	}
+
+	log.Printf("MFA for user %s reset by %s", userID, auth.CurrentUserID(ctx))
+	ctx.JSON(http.StatusOK, gin.H{"message": "MFA reset successfully"})
+}
+
+// verifyMFACode accepts either a current TOTP code that has not been used before
+// or an unused recovery code, consuming whichever one matched.
+func verifyMFACode(mfaRepo repository.MFAStore, settings *models.MFASettings, code string) bool {
+	if step, ok := auth.ValidateTOTP(settings.Secret, code, time.Now()); ok {
+		return mfaRepo.ConsumeTOTPStep(settings.UserID, step) == nil
+	}
+	return mfaRepo.ConsumeRecoveryCode(settings.UserID, auth.HashRecoveryCode(code)) == nil
+}
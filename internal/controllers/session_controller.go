@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/merrors"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// SessionController lets users see where they are signed in and sign devices out.
+type SessionController struct {
+	sessionRepo repository.SessionStore
+}
+
+// NewSessionController creates a new instance of SessionController.
+func NewSessionController(sessionRepo repository.SessionStore) *SessionController {
+	return &SessionController{sessionRepo: sessionRepo}
+}
+
+// sessionResponse marks which of the listed sessions made the request.
+type sessionResponse struct {
+	*models.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions returns the authenticated user's active sessions.
+func (c *SessionController) ListSessions(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersRead) {
+		return
+	}
+
+	sessions, err := c.sessionRepo.ListActiveSessionsByUser(userID)
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving sessions")
+		return
+	}
+
+	currentID := auth.CurrentSessionID(ctx)
+	res := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, sessionResponse{Session: session, Current: session.ID == currentID})
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RevokeSession signs one of the authenticated user's sessions out. Tokens issued for the
+// session are rejected from then on.
+func (c *SessionController) RevokeSession(ctx *gin.Context) {
+	userID := auth.CurrentUserID(ctx)
+	if !auth.RequireScope(ctx, auth.ScopeUsersWrite) {
+		return
+	}
+
+	sessionID := ctx.Param("id")
+	if sessionID == "" {
+		merrors.BadRequest(ctx, "Session ID is required")
+		return
+	}
+
+	if err := c.sessionRepo.RevokeSession(sessionID, userID); err != nil {
+		merrors.NotFound(ctx, "Session not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// Logout revokes the session of the token used for the request.
+func (c *SessionController) Logout(ctx *gin.Context) {
+	if sessionID := auth.CurrentSessionID(ctx); sessionID != "" {
+		if err := c.sessionRepo.RevokeSession(sessionID, auth.CurrentUserID(ctx)); err != nil {
+			merrors.InternalServer(ctx, "Error ending session")
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "User logged out successfully",
+	})
+}
@@ -4,30 +4,58 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/auth"
+	"github.com/souvik03-136/Go-Store/internal/config"
 	"github.com/souvik03-136/Go-Store/internal/merrors"
 	"github.com/souvik03-136/Go-Store/internal/models"
 	"github.com/souvik03-136/Go-Store/internal/repository"
 )
 
 type UserController struct {
-	userRepo *repository.UserRepository
+	userRepo  repository.UserStore
+	usageRepo repository.UsageStore
+	quotas    config.QuotaConfig
 }
 
 // NewUserController creates a new instance of UserController.
-func NewUserController(userRepo *repository.UserRepository) *UserController {
-	return &UserController{userRepo: userRepo}
+func NewUserController(userRepo repository.UserStore, usageRepo repository.UsageStore, cfg *config.Config) *UserController {
+	return &UserController{userRepo: userRepo, usageRepo: usageRepo, quotas: cfg.Quota}
+}
+
+// authorizeUser checks that the credential may act on a user account. Users need the given
+// scope to act on their own account and the users:admin scope to act on anyone else's.
+func authorizeUser(ctx *gin.Context, userID, scope string) bool {
+	if userID == auth.CurrentUserID(ctx) {
+		return auth.RequireScope(ctx, scope)
+	}
+	return auth.RequireScope(ctx, auth.ScopeUsersAdmin)
+}
+
+// createUserRequest is the payload accepted when registering a user.
+type createUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
 
 // CreateUser handles user registration.
 func (c *UserController) CreateUser(ctx *gin.Context) {
-	var user models.User
-
-	if err := ctx.ShouldBindJSON(&user); err != nil {
+	var req createUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		merrors.BadRequest(ctx, "Invalid request payload")
 		return
 	}
 
-	if err := c.userRepo.CreateUser(&user); err != nil {
+	// NewUser hashes the password and always assigns the "user" role;
+	// roles are only ever granted by an administrator
+	user, err := models.NewUser(uuid.New().String(), req.Username, req.Email, req.Password)
+	if err != nil {
+		merrors.Validation(ctx, err.Error())
+		return
+	}
+
+	if err := c.userRepo.CreateUser(user); err != nil {
 		merrors.InternalServer(ctx, "Error creating user")
 		return
 	}
@@ -37,13 +65,17 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 
 // GetUserByID handles fetching a user by their ID.
 func (c *UserController) GetUserByID(ctx *gin.Context) {
-	userID := ctx.Query("id")
+	userID := ctx.Param("id")
 
 	if userID == "" {
 		merrors.BadRequest(ctx, "User ID is required")
 		return
 	}
 
+	if !authorizeUser(ctx, userID, auth.ScopeUsersRead) {
+		return
+	}
+
 	user, err := c.userRepo.GetUserByID(userID)
 	if err != nil {
 		merrors.NotFound(ctx, "User not found")
@@ -55,19 +87,45 @@ func (c *UserController) GetUserByID(ctx *gin.Context) {
 
 // UpdateUser handles updating a user's information.
 func (c *UserController) UpdateUser(ctx *gin.Context) {
-	userID := ctx.Query("id")
+	userID := ctx.Param("id")
 
 	if userID == "" {
 		merrors.BadRequest(ctx, "User ID is required")
 		return
 	}
 
+	if !authorizeUser(ctx, userID, auth.ScopeUsersWrite) {
+		return
+	}
+
+	existing, err := c.userRepo.GetUserByID(userID)
+	if err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+
 	var user models.User
 	if err := ctx.ShouldBindJSON(&user); err != nil {
 		merrors.BadRequest(ctx, "Invalid request payload")
 		return
 	}
 
+	// Only administrators may change roles; the password is never part of the payload
+	if user.Role == "" || !auth.GetClaims(ctx).HasScope(auth.ScopeUsersAdmin) {
+		user.Role = existing.Role
+	}
+	user.Password = existing.Password
+
+	// Verification is only ever set by the email flow and is lost when the address changes
+	user.EmailVerifiedAt = existing.EmailVerifiedAt
+	if user.Email != existing.Email {
+		user.EmailVerifiedAt = nil
+	}
+
+	// Anonymous users become registered through /v1/auth/anonymous/upgrade only
+	user.IsAnonymous = existing.IsAnonymous
+	user.ExpiresAt = existing.ExpiresAt
+
 	user.ID = userID
 	if err := c.userRepo.UpdateUser(&user); err != nil {
 		merrors.InternalServer(ctx, "Error updating user")
@@ -79,13 +137,17 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 
 // DeleteUser handles the deletion of a user by their ID.
 func (c *UserController) DeleteUser(ctx *gin.Context) {
-	userID := ctx.Query("id")
+	userID := ctx.Param("id")
 
 	if userID == "" {
 		merrors.BadRequest(ctx, "User ID is required")
 		return
 	}
 
+	if !authorizeUser(ctx, userID, auth.ScopeUsersWrite) {
+		return
+	}
+
 	if err := c.userRepo.DeleteUser(userID); err != nil {
 		merrors.InternalServer(ctx, "Error deleting user")
 		return
@@ -93,3 +155,79 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
+
+// quotaRequest is the payload for setting a user's quota overrides. A null limit restores
+// the default for the user's role, and zero means unlimited.
+type quotaRequest struct {
+	MaxBytes *int64 `json:"max_bytes"`
+	MaxFiles *int64 `json:"max_files"`
+}
+
+// GetUsage returns how much a user stores and the quota that applies to them.
+func (c *UserController) GetUsage(ctx *gin.Context) {
+	userID := ctx.Param("id")
+
+	if userID == "" {
+		merrors.BadRequest(ctx, "User ID is required")
+		return
+	}
+
+	if !authorizeUser(ctx, userID, auth.ScopeUsersRead) {
+		return
+	}
+
+	user, err := c.userRepo.GetUserByID(userID)
+	if err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+
+	usage, err := c.usageRepo.GetUsage(userID)
+	if err != nil {
+		merrors.InternalServer(ctx, "Error retrieving storage usage")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"user_id":    userID,
+		"bytes_used": usage.BytesUsed,
+		"file_count": usage.FileCount,
+		"quota":      usage.EffectiveQuota(c.quotas.ForUser(user)),
+	})
+}
+
+// SetQuota lets an administrator override a user's storage quota.
+func (c *UserController) SetQuota(ctx *gin.Context) {
+	if !auth.RequireScope(ctx, auth.ScopeUsersAdmin) {
+		return
+	}
+
+	userID := ctx.Param("id")
+	if userID == "" {
+		merrors.BadRequest(ctx, "User ID is required")
+		return
+	}
+
+	var req quotaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		merrors.BadRequest(ctx, "Invalid request payload")
+		return
+	}
+
+	if (req.MaxBytes != nil && *req.MaxBytes < 0) || (req.MaxFiles != nil && *req.MaxFiles < 0) {
+		merrors.Validation(ctx, "Quota limits cannot be negative")
+		return
+	}
+
+	if _, err := c.userRepo.GetUserByID(userID); err != nil {
+		merrors.NotFound(ctx, "User not found")
+		return
+	}
+
+	if err := c.usageRepo.SetQuotaOverride(userID, req.MaxBytes, req.MaxFiles); err != nil {
+		merrors.InternalServer(ctx, "Error saving quota")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Quota updated successfully"})
+}
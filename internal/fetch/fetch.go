@@ -0,0 +1,246 @@
+// Package fetch downloads files from remote URLs for imports. Connections are only made to public
+// addresses, checked once DNS has been resolved, so a host name cannot be used to reach the
+// server's own network or a cloud metadata service.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Timeouts for the stages of a download before the body is read.
+const (
+	dialTimeout           = 10 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+)
+
+// Errors downloading a file.
+var (
+	ErrUnsupportedURL   = errors.New("only http and https URLs can be fetched")
+	ErrBlockedAddress   = errors.New("address is not allowed")
+	ErrTooManyRedirects = errors.New("too many redirects")
+	ErrTooLarge         = errors.New("remote file exceeds the size limit")
+)
+
+// reservedNetworks are internal or special-purpose ranges that the net.IP predicates do not
+// cover, including IPv6 ranges that embed IPv4 addresses.
+var reservedNetworks = parseNetworks(
+	"0.0.0.0/8",      // "This" network
+	"100.64.0.0/10",  // Carrier-grade NAT
+	"192.0.0.0/24",   // IETF protocol assignments
+	"198.18.0.0/15",  // Benchmarking
+	"240.0.0.0/4",    // Reserved, including broadcast
+	"64:ff9b::/96",   // NAT64
+	"64:ff9b:1::/48", // Local-use NAT64
+	"2001::/32",      // Teredo
+	"2002::/16",      // 6to4
+	"100::/64",       // Discard-only
+)
+
+// StatusError is returned when the remote server does not respond with the file.
+type StatusError struct {
+	Status string
+}
+
+// Error describes the response.
+func (e *StatusError) Error() string {
+	return "remote server responded " + e.Status
+}
+
+// Options limit what a Client fetches.
+type Options struct {
+	MaxSize         int64         // Largest file fetched, in bytes, zero for no limit
+	Timeout         time.Duration // Limit for a whole download, zero for none
+	MaxRedirects    int
+	AllowedNetworks []*net.IPNet // Ranges fetched from even though they are internal
+}
+
+// Client downloads remote files within its Options.
+type Client struct {
+	http *http.Client
+	opts Options
+}
+
+// NewClient creates a Client. It never uses a proxy from the environment, as the proxy would
+// connect on its behalf to addresses the Client refuses.
+func NewClient(opts Options) *Client {
+	c := &Client{opts: opts}
+
+	dialer := &net.Dialer{Timeout: dialTimeout, Control: c.control}
+	transport := &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    tlsHandshakeTimeout,
+		ResponseHeaderTimeout:  responseHeaderTimeout,
+		MaxResponseHeaderBytes: 1 << 20,
+	}
+	c.http = &http.Client{Transport: transport, CheckRedirect: c.checkRedirect}
+	return c
+}
+
+// ParseNetworks parses CIDR ranges such as "10.1.0.0/16".
+func ParseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// CheckURL parses a URL and checks that it can be fetched: it must be http or https, and a host
+// given as an IP address must be allowed. Host names are checked when they are connected to.
+func (c *Client) CheckURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return nil, ErrUnsupportedURL
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.User != nil {
+		return nil, ErrUnsupportedURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !c.allowed(ip) {
+		return nil, ErrBlockedAddress
+	}
+	return u, nil
+}
+
+// Download fetches a URL into w, calling progress with the bytes received so far and the total
+// size, or -1 if the server did not state it. It returns how many bytes were written.
+func (c *Client) Download(ctx context.Context, raw string, w io.Writer, progress func(downloaded, total int64)) (int64, error) {
+	u, err := c.CheckURL(raw)
+	if err != nil {
+		return 0, err
+	}
+	if c.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "Go-Store-Importer")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, &StatusError{Status: resp.Status}
+	}
+	total := resp.ContentLength
+	if c.opts.MaxSize > 0 && total > c.opts.MaxSize {
+		return 0, ErrTooLarge
+	}
+
+	// The stated length may be missing or wrong, so the body is limited as it is read
+	body := io.Reader(resp.Body)
+	if c.opts.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, c.opts.MaxSize+1)
+	}
+	counter := &progressWriter{w: w, total: total, progress: progress}
+	n, err := io.Copy(counter, body)
+	if err != nil {
+		return n, err
+	}
+	if c.opts.MaxSize > 0 && n > c.opts.MaxSize {
+		return n, ErrTooLarge
+	}
+	return n, nil
+}
+
+// checkRedirect limits how many redirects are followed, and where they may lead. Addresses are
+// checked again when the redirect's host is connected to.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > c.opts.MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	if _, err := c.CheckURL(req.URL.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// control refuses connections to addresses that are not allowed. It runs for every address
+// dialed, after the host name has been resolved, so DNS answers cannot get around the check.
+func (c *Client) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !c.allowed(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// allowed reports whether an address may be connected to: it is public, or in an allowed network.
+func (c *Client) allowed(ip net.IP) bool {
+	for _, network := range c.opts.AllowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return !Internal(ip)
+}
+
+// Internal reports whether an address is loopback, private, link-local, multicast or otherwise
+// not a public unicast address. IPv4 addresses written as IPv6 are judged as IPv4.
+func Internal(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetworks parses CIDR ranges known to be valid.
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks, err := ParseNetworks(cidrs)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
+
+// progressWriter reports the bytes written through it.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(downloaded, total int64)
+}
+
+// Write writes to the underlying writer and reports the progress.
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.progress != nil {
+		p.progress(p.written, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,231 @@
+// Package imaging decodes, resizes and encodes images in pure Go, for thumbnails and other
+// objects derived from uploaded images.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder with image.Decode
+)
+
+// Errors returned when an image cannot be processed. None of them goes away on retry.
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image dimensions exceed the limit")
+	ErrInvalid     = errors.New("invalid image")
+	ErrCropOutside = errors.New("crop region is outside the image")
+)
+
+// Unprocessable reports whether an error means the image itself cannot be processed, as opposed
+// to a failure reading it.
+func Unprocessable(err error) bool {
+	return errors.Is(err, ErrUnsupported) || errors.Is(err, ErrTooLarge) || errors.Is(err, ErrInvalid) || errors.Is(err, ErrCropOutside)
+}
+
+// Output formats, named as image.Decode names the formats it reads.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+)
+
+// Ways an image is fitted to a requested width and height.
+const (
+	FitContain = "contain" // Scale down to fit within the box, keeping the aspect ratio
+	FitCover   = "cover"   // Scale to fill the box, keeping the aspect ratio and cropping the overflow
+	FitFill    = "fill"    // Stretch to exactly the box
+)
+
+// Options describe a transformation. A zero Width or Height is derived from the other using the
+// aspect ratio; with both zero the image keeps its size. An empty Crop keeps the whole image.
+type Options struct {
+	Width  int
+	Height int
+	Fit    string
+	Crop   image.Rectangle // Region of the source image to keep, applied before scaling
+}
+
+// supportedTypes are the content types that can be decoded, mapped to their format names.
+var supportedTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// IsSupported reports whether images of a content type can be decoded.
+func IsSupported(contentType string) bool {
+	_, ok := supportedTypes[contentType]
+	return ok
+}
+
+// ContentType returns the content type of an output format.
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Decode reads an image, refusing ones with more than maxPixels pixels before their pixel data
+// is decoded. Only the header is buffered while the dimensions are checked, so a small file
+// that would decode to a huge bitmap is rejected cheaply. For animated GIFs the first frame is
+// returned. The format name is returned with the image. Errors reading r are returned as they
+// are; content that is not a valid image gives ErrInvalid.
+func Decode(r io.Reader, maxPixels int64) (image.Image, string, error) {
+	// Decoders pass read errors through, so they are recorded to tell them apart from bad content
+	reader := &readErrorRecorder{r: r}
+
+	var header bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(reader, &header))
+	if err != nil {
+		if reader.err != nil {
+			return nil, "", reader.err
+		}
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupported
+		}
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, "", fmt.Errorf("%w: image has no pixels", ErrInvalid)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(io.MultiReader(&header, reader))
+	if err != nil {
+		if reader.err != nil {
+			return nil, "", reader.err
+		}
+		return nil, "", fmt.Errorf("%w: %s: %v", ErrInvalid, format, err)
+	}
+	return img, format, nil
+}
+
+// readErrorRecorder remembers the first error other than io.EOF returned by a reader.
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+// Read reads from the underlying reader, recording any failure
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// Fit scales an image down to fit within maxWidth by maxHeight, keeping its aspect ratio.
+// Images that already fit are returned unchanged; images are never enlarged.
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := fitSize(bounds.Dx(), bounds.Dy(), maxWidth, maxHeight)
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return img
+	}
+	return Resize(img, width, height)
+}
+
+// fitSize returns the size of a width by height image scaled down to fit within maxWidth by
+// maxHeight, keeping its aspect ratio. Sizes that already fit are returned unchanged.
+func fitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+
+	// Scale by whichever side is further over its limit, rounding to the nearest pixel
+	if int64(width)*int64(maxHeight) > int64(height)*int64(maxWidth) {
+		return maxWidth, max(1, int((int64(height)*int64(maxWidth)+int64(width)/2)/int64(width)))
+	}
+	return max(1, int((int64(width)*int64(maxHeight)+int64(height)/2)/int64(height))), maxHeight
+}
+
+// Resize scales an image to exactly width by height pixels.
+func Resize(img image.Image, width, height int) image.Image {
+	return scale(img, img.Bounds(), width, height)
+}
+
+// Transform crops and scales an image as described by opts. Cropping outside the image is an
+// error; a contained image is never enlarged.
+func Transform(img image.Image, opts Options) (image.Image, error) {
+	src := img.Bounds()
+	if !opts.Crop.Empty() {
+		crop := opts.Crop.Add(src.Min).Intersect(src)
+		if crop.Empty() {
+			return nil, ErrCropOutside
+		}
+		src = crop
+	}
+
+	width, height := opts.Width, opts.Height
+	switch {
+	case width == 0 && height == 0:
+		width, height = src.Dx(), src.Dy()
+	case width == 0:
+		width = max(1, int(int64(src.Dx())*int64(height)/int64(src.Dy())))
+	case height == 0:
+		height = max(1, int(int64(src.Dy())*int64(width)/int64(src.Dx())))
+	}
+
+	switch opts.Fit {
+	case FitFill:
+	case FitCover:
+		// Keep the centred part of the source with the box's aspect ratio
+		if int64(src.Dx())*int64(height) > int64(src.Dy())*int64(width) {
+			keep := int(int64(src.Dy()) * int64(width) / int64(height))
+			src.Min.X += (src.Dx() - keep) / 2
+			src.Max.X = src.Min.X + max(1, keep)
+		} else {
+			keep := int(int64(src.Dx()) * int64(height) / int64(width))
+			src.Min.Y += (src.Dy() - keep) / 2
+			src.Max.Y = src.Min.Y + max(1, keep)
+		}
+	default:
+		width, height = fitSize(src.Dx(), src.Dy(), width, height)
+	}
+
+	if src == img.Bounds() && width == src.Dx() && height == src.Dy() {
+		return img, nil
+	}
+	return scale(img, src, width, height), nil
+}
+
+// scale resamples the src region of an image to width by height pixels.
+func scale(img image.Image, src image.Rectangle, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// IsOpaque reports whether every pixel of an image is fully opaque.
+func IsOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// Encode writes an image in an output format. Quality applies to JPEG, from 1 to 100, and is
+// ignored by the lossless formats.
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+		return encoder.Encode(w, img)
+	case FormatGIF:
+		return gif.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("%w: cannot encode %s", ErrUnsupported, format)
+	}
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// anonymousCleanupBatchSize bounds how many expired users are removed per run.
+const anonymousCleanupBatchSize = 100
+
+// AnonymousUserCleanup deletes anonymous users whose expiry has passed, along with their files.
+type AnonymousUserCleanup struct {
+	userRepo repository.UserStore
+	fileRepo repository.FileStore
+	storage  storage.Storage
+}
+
+// NewAnonymousUserCleanup creates a new instance of AnonymousUserCleanup.
+func NewAnonymousUserCleanup(userRepo repository.UserStore, fileRepo repository.FileStore, store storage.Storage) *AnonymousUserCleanup {
+	return &AnonymousUserCleanup{userRepo: userRepo, fileRepo: fileRepo, storage: store}
+}
+
+// Name identifies the job in logs.
+func (j *AnonymousUserCleanup) Name() string {
+	return "anonymous-user-cleanup"
+}
+
+// Run removes one batch of expired anonymous users. Stored objects are deleted before the
+// user row, so a storage failure leaves the user in place to be retried on the next run.
+func (j *AnonymousUserCleanup) Run(ctx context.Context) error {
+	users, err := j.userRepo.ListExpiredAnonymousUsers(time.Now(), anonymousCleanupBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		files, err := j.fileRepo.ListFilesByOwner(user.ID)
+		if err != nil {
+			return err
+		}
+
+		failed := false
+		for _, file := range files {
+			if err := deleteFileObjects(ctx, j.storage, file); err != nil {
+				log.Printf("Failed to delete file %s of expired anonymous user %s: %v", file.ID, user.ID, err)
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+
+		// Deleting the user cascades to their file rows and permissions
+		if err := j.userRepo.DeleteUser(user.ID); err != nil {
+			return err
+		}
+		log.Printf("Deleted expired anonymous user %s and %d files", user.ID, len(files))
+	}
+
+	return nil
+}
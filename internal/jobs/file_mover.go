@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+	"golang.org/x/time/rate"
+)
+
+// moverBurst is the most bytes a single read may take from the rate limiter.
+const moverBurst = 256 << 10
+
+// fileMover moves file content from one backend to another, as done by storage migrations and
+// lifecycle rules. Each file is copied, read back from the target and checked against its SHA-256,
+// and only then is its row pointed at the target, in a single conditional update.
+type fileMover struct {
+	fileRepo repository.FileStore
+	limiter  *rate.Limiter
+}
+
+// newFileMover creates a fileMover that reads at most bytesPerSec bytes per second in total,
+// shared between concurrent moves. A rate of zero or less disables the limit.
+func newFileMover(fileRepo repository.FileStore, bytesPerSec int64) *fileMover {
+	var limiter *rate.Limiter
+	if bytesPerSec > 0 {
+		burst := moverBurst
+		if bytesPerSec < int64(burst) {
+			burst = int(bytesPerSec)
+		}
+		limiter = rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+	}
+	return &fileMover{fileRepo: fileRepo, limiter: limiter}
+}
+
+// move copies a file's object from source to target and records it as stored on backend. It
+// reports false if the file changed while it was copied, in which case the copy is discarded
+// unless the file has already been moved by someone else. The source copy is left in place.
+func (m *fileMover) move(ctx context.Context, file *models.File, source, target storage.Storage, backend string) (bool, error) {
+	object, err := source.Open(ctx, file.StorageKey)
+	if err != nil {
+		return false, err
+	}
+
+	// Files stored before checksums were recorded are checked against the checksum of what was read
+	sums := storage.Checksums{SHA256: file.ChecksumSHA256, MD5: file.ChecksumMD5, CRC32C: file.ChecksumCRC32C}
+	content := m.throttle(ctx, object)
+	var hasher hash.Hash
+	if sums.SHA256 == "" {
+		hasher = sha256.New()
+		content = io.TeeReader(content, hasher)
+	}
+
+	info, err := target.Put(ctx, file.StorageKey, content, storage.PutOptions{Size: file.Size, ContentType: file.ContentType, Checksums: sums})
+	object.Close()
+	if err != nil {
+		return false, err
+	}
+	if hasher != nil {
+		sums.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	if err := m.verifyCopy(ctx, target, file, sums.SHA256); err != nil {
+		if delErr := target.DeleteFile(ctx, file.StorageKey); delErr != nil {
+			log.Printf("Failed to remove bad copy of file %s: %v", file.ID, delErr)
+		}
+		return false, err
+	}
+
+	moved, err := m.fileRepo.MoveFileBackend(file, backend, info.URL, sums.SHA256)
+	if err != nil || moved {
+		return moved, err
+	}
+
+	// The file was deleted or moved while it was copied
+	current, err := m.fileRepo.GetFileByID(file.ID)
+	if err == nil && current.Backend == backend {
+		return false, nil
+	}
+	if err := target.DeleteFile(ctx, file.StorageKey); err != nil {
+		return false, fmt.Errorf("file changed while it was copied and the copy could not be removed: %v", err)
+	}
+	return false, nil
+}
+
+// verifyCopy reads an object back from the target and compares it with the expected checksum and size.
+func (m *fileMover) verifyCopy(ctx context.Context, target storage.Storage, file *models.File, checksumSHA256 string) error {
+	object, err := target.Open(ctx, file.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	n, err := io.Copy(io.Discard, storage.NewVerifyingReader(m.throttle(ctx, object), checksumSHA256))
+	if err != nil {
+		return fmt.Errorf("copy does not match the source: %v", err)
+	}
+	if n != file.Size {
+		return fmt.Errorf("copy is %d bytes, expected %d", n, file.Size)
+	}
+	return nil
+}
+
+// throttle wraps r so reads count against the shared rate limit.
+func (m *fileMover) throttle(ctx context.Context, r io.Reader) io.Reader {
+	if m.limiter == nil {
+		return r
+	}
+	return &rateLimitedReader{ctx: ctx, r: r, limiter: m.limiter}
+}
+
+// rateLimitedReader waits on a limiter shared between readers for every byte read.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// Read implements io.Reader
+func (l *rateLimitedReader) Read(p []byte) (int, error) {
+	if burst := l.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := l.r.Read(p)
+	if n > 0 {
+		if waitErr := l.limiter.WaitN(l.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
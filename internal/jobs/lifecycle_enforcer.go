@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// LifecycleEnforcer applies the lifecycle rules defined by administrators. Rules marked as dry
+// runs only log how many files they match.
+type LifecycleEnforcer struct {
+	ruleRepo    repository.LifecycleRuleStore
+	fileRepo    repository.FileStore
+	storage     storage.Storage
+	hot         storage.Storage
+	cold        storage.Storage // Nil when no cold tier is configured
+	coldBackend string
+	mover       *fileMover
+	batchSize   int
+}
+
+// NewLifecycleEnforcer creates a new instance of LifecycleEnforcer that applies each rule to up
+// to batchSize files per run. Objects are moved to the cold tier of a TieredStorage, which is
+// recorded on files as coldBackend, reading at most bytesPerSec bytes per second.
+func NewLifecycleEnforcer(ruleRepo repository.LifecycleRuleStore, fileRepo repository.FileStore, store storage.Storage, coldBackend string, batchSize int, bytesPerSec int64) *LifecycleEnforcer {
+	j := &LifecycleEnforcer{
+		ruleRepo:    ruleRepo,
+		fileRepo:    fileRepo,
+		storage:     store,
+		hot:         store,
+		coldBackend: coldBackend,
+		mover:       newFileMover(fileRepo, bytesPerSec),
+		batchSize:   batchSize,
+	}
+	if tiered, ok := store.(*storage.TieredStorage); ok {
+		j.hot, j.cold = tiered.Hot(), tiered.Cold()
+	}
+	return j
+}
+
+// Name identifies the job in logs.
+func (j *LifecycleEnforcer) Name() string {
+	return "lifecycle-enforcer"
+}
+
+// Run applies every rule to the next batch of files it matches.
+func (j *LifecycleEnforcer) Run(ctx context.Context) error {
+	rules, err := j.ruleRepo.ListLifecycleRules()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		files, err := j.fileRepo.ListFilesForLifecycleRule(rule, j.coldBackend, now, j.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if rule.DryRun {
+			log.Printf("Lifecycle rule %q (dry run) would %s %d files", rule.Name, rule.Action, len(files))
+			continue
+		}
+
+		applied := 0
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err := j.apply(ctx, rule, file); err != nil {
+				metrics.LifecycleErrors.Add(1)
+				log.Printf("Lifecycle rule %q failed on file %s: %v", rule.Name, file.ID, err)
+				if errors.Is(err, errRuleNotApplicable) {
+					break
+				}
+				continue
+			}
+			applied++
+		}
+		log.Printf("Lifecycle rule %q applied %s to %d of %d files", rule.Name, rule.Action, applied, len(files))
+	}
+	return nil
+}
+
+// errRuleNotApplicable stops a rule that cannot work with the configured storage.
+var errRuleNotApplicable = errors.New("rule cannot be applied with the configured storage")
+
+// apply performs a rule's action on one file.
+func (j *LifecycleEnforcer) apply(ctx context.Context, rule *models.LifecycleRule, file *models.File) error {
+	switch rule.Action {
+	case models.LifecycleExpire, models.LifecycleDeleteOldVersions:
+		return j.expire(ctx, file)
+	case models.LifecycleMoveToCold:
+		return j.moveToCold(ctx, file)
+	case models.LifecycleSetStorageClass:
+		return j.setStorageClass(ctx, file, rule.StorageClass)
+	default:
+		return fmt.Errorf("%w: unknown action %s", errRuleNotApplicable, rule.Action)
+	}
+}
+
+// expire deletes a file the same way a user would. If the object cannot be removed the file stays
+// hidden and reconciliation finishes the delete.
+func (j *LifecycleEnforcer) expire(ctx context.Context, file *models.File) error {
+	if err := j.fileRepo.MarkFileDeleting(file.ID); err != nil {
+		return err
+	}
+	if err := deleteFileObjects(ctx, j.storage, file); err != nil {
+		return err
+	}
+	if err := j.fileRepo.DeleteFile(file.ID); err != nil {
+		return err
+	}
+	metrics.LifecycleFilesExpired.Add(1)
+	return nil
+}
+
+// moveToCold copies a file to the cold tier and removes the hot copy once the file points at it.
+func (j *LifecycleEnforcer) moveToCold(ctx context.Context, file *models.File) error {
+	if j.cold == nil {
+		return fmt.Errorf("%w: no cold storage provider is configured", errRuleNotApplicable)
+	}
+
+	moved, err := j.mover.move(ctx, file, j.hot, j.cold, j.coldBackend)
+	if err != nil || !moved {
+		return err
+	}
+	metrics.LifecycleFilesTransitioned.Add(1)
+
+	if err := j.hot.DeleteFile(ctx, file.StorageKey); err != nil {
+		log.Printf("Failed to remove hot copy of file %s after moving it to cold storage: %v", file.ID, err)
+	}
+	return nil
+}
+
+// setStorageClass changes the storage class of a file's object on whichever tier holds it.
+func (j *LifecycleEnforcer) setStorageClass(ctx context.Context, file *models.File, class string) error {
+	backend := j.hot
+	if j.cold != nil && file.Backend == j.coldBackend {
+		backend = j.cold
+	}
+
+	setter, ok := backend.(storage.StorageClassSetter)
+	if !ok {
+		return fmt.Errorf("%w: the storage backend does not support storage classes", errRuleNotApplicable)
+	}
+	if err := setter.SetStorageClass(ctx, file.StorageKey, class); err != nil {
+		return err
+	}
+	if err := j.fileRepo.UpdateStorageClass(file.ID, class); err != nil {
+		return err
+	}
+	metrics.LifecycleFilesTransitioned.Add(1)
+	return nil
+}
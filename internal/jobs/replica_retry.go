@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// replicaRetryBatchSize bounds how many missed replica operations are repeated per run.
+const replicaRetryBatchSize = 100
+
+// ReplicaRetry brings replicas that missed a write or delete back in line with the others.
+type ReplicaRetry struct {
+	storage *storage.ReplicatedStorage
+}
+
+// NewReplicaRetry creates a new instance of ReplicaRetry.
+func NewReplicaRetry(store *storage.ReplicatedStorage) *ReplicaRetry {
+	return &ReplicaRetry{storage: store}
+}
+
+// Name identifies the job in logs.
+func (j *ReplicaRetry) Name() string {
+	return "replica-retry"
+}
+
+// Run repeats one batch of queued replica operations that are due.
+func (j *ReplicaRetry) Run(ctx context.Context) error {
+	return j.storage.RetryPending(ctx, replicaRetryBatchSize)
+}
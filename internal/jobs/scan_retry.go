@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/scanner"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// ScanRetry scans files still waiting for a malware verdict: files stored before scanning was
+// introduced, and uploads the scanner failed on. A file that cannot be scanned stays pending,
+// and therefore quarantined, and its attempt is recorded so the next run tries others first.
+type ScanRetry struct {
+	fileRepo  repository.FileStore
+	storage   storage.Storage
+	scanner   scanner.Scanner
+	batchSize int
+}
+
+// NewScanRetry creates a new instance of ScanRetry that scans up to batchSize files per run.
+func NewScanRetry(fileRepo repository.FileStore, store storage.Storage, fileScanner scanner.Scanner, batchSize int) *ScanRetry {
+	return &ScanRetry{fileRepo: fileRepo, storage: store, scanner: fileScanner, batchSize: batchSize}
+}
+
+// Name identifies the job in logs.
+func (j *ScanRetry) Name() string {
+	return "scan-retry"
+}
+
+// Run scans the files that have waited longest for a verdict.
+func (j *ScanRetry) Run(ctx context.Context) error {
+	files, err := j.fileRepo.ListFilesPendingScan(j.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		status, err := j.scan(ctx, file)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			metrics.ScanErrors.Add(1)
+			log.Printf("Failed to scan file %s, leaving it quarantined: %v", file.ID, err)
+			status = models.ScanStatusPending
+		}
+
+		if err := j.fileRepo.UpdateScanStatus(file.ID, status, time.Now()); err != nil {
+			return err
+		}
+		switch status {
+		case models.ScanStatusClean:
+			metrics.ScanFilesClean.Add(1)
+		case models.ScanStatusInfected:
+			metrics.ScanFilesInfected.Add(1)
+		}
+	}
+	return nil
+}
+
+// scan reads a file's stored content through the scanner and returns its verdict.
+func (j *ScanRetry) scan(ctx context.Context, file *models.File) (string, error) {
+	object, err := j.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		return "", err
+	}
+	defer object.Close()
+
+	result, err := j.scanner.Scan(ctx, object)
+	if err != nil {
+		return "", err
+	}
+	if !result.Clean {
+		log.Printf("File %s owned by %s is infected with %s", file.ID, file.OwnerID, result.Signature)
+		return models.ScanStatusInfected, nil
+	}
+	return models.ScanStatusClean, nil
+}
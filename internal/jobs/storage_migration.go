@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// StorageMigration moves file content from the source of a MigratingStorage to its target.
+// Progress is kept in the file rows themselves, so the job can be stopped and restarted at any
+// point, and the API keeps serving files from whichever backend holds them meanwhile.
+type StorageMigration struct {
+	fileRepo     repository.FileStore
+	storage      *storage.MigratingStorage
+	mover        *fileMover
+	source       string
+	target       string
+	batchSize    int
+	concurrency  int
+	deleteSource bool
+
+	// Files are visited in creation order, so a file that keeps failing does not hold up the rest.
+	// The cursor starts over once a pass is complete, retrying the files that failed.
+	afterCreatedAt time.Time
+	afterID        string
+	finished       bool
+}
+
+// NewStorageMigration creates a new instance of StorageMigration that moves up to batchSize files
+// per run, copying concurrency files at a time and at most bytesPerSec bytes per second in total.
+// The source and target are the provider names recorded on files.
+func NewStorageMigration(fileRepo repository.FileStore, store *storage.MigratingStorage, source, target string, batchSize, concurrency int, bytesPerSec int64, deleteSource bool) *StorageMigration {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &StorageMigration{
+		fileRepo:     fileRepo,
+		storage:      store,
+		mover:        newFileMover(fileRepo, bytesPerSec),
+		source:       source,
+		target:       target,
+		batchSize:    batchSize,
+		concurrency:  concurrency,
+		deleteSource: deleteSource,
+	}
+}
+
+// Name identifies the job in logs.
+func (j *StorageMigration) Name() string {
+	return "storage-migration"
+}
+
+// Run moves the next batch of files.
+func (j *StorageMigration) Run(ctx context.Context) error {
+	files, err := j.fileRepo.ListFilesToMigrate(j.source, j.afterCreatedAt, j.afterID, j.batchSize)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		if j.afterID == "" {
+			if !j.finished {
+				log.Printf("Storage migration from %s to %s is complete", j.source, j.target)
+				j.finished = true
+			}
+			return nil
+		}
+		// End of a pass; start over to retry the files that failed
+		j.afterCreatedAt, j.afterID = time.Time{}, ""
+		return nil
+	}
+	j.finished = false
+
+	work := make(chan *models.File)
+	var wg sync.WaitGroup
+	for i := 0; i < j.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range work {
+				if err := j.migrate(ctx, file); err != nil {
+					metrics.MigrationErrors.Add(1)
+					log.Printf("Failed to migrate file %s: %v", file.ID, err)
+				}
+			}
+		}()
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- file
+	}
+	close(work)
+	wg.Wait()
+
+	last := files[len(files)-1]
+	j.afterCreatedAt, j.afterID = last.CreatedAt, last.ID
+	return ctx.Err()
+}
+
+// migrate moves one file to the target.
+func (j *StorageMigration) migrate(ctx context.Context, file *models.File) error {
+	moved, err := j.mover.move(ctx, file, j.storage.Source(), j.storage.Target(), j.target)
+	if err != nil || !moved {
+		return err
+	}
+
+	metrics.MigrationFilesMoved.Add(1)
+	metrics.MigrationBytesCopied.Add(file.Size)
+
+	if j.deleteSource {
+		if err := j.storage.Source().DeleteFile(ctx, file.StorageKey); err != nil {
+			log.Printf("Failed to remove migrated file %s from the source: %v", file.ID, err)
+		}
+	}
+	return nil
+}
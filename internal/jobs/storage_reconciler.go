@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// reconcileBatchSize bounds how many half-finished files are resolved per run.
+const reconcileBatchSize = 500
+
+// StorageReconciler compares the bucket with the files table. It finishes deletes and rolls back
+// creates that were interrupted, and reports, or optionally removes, objects that no file row
+// points to. Active rows whose object is missing are only reported, since removing them would
+// lose the metadata that might be needed to restore the object. Objects derived from a file, such
+// as thumbnails, belong to the file's row and are only orphaned once it is gone.
+type StorageReconciler struct {
+	fileRepo      repository.FileStore
+	storage       storage.Storage
+	gracePeriod   time.Duration
+	deleteOrphans bool
+}
+
+// NewStorageReconciler creates a new instance of StorageReconciler. Files and objects younger
+// than the grace period are left alone, as their upload may still be in progress.
+func NewStorageReconciler(fileRepo repository.FileStore, store storage.Storage, gracePeriod time.Duration, deleteOrphans bool) *StorageReconciler {
+	return &StorageReconciler{fileRepo: fileRepo, storage: store, gracePeriod: gracePeriod, deleteOrphans: deleteOrphans}
+}
+
+// Name identifies the job in logs.
+func (j *StorageReconciler) Name() string {
+	return "storage-reconciler"
+}
+
+// Run performs one reconciliation pass.
+func (j *StorageReconciler) Run(ctx context.Context) error {
+	cutoff := time.Now().Add(-j.gracePeriod)
+
+	// Uploads that never completed and deletes that never finished are both resolved by
+	// removing the object and then the row
+	for _, state := range []string{models.FileStatePending, models.FileStateDeleting} {
+		if err := j.removeStale(ctx, state, cutoff); err != nil {
+			return err
+		}
+	}
+
+	objects, err := j.storage.List(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	// Read the rows after listing, so an object uploaded in between is never taken for an orphan
+	keys, err := j.fileRepo.ListStorageKeys()
+	if err != nil {
+		return err
+	}
+
+	orphans, removed := 0, 0
+	stored := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		stored[object.Key] = true
+		if _, ok := keys[object.Key]; ok || object.LastModified.After(cutoff) {
+			continue
+		}
+		if parent, derived := models.ParentStorageKey(object.Key); derived {
+			if _, ok := keys[parent]; ok {
+				continue
+			}
+		}
+
+		orphans++
+		if !j.deleteOrphans {
+			log.Printf("Orphaned object %s (%d bytes) has no file record", object.Key, object.Size)
+			continue
+		}
+		if err := j.storage.DeleteFile(ctx, object.Key); err != nil {
+			log.Printf("Failed to delete orphaned object %s: %v", object.Key, err)
+			continue
+		}
+		removed++
+	}
+
+	missing := 0
+	for key, state := range keys {
+		if state == models.FileStateActive && !stored[key] {
+			missing++
+			log.Printf("File record with storage key %s has no object in storage", key)
+		}
+	}
+
+	if orphans > 0 || missing > 0 {
+		log.Printf("Storage reconciliation found %d orphaned objects (%d removed) and %d files without content", orphans, removed, missing)
+	}
+	return nil
+}
+
+// removeStale deletes the objects and rows of files stuck in a state since before the cutoff.
+func (j *StorageReconciler) removeStale(ctx context.Context, state string, cutoff time.Time) error {
+	files, err := j.fileRepo.ListFilesInState(state, cutoff, reconcileBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := deleteFileObjects(ctx, j.storage, file); err != nil {
+			log.Printf("Failed to delete object for %s file %s: %v", state, file.ID, err)
+			continue
+		}
+		if err := j.fileRepo.DeleteFile(file.ID); err != nil {
+			return err
+		}
+		log.Printf("Removed %s file %s", state, file.ID)
+	}
+	return nil
+}
+
+// deleteFileObjects deletes a file's object and every object derived from it, derived objects
+// first so none are left behind if the delete has to be retried.
+func deleteFileObjects(ctx context.Context, store storage.Storage, file *models.File) error {
+	if err := storage.DeletePrefix(ctx, store, models.DerivedKeyPrefix(file.StorageKey)); err != nil {
+		return err
+	}
+	return store.DeleteFile(ctx, file.StorageKey)
+}
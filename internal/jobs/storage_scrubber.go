@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// StorageScrubber re-reads stored objects and compares them with the checksums recorded at
+// upload, flagging files whose content has changed or gone missing. Reads are throttled so a
+// pass does not compete with user traffic.
+type StorageScrubber struct {
+	fileRepo    repository.FileStore
+	storage     storage.Storage
+	batchSize   int
+	bytesPerSec int64
+}
+
+// NewStorageScrubber creates a new instance of StorageScrubber that verifies up to batchSize
+// files per run, reading at most bytesPerSec bytes per second.
+func NewStorageScrubber(fileRepo repository.FileStore, store storage.Storage, batchSize int, bytesPerSec int64) *StorageScrubber {
+	return &StorageScrubber{fileRepo: fileRepo, storage: store, batchSize: batchSize, bytesPerSec: bytesPerSec}
+}
+
+// Name identifies the job in logs.
+func (j *StorageScrubber) Name() string {
+	return "storage-scrubber"
+}
+
+// Run verifies the least recently verified files.
+func (j *StorageScrubber) Run(ctx context.Context) error {
+	files, err := j.fileRepo.ListFilesToVerify(j.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		status, sha, err := j.verify(ctx, file)
+		if err != nil {
+			// Read failures are not proof of corruption, so the file is retried on a later run
+			metrics.ScrubErrors.Add(1)
+			log.Printf("Failed to verify file %s: %v", file.ID, err)
+			continue
+		}
+
+		if err := j.fileRepo.UpdateIntegrity(file.ID, status, sha, time.Now()); err != nil {
+			return err
+		}
+
+		metrics.ScrubFilesVerified.Add(1)
+		if status == models.IntegrityCorrupted {
+			metrics.ScrubFilesCorrupted.Add(1)
+			log.Printf("File %s is corrupted in storage (key %s)", file.ID, file.StorageKey)
+		}
+	}
+	return nil
+}
+
+// verify reads a file's object and returns its integrity status. Files stored before checksums
+// were recorded have nothing to compare against, so their current SHA-256 is returned to be
+// recorded as the baseline.
+func (j *StorageScrubber) verify(ctx context.Context, file *models.File) (string, string, error) {
+	object, err := j.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		return "", "", err
+	}
+	defer object.Close()
+
+	reader := newThrottledReader(ctx, object, j.bytesPerSec)
+	if file.ChecksumSHA256 == "" {
+		sums, n, err := storage.ComputeChecksums(reader)
+		metrics.ScrubBytesRead.Add(n)
+		if err != nil {
+			return "", "", err
+		}
+		if n != file.Size {
+			return models.IntegrityCorrupted, "", nil
+		}
+		return models.IntegrityOK, sums.SHA256, nil
+	}
+
+	n, err := io.Copy(io.Discard, storage.NewVerifyingReader(reader, file.ChecksumSHA256))
+	metrics.ScrubBytesRead.Add(n)
+	if errors.Is(err, storage.ErrChecksumMismatch) || (err == nil && n != file.Size) {
+		return models.IntegrityCorrupted, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return models.IntegrityOK, "", nil
+}
+
+// throttledReader limits how fast an underlying reader is consumed.
+type throttledReader struct {
+	ctx         context.Context
+	r           io.Reader
+	bytesPerSec int64
+	start       time.Time
+	read        int64
+}
+
+// newThrottledReader wraps r so it is read at no more than bytesPerSec. A rate of zero or less
+// disables throttling.
+func newThrottledReader(ctx context.Context, r io.Reader, bytesPerSec int64) io.Reader {
+	if bytesPerSec <= 0 {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, bytesPerSec: bytesPerSec, start: time.Now()}
+}
+
+// Read implements io.Reader, sleeping whenever reading has got ahead of the allowed rate.
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > t.bytesPerSec {
+		p = p[:t.bytesPerSec]
+	}
+
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+
+	allowedAt := t.start.Add(time.Duration(float64(t.read) / float64(t.bytesPerSec) * float64(time.Second)))
+	if wait := time.Until(allowedAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"log"
+
+	"github.com/souvik03-136/Go-Store/internal/imaging"
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// thumbnailQuality is the JPEG quality thumbnails are encoded with.
+const thumbnailQuality = 80
+
+// ThumbnailGenerator stores scaled-down copies of uploaded images in each configured size, next
+// to the original object. Images are only processed once their malware scan has found them clean.
+// Images that cannot be decoded, are too large to decode safely or have no object are marked as
+// failed; other storage errors leave the file pending so it is retried on a later run.
+type ThumbnailGenerator struct {
+	fileRepo  repository.FileStore
+	storage   storage.Storage
+	sizes     []int
+	batchSize int
+	maxPixels int64
+}
+
+// NewThumbnailGenerator creates a new instance of ThumbnailGenerator that processes up to
+// batchSize files per run, skipping source images with more than maxPixels pixels.
+func NewThumbnailGenerator(fileRepo repository.FileStore, store storage.Storage, sizes []int, batchSize int, maxPixels int64) *ThumbnailGenerator {
+	return &ThumbnailGenerator{fileRepo: fileRepo, storage: store, sizes: sizes, batchSize: batchSize, maxPixels: maxPixels}
+}
+
+// Name identifies the job in logs.
+func (j *ThumbnailGenerator) Name() string {
+	return "thumbnail-generator"
+}
+
+// Run generates thumbnails for the oldest images waiting for them.
+func (j *ThumbnailGenerator) Run(ctx context.Context) error {
+	files, err := j.fileRepo.ListFilesNeedingThumbnails(j.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		status := models.ThumbnailReady
+		if err := j.generate(ctx, file); err != nil {
+			if !imaging.Unprocessable(err) && !errors.Is(err, storage.ErrObjectNotFound) {
+				metrics.ThumbnailErrors.Add(1)
+				log.Printf("Failed to generate thumbnails for file %s, will retry: %v", file.ID, err)
+				continue
+			}
+			metrics.ThumbnailFilesFailed.Add(1)
+			log.Printf("Cannot generate thumbnails for file %s: %v", file.ID, err)
+			status = models.ThumbnailFailed
+		}
+
+		if err := j.fileRepo.UpdateThumbnailStatus(file.ID, status); err != nil {
+			return err
+		}
+		if status == models.ThumbnailReady {
+			metrics.ThumbnailFilesGenerated.Add(1)
+		}
+	}
+	return nil
+}
+
+// generate decodes a file's image and stores a thumbnail of it in every size.
+func (j *ThumbnailGenerator) generate(ctx context.Context, file *models.File) error {
+	img, err := j.decode(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	// Transparency only survives as PNG; everything else is smaller as JPEG
+	format := imaging.FormatJPEG
+	if !imaging.IsOpaque(img) {
+		format = imaging.FormatPNG
+	}
+
+	for _, size := range j.sizes {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Fit(img, size, size), format, thumbnailQuality); err != nil {
+			return err
+		}
+
+		key := models.ThumbnailKeyFor(file.StorageKey, size)
+		opts := storage.PutOptions{Size: int64(buf.Len()), ContentType: imaging.ContentType(format)}
+		if _, err := j.storage.Put(ctx, key, &buf, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode reads a file's content and decodes it as an image.
+func (j *ThumbnailGenerator) decode(ctx context.Context, file *models.File) (image.Image, error) {
+	object, err := j.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	img, _, err := imaging.Decode(object, j.maxPixels)
+	return img, err
+}
@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/fetch"
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// Progress is recorded at most this often, so large downloads do not flood the database.
+const importProgressInterval = time.Second
+
+// importStaleAfter is how long a running import may go without recording progress, beyond the
+// download timeout, before its importer is presumed to have stopped and the job is run again.
+const importStaleAfter = time.Minute
+
+// FileIngester stores downloaded content as a new file of a user, with the same checks as an
+// upload. Errors say why the file was refused.
+type FileIngester interface {
+	IngestFile(ctx context.Context, ownerID, filePath, contentPath string, size int64) (*models.File, error)
+}
+
+// URLImporter downloads the files of pending import jobs and stores them. Each job is downloaded
+// to a temporary file, recording its progress, then stored with the checks of an upload. Jobs
+// interrupted by a shutdown are left running, and are picked up again once they are stale.
+type URLImporter struct {
+	importRepo  repository.ImportJobStore
+	ingester    FileIngester
+	client      *fetch.Client
+	concurrency int
+	timeout     time.Duration
+}
+
+// NewURLImporter creates a new instance of URLImporter that downloads up to concurrency files at
+// once, each within timeout.
+func NewURLImporter(importRepo repository.ImportJobStore, ingester FileIngester, client *fetch.Client, concurrency int, timeout time.Duration) *URLImporter {
+	return &URLImporter{importRepo: importRepo, ingester: ingester, client: client, concurrency: max(1, concurrency), timeout: timeout}
+}
+
+// Name identifies the job in logs.
+func (j *URLImporter) Name() string {
+	return "url-importer"
+}
+
+// Run imports the oldest waiting jobs.
+func (j *URLImporter) Run(ctx context.Context) error {
+	staleBefore := time.Now().Add(-j.timeout - importStaleAfter)
+	jobs, err := j.importRepo.ListImportJobsToRun(staleBefore, j.concurrency)
+	if err != nil {
+		return err
+	}
+
+	work := make(chan *models.ImportJob)
+	var wg sync.WaitGroup
+	for i := 0; i < j.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				claimed, err := j.importRepo.ClaimImportJob(job.ID, staleBefore)
+				if err != nil {
+					log.Printf("Failed to claim import job %s: %v", job.ID, err)
+					continue
+				}
+				if claimed {
+					j.importFile(ctx, job)
+				}
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- job
+	}
+	close(work)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// importFile downloads and stores one claimed job's file, then records how it finished.
+func (j *URLImporter) importFile(ctx context.Context, job *models.ImportJob) {
+	file, err := j.download(ctx, job)
+	if ctx.Err() != nil {
+		// Shutting down; the job goes stale and is run again
+		return
+	}
+
+	now := time.Now()
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+	if err != nil {
+		metrics.ImportsFailed.Add(1)
+		log.Printf("Import job %s of %s failed: %v", job.ID, job.URL, err)
+		job.Status = models.ImportFailed
+		job.Error = importErrorMessage(err)
+	} else {
+		metrics.ImportsCompleted.Add(1)
+		job.Status = models.ImportCompleted
+		job.FileID = file.ID
+	}
+	if err := j.importRepo.FinishImportJob(job); err != nil {
+		log.Printf("Failed to record the result of import job %s: %v", job.ID, err)
+	}
+}
+
+// download fetches a job's URL into a temporary file and stores it as a new file.
+func (j *URLImporter) download(ctx context.Context, job *models.ImportJob) (*models.File, error) {
+	spooled, err := os.CreateTemp("", "go-store-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spooled.Name())
+	defer spooled.Close()
+
+	var lastUpdate time.Time
+	size, err := j.client.Download(ctx, job.URL, spooled, func(downloaded, total int64) {
+		job.BytesDownloaded, job.TotalBytes = downloaded, max(total, 0)
+		if time.Since(lastUpdate) < importProgressInterval {
+			return
+		}
+		lastUpdate = time.Now()
+		if err := j.importRepo.UpdateImportProgress(job.ID, job.BytesDownloaded, job.TotalBytes); err != nil {
+			log.Printf("Failed to record progress of import job %s: %v", job.ID, err)
+		}
+	})
+	metrics.ImportBytesDownloaded.Add(size)
+	if err != nil {
+		return nil, err
+	}
+	job.BytesDownloaded = size
+	if err := spooled.Close(); err != nil {
+		return nil, err
+	}
+
+	file, err := j.ingester.IngestFile(ctx, job.OwnerID, job.Path, spooled.Name(), size)
+	if err != nil {
+		return nil, &refusedError{err}
+	}
+	return file, nil
+}
+
+// refusedError is returned when a downloaded file is refused, for example by the upload policy.
+// Its message is meant for the job's owner.
+type refusedError struct {
+	err error
+}
+
+// Error returns the reason the file was refused.
+func (e *refusedError) Error() string {
+	return e.err.Error()
+}
+
+// importErrorMessage describes why an import failed for the job's owner, without revealing more
+// about the network than whether an address was refused.
+func importErrorMessage(err error) string {
+	var refused *refusedError
+	var status *fetch.StatusError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &refused):
+		return refused.Error()
+	case errors.Is(err, fetch.ErrBlockedAddress):
+		return "The URL leads to an address that imports are not allowed to reach"
+	case errors.Is(err, fetch.ErrUnsupportedURL):
+		return "Only http and https URLs can be imported"
+	case errors.Is(err, fetch.ErrTooManyRedirects):
+		return "The URL redirected too many times"
+	case errors.Is(err, fetch.ErrTooLarge):
+		return "The file is larger than imports allow"
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &urlErr) && urlErr.Timeout():
+		return "The download timed out"
+	case errors.As(err, &status):
+		return fmt.Sprintf("The server responded %s", status.Status)
+	default:
+		return "The file could not be downloaded"
+	}
+}
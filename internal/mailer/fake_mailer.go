@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeMailer is a test double that keeps every message it is asked to send.
+type FakeMailer struct {
+	Err error
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+// NewFakeMailer initializes a mailer that records messages and returns the given error
+func NewFakeMailer(err error) *FakeMailer {
+	return &FakeMailer{Err: err}
+}
+
+// Send records the message and returns the configured error
+func (m *FakeMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	m.sent = append(m.sent, msg)
+	m.mu.Unlock()
+
+	return m.Err
+}
+
+// Sent returns every message sent so far, in order.
+func (m *FakeMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/souvik03-136/Go-Store/internal/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer interface that both the SMTP and log mailers will implement
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer creates the mailer selected by MAIL_PROVIDER ("smtp" or "log").
+// The log mailer is the default so development works without a mail server.
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Provider {
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From), nil
+	case "", "log":
+		return NewLogMailer(cfg.Mail.LogFile), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail provider: %s", cfg.Mail.Provider)
+	}
+}
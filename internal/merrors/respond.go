@@ -0,0 +1,46 @@
+package merrors
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/souvik03-136/Go-Store/internal/utils"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                ERROR VALUES                                */
+/* -------------------------------------------------------------------------- */
+
+// NewError describes an error as the responders in this package report it, for handlers that
+// collect errors, such as one per item of a batch, rather than responding with the first.
+func NewError(code int, message string, details interface{}) *utils.Error {
+	return &utils.Error{Code: code, Type: typeForCode(code), Message: message, Details: details}
+}
+
+// Respond responds with an error built by NewError.
+func Respond(ctx *gin.Context, err *utils.Error) {
+	ctx.JSON(err.Code, utils.BaseResponse{Error: err})
+	ctx.Abort()
+}
+
+// typeForCode returns the error type the responder for a status code reports.
+func typeForCode(code int) string {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnauthorized:
+		return errorType.Unauthorized // As BadRequest reports it
+	case http.StatusForbidden:
+		return errorType.Forbidden
+	case http.StatusNotFound:
+		return errorType.NotFound
+	case http.StatusConflict:
+		return errorType.conflict
+	case http.StatusUnprocessableEntity:
+		return errorType.validation
+	case http.StatusServiceUnavailable:
+		return errorType.ServiceUnavailable
+	case 550:
+		return errorType.Downstream
+	default:
+		return errorType.server
+	}
+}
@@ -24,3 +24,21 @@ func Validation(ctx *gin.Context, err string) {
 	ctx.JSON(errorCode, res)
 	ctx.Abort()
 }
+
+// ValidationDetails responds like Validation and adds machine-readable details,
+// such as the individual rules a request broke.
+func ValidationDetails(ctx *gin.Context, err string, details interface{}) {
+	var res utils.BaseResponse
+	var smerror utils.Error
+	errorCode := http.StatusUnprocessableEntity
+
+	smerror.Code = errorCode
+	smerror.Type = errorType.validation
+	smerror.Message = err
+	smerror.Details = details
+
+	res.Error = &smerror
+
+	ctx.JSON(errorCode, res)
+	ctx.Abort()
+}
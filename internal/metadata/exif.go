@@ -0,0 +1,251 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// EXIF tags read from a TIFF structure.
+const (
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitude       = 0x0004
+)
+
+// maxIFDEntries bounds the entries read from one directory, so a corrupt count cannot make a
+// parser loop for long.
+const maxIFDEntries = 1000
+
+// exifHeader prefixes the TIFF structure in a JPEG APP1 segment.
+var exifHeader = []byte("Exif\x00\x00")
+
+// xmpHeader prefixes XMP packets in a JPEG APP1 segment.
+var xmpHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// tiff is a TIFF structure, as used by EXIF, with the byte order it declares.
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ifdEntry is one tag of an image file directory.
+type ifdEntry struct {
+	tag      uint16
+	kind     uint16
+	count    uint32
+	valuePos int // Position of the value, inline in the entry when it fits in four bytes
+}
+
+// typeSizes are the byte sizes of the TIFF field types, indexed by type.
+var typeSizes = []int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// parseTIFF checks a TIFF header and returns the structure with the offset of its first directory.
+func parseTIFF(data []byte) (*tiff, int, error) {
+	if len(data) < 8 {
+		return nil, 0, ErrInvalid
+	}
+
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, ErrInvalid
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, 0, ErrInvalid
+	}
+	return t, int(t.order.Uint32(data[4:])), nil
+}
+
+// entries reads the entries of the directory at offset.
+func (t *tiff) entries(offset int) ([]ifdEntry, error) {
+	if offset < 8 || offset+2 > len(t.data) {
+		return nil, ErrInvalid
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	if count > maxIFDEntries || offset+2+count*12 > len(t.data) {
+		return nil, ErrInvalid
+	}
+
+	entries := make([]ifdEntry, 0, count)
+	for i := 0; i < count; i++ {
+		pos := offset + 2 + i*12
+		entry := ifdEntry{
+			tag:      t.order.Uint16(t.data[pos:]),
+			kind:     t.order.Uint16(t.data[pos+2:]),
+			count:    t.order.Uint32(t.data[pos+4:]),
+			valuePos: pos + 8,
+		}
+		if size := t.size(entry); size > 4 {
+			entry.valuePos = int(t.order.Uint32(t.data[pos+8:]))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// size returns the byte size of an entry's value, or zero for unknown types.
+func (t *tiff) size(e ifdEntry) int {
+	if int(e.kind) >= len(typeSizes) || e.count > uint32(len(t.data)) {
+		return 0
+	}
+	return typeSizes[e.kind] * int(e.count)
+}
+
+// value returns the bytes of an entry's value, or nil if they lie outside the data.
+func (t *tiff) value(e ifdEntry) []byte {
+	size := t.size(e)
+	if size == 0 || e.valuePos < 0 || e.valuePos+size > len(t.data) {
+		return nil
+	}
+	return t.data[e.valuePos : e.valuePos+size]
+}
+
+// str returns an ASCII entry's value without its terminating NUL and surrounding spaces.
+func (t *tiff) str(e ifdEntry) string {
+	value := t.value(e)
+	if i := bytes.IndexByte(value, 0); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(string(value))
+}
+
+// uint returns a SHORT or LONG entry's first value.
+func (t *tiff) uint(e ifdEntry) int {
+	value := t.value(e)
+	switch {
+	case e.kind == 3 && len(value) >= 2:
+		return int(t.order.Uint16(value))
+	case e.kind == 4 && len(value) >= 4:
+		return int(t.order.Uint32(value))
+	default:
+		return 0
+	}
+}
+
+// readExif records the camera, capture time, orientation and presence of a location from a TIFF
+// structure. Damaged subdirectories are skipped, so as much as possible is read.
+func readExif(data []byte, meta *models.FileMetadata) error {
+	t, ifd0, err := parseTIFF(data)
+	if err != nil {
+		return err
+	}
+	entries, err := t.entries(ifd0)
+	if err != nil {
+		return err
+	}
+
+	var dateTime, original, offset string
+	for _, e := range entries {
+		switch e.tag {
+		case tagMake:
+			meta.CameraMake = t.str(e)
+		case tagModel:
+			meta.CameraModel = t.str(e)
+		case tagOrientation:
+			meta.Orientation = t.uint(e)
+		case tagDateTime:
+			dateTime = t.str(e)
+		case tagExifIFD:
+			exif, err := t.entries(t.uint(e))
+			if err != nil {
+				continue
+			}
+			for _, sub := range exif {
+				switch sub.tag {
+				case tagDateTimeOriginal:
+					original = t.str(sub)
+				case tagOffsetTimeOriginal:
+					offset = t.str(sub)
+				}
+			}
+		case tagGPSIFD:
+			gps, err := t.entries(t.uint(e))
+			if err != nil {
+				continue
+			}
+			for _, sub := range gps {
+				if sub.tag == tagGPSLatitude || sub.tag == tagGPSLongitude {
+					meta.HasLocation = true
+				}
+			}
+		}
+	}
+
+	// Prefer when the photo was taken over when the file was last changed
+	if original != "" {
+		meta.CapturedAt = parseExifTime(original, offset)
+	} else if dateTime != "" {
+		meta.CapturedAt = parseExifTime(dateTime, "")
+	}
+	return nil
+}
+
+// parseExifTime parses an EXIF timestamp such as "2024:06:01 14:30:00". Without a recorded UTC
+// offset the camera's local time is returned as if it were UTC.
+func parseExifTime(value, offset string) *time.Time {
+	layout, value := "2006:01:02 15:04:05", strings.TrimSpace(value)
+	if offset != "" {
+		layout, value = layout+"-07:00", value+offset
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// clearGPS empties the GPS directory of a TIFF structure in place. Every GPS entry and the data
+// it points to is overwritten with zeros, so no trace of the location remains, and the
+// directory is left valid with no entries. It reports whether anything was cleared.
+func clearGPS(data []byte) (bool, error) {
+	t, ifd0, err := parseTIFF(data)
+	if err != nil {
+		return false, err
+	}
+	entries, err := t.entries(ifd0)
+	if err != nil {
+		return false, err
+	}
+
+	cleared := false
+	for _, e := range entries {
+		if e.tag != tagGPSIFD {
+			continue
+		}
+		offset := t.uint(e)
+		gps, err := t.entries(offset)
+		if err != nil {
+			return false, err
+		}
+		if len(gps) == 0 {
+			continue
+		}
+
+		for _, sub := range gps {
+			if size := t.size(sub); size > 4 {
+				if value := t.value(sub); value != nil {
+					clear(value)
+				}
+			}
+		}
+		// The directory ends with the offset of the next one, which GPS directories never have
+		clear(t.data[offset:min(offset+2+len(gps)*12+4, len(t.data))])
+		cleared = true
+	}
+	return cleared, nil
+}
@@ -0,0 +1,184 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// JPEG markers that matter when walking a file's segments.
+const (
+	markerAPP1 = 0xE1
+	markerSOS  = 0xDA
+	markerEOI  = 0xD9
+)
+
+// xmpExtensionHeader prefixes the continuation of an XMP packet too large for one segment.
+var xmpExtensionHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+
+// xmpLocationProperties are the XMP properties that record where a photo was taken.
+var xmpLocationProperties = [][]byte{[]byte("GPSLatitude"), []byte("GPSLongitude")}
+
+// jpegSegment is a marker segment before a JPEG file's image data.
+type jpegSegment struct {
+	marker byte
+	start  int64 // Position of the marker
+	end    int64 // Position after the segment
+}
+
+// payload returns the segment's content after its marker and length.
+func (s jpegSegment) payload(r io.ReaderAt) ([]byte, error) {
+	data := make([]byte, s.end-s.start-4)
+	if _, err := r.ReadAt(data, s.start+4); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// jpegSegments lists the segments of a JPEG file up to the start of its image data, where
+// metadata ends. Only the segment headers are read.
+func jpegSegments(r io.ReaderAt, size int64) ([]jpegSegment, error) {
+	var segments []jpegSegment
+	header := make([]byte, 4)
+	pos := int64(2)
+
+	for pos+4 <= size {
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return nil, err
+		}
+		if header[0] != 0xFF {
+			return nil, ErrInvalid
+		}
+
+		marker := header[1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			pos++
+			continue
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD8:
+			// Markers without a length
+			pos += 2
+			continue
+		case marker == markerSOS || marker == markerEOI:
+			return segments, nil
+		}
+
+		length := int64(binary.BigEndian.Uint16(header[2:]))
+		if length < 2 || pos+2+length > size {
+			return nil, ErrInvalid
+		}
+		segments = append(segments, jpegSegment{marker: marker, start: pos, end: pos + 2 + length})
+		pos += 2 + length
+	}
+	return segments, nil
+}
+
+// readJPEG records the EXIF metadata of a JPEG file, and whether its XMP records a location.
+func readJPEG(r io.ReaderAt, size int64, meta *models.FileMetadata) error {
+	segments, err := jpegSegments(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment.marker != markerAPP1 {
+			continue
+		}
+		payload, err := segment.payload(r)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case bytes.HasPrefix(payload, exifHeader):
+			if err := readExif(payload[len(exifHeader):], meta); err != nil {
+				return err
+			}
+		case isXMP(payload) && hasXMPLocation(payload):
+			meta.HasLocation = true
+		}
+	}
+	return nil
+}
+
+// StripLocation removes the location from a JPEG file's metadata: the GPS entries of its EXIF
+// data are erased, and XMP packets that record a location are dropped. The rest of the
+// metadata and the image data are kept byte for byte. It returns the file unchanged, and false,
+// if it records no location. Content that is not a JPEG file is returned unchanged.
+func StripLocation(data []byte) ([]byte, bool, error) {
+	if !isJPEG(data) {
+		return data, false, nil
+	}
+
+	segments, err := jpegSegments(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, false, err
+	}
+
+	stripped := bytes.Clone(data)
+	changed := false
+	var drop []jpegSegment
+
+	for _, segment := range segments {
+		if segment.marker != markerAPP1 {
+			continue
+		}
+		payload := stripped[segment.start+4 : segment.end]
+
+		switch {
+		case bytes.HasPrefix(payload, exifHeader):
+			cleared, err := clearGPS(payload[len(exifHeader):])
+			if err != nil {
+				return nil, false, err
+			}
+			changed = changed || cleared
+		case isXMP(payload) && hasXMPLocation(payload):
+			drop = append(drop, segment)
+		}
+	}
+
+	if len(drop) > 0 {
+		changed = true
+		kept := make([]byte, 0, len(stripped))
+		pos := int64(0)
+		for _, segment := range drop {
+			kept = append(kept, stripped[pos:segment.start]...)
+			pos = segment.end
+		}
+		stripped = append(kept, stripped[pos:]...)
+	}
+
+	if !changed {
+		return data, false, nil
+	}
+	return stripped, true, nil
+}
+
+// CanStripLocation reports whether StripLocation can remove the location from files of a
+// content type.
+func CanStripLocation(contentType string) bool {
+	return contentType == "image/jpeg"
+}
+
+// isJPEG reports whether content starts with the JPEG start-of-image marker.
+func isJPEG(header []byte) bool {
+	return len(header) >= 3 && header[0] == 0xFF && header[1] == 0xD8 && header[2] == 0xFF
+}
+
+// isXMP reports whether an APP1 payload is an XMP packet or part of one.
+func isXMP(payload []byte) bool {
+	return bytes.HasPrefix(payload, xmpHeader) || bytes.HasPrefix(payload, xmpExtensionHeader)
+}
+
+// hasXMPLocation reports whether an XMP packet records a location.
+func hasXMPLocation(payload []byte) bool {
+	for _, property := range xmpLocationProperties {
+		if bytes.Contains(payload, property) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// maxBoxDepth bounds how deeply MP4 boxes are searched.
+const maxBoxDepth = 4
+
+// readMP4 records the duration of an MP4 or QuickTime file from its movie header, and the
+// dimensions of its first video track.
+func readMP4(r io.ReaderAt, size int64, meta *models.FileMetadata) error {
+	return walkBoxes(r, 0, size, 0, func(kind string, start, end int64) error {
+		switch kind {
+		case "mvhd":
+			header := make([]byte, min(end-start, 32))
+			if _, err := r.ReadAt(header, start); err != nil {
+				return err
+			}
+
+			// Version 1 headers use 64-bit times and duration
+			var timescale uint32
+			var duration uint64
+			switch {
+			case header[0] == 0 && len(header) >= 20:
+				timescale = binary.BigEndian.Uint32(header[12:])
+				duration = uint64(binary.BigEndian.Uint32(header[16:]))
+			case header[0] == 1 && len(header) >= 32:
+				timescale = binary.BigEndian.Uint32(header[20:])
+				duration = binary.BigEndian.Uint64(header[24:])
+			default:
+				return ErrInvalid
+			}
+			if timescale > 0 {
+				meta.DurationSeconds = float64(duration) / float64(timescale)
+			}
+		case "tkhd":
+			if meta.Width > 0 {
+				return nil
+			}
+			header := make([]byte, min(end-start, 92))
+			if _, err := r.ReadAt(header, start); err != nil {
+				return err
+			}
+
+			// Sizes are 16.16 fixed point, and zero for tracks without pictures
+			offset := 76
+			if header[0] == 1 {
+				offset = 88
+			}
+			if len(header) >= offset+8 {
+				meta.Width = int(binary.BigEndian.Uint32(header[offset:]) >> 16)
+				meta.Height = int(binary.BigEndian.Uint32(header[offset+4:]) >> 16)
+			}
+		}
+		return nil
+	})
+}
+
+// walkBoxes calls fn with the type and content range of every MP4 box between start and end,
+// descending into the containers that lead to the movie and track headers.
+func walkBoxes(r io.ReaderAt, start, end int64, depth int, fn func(kind string, start, end int64) error) error {
+	header := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		content := pos + 8
+		switch size {
+		case 0:
+			// The box runs to the end of the file
+			size = end - pos
+		case 1:
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			content = pos + 16
+		}
+		if size < content-pos || pos+size > end {
+			return ErrInvalid
+		}
+
+		switch kind {
+		case "moov", "trak":
+			if depth < maxBoxDepth {
+				if err := walkBoxes(r, content, pos+size, depth+1, fn); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := fn(kind, content, pos+size); err != nil {
+				return err
+			}
+		}
+		pos += size
+	}
+	return nil
+}
+
+// readWAV records the duration of a WAV file from its format and the size of its samples.
+func readWAV(r io.ReaderAt, size int64, meta *models.FileMetadata) error {
+	var byteRate, dataSize int64
+	header := make([]byte, 8)
+
+	for pos := int64(12); pos+8 <= size; {
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return err
+		}
+		kind := string(header[:4])
+		length := int64(binary.LittleEndian.Uint32(header[4:]))
+
+		switch kind {
+		case "fmt ":
+			format := make([]byte, 12)
+			if length < 12 {
+				return ErrInvalid
+			}
+			if _, err := r.ReadAt(format, pos+8); err != nil {
+				return err
+			}
+			byteRate = int64(binary.LittleEndian.Uint32(format[8:]))
+		case "data":
+			// Recordings written as a stream may not know their size, so the rest of the file is used
+			dataSize = min(length, size-pos-8)
+		}
+		if byteRate > 0 && dataSize > 0 {
+			meta.DurationSeconds = float64(dataSize) / float64(byteRate)
+			return nil
+		}
+
+		// Chunks are padded to an even length
+		pos += 8 + length + length%2
+	}
+	return nil
+}
+
+// readFLAC records the duration of a FLAC file from its stream information block.
+func readFLAC(r io.ReaderAt, meta *models.FileMetadata) error {
+	block := make([]byte, 8+18)
+	if _, err := r.ReadAt(block, 4); err != nil {
+		return err
+	}
+	if block[0]&0x7F != 0 {
+		return ErrInvalid
+	}
+
+	// 20 bits of sample rate, 3 of channels, 5 of sample size and 36 of total samples
+	info := binary.BigEndian.Uint64(block[4+10:])
+	sampleRate := info >> 44
+	samples := info & (1<<36 - 1)
+	if sampleRate > 0 && samples > 0 {
+		meta.DurationSeconds = float64(samples) / float64(sampleRate)
+	}
+	return nil
+}
+
+// MP3 bitrates in kbit/s by bitrate index, for MPEG-1 and for MPEG-2 and 2.5 layer III.
+var (
+	mpeg1Bitrates = []int64{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mpeg2Bitrates = []int64{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+// mpeg1SampleRates are the MPEG-1 sample rates by index; MPEG-2 halves them and 2.5 quarters them.
+var mpeg1SampleRates = []int64{44100, 48000, 32000}
+
+// mp3Frame is the header of an MPEG audio layer III frame.
+type mp3Frame struct {
+	mpeg1      bool
+	mono       bool
+	bitrate    int64 // bit/s
+	sampleRate int64
+}
+
+// parseMP3Frame parses a layer III frame header, returning false if it is not one.
+func parseMP3Frame(header []byte) (mp3Frame, bool) {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+
+	version := header[1] >> 3 & 3 // 0 is MPEG-2.5, 2 is MPEG-2 and 3 is MPEG-1
+	layer := header[1] >> 1 & 3   // 1 is layer III
+	bitrateIndex := header[2] >> 4
+	rateIndex := header[2] >> 2 & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	frame := mp3Frame{mpeg1: version == 3, mono: header[3]>>6 == 3}
+	frame.sampleRate = mpeg1SampleRates[rateIndex]
+	frame.bitrate = mpeg2Bitrates[bitrateIndex] * 1000
+	switch version {
+	case 3:
+		frame.bitrate = mpeg1Bitrates[bitrateIndex] * 1000
+	case 2:
+		frame.sampleRate /= 2
+	case 0:
+		frame.sampleRate /= 4
+	}
+	return frame, true
+}
+
+// samplesPerFrame returns how many samples each frame holds.
+func (f mp3Frame) samplesPerFrame() int64 {
+	if f.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// sideInfoSize returns the size of the side information that follows the frame header, after
+// which a Xing header is placed.
+func (f mp3Frame) sideInfoSize() int {
+	switch {
+	case f.mpeg1 && f.mono:
+		return 17
+	case f.mpeg1:
+		return 32
+	case f.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// readMP3 records the duration of an MP3 file. Variable bitrate files state their frame count in
+// a Xing or VBRI header; without one the bitrate of the first frame is assumed throughout.
+func readMP3(r io.ReaderAt, size int64, meta *models.FileMetadata) error {
+	start := int64(0)
+	header := make([]byte, 10)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return err
+	}
+	if bytes.HasPrefix(header, []byte("ID3")) {
+		// An ID3v2 tag comes first, with its size in 7-bit bytes and an optional footer
+		start = 10 + (int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9]))
+		if header[5]&0x10 != 0 {
+			start += 10
+		}
+	}
+
+	end := size
+	trailer := make([]byte, 3)
+	if size >= 128 {
+		if _, err := r.ReadAt(trailer, size-128); err == nil && string(trailer) == "TAG" {
+			end -= 128
+		}
+	}
+
+	// The first frame holds any Xing or VBRI header, and both fit in its first 64 bytes
+	frameHeader := make([]byte, 64)
+	if start+int64(len(frameHeader)) > end {
+		return ErrInvalid
+	}
+	if _, err := r.ReadAt(frameHeader, start); err != nil {
+		return err
+	}
+	frame, ok := parseMP3Frame(frameHeader)
+	if !ok {
+		return ErrInvalid
+	}
+
+	var frames int64
+	xing := 4 + frame.sideInfoSize()
+	switch tag := string(frameHeader[xing : xing+4]); {
+	case tag == "Xing" || tag == "Info":
+		if binary.BigEndian.Uint32(frameHeader[xing+4:])&1 != 0 {
+			frames = int64(binary.BigEndian.Uint32(frameHeader[xing+8:]))
+		}
+	case string(frameHeader[36:40]) == "VBRI":
+		frames = int64(binary.BigEndian.Uint32(frameHeader[36+14:]))
+	}
+
+	if frames > 0 {
+		meta.DurationSeconds = float64(frames*frame.samplesPerFrame()) / float64(frame.sampleRate)
+	} else {
+		meta.DurationSeconds = float64((end-start)*8) / float64(frame.bitrate)
+	}
+	return nil
+}
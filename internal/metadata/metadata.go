@@ -0,0 +1,83 @@
+// Package metadata reads technical metadata from uploaded files in pure Go: image dimensions,
+// EXIF camera details and capture time, PDF page counts and audio and video durations. It can
+// also remove the location a JPEG photo records before the photo is stored.
+package metadata
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"  // Registers the GIF decoder with image.DecodeConfig
+	_ "image/jpeg" // Registers the JPEG decoder with image.DecodeConfig
+	_ "image/png"  // Registers the PNG decoder with image.DecodeConfig
+	"io"
+	"math"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder with image.DecodeConfig
+)
+
+// ErrInvalid is returned for content that claims a format but does not follow it.
+var ErrInvalid = errors.New("invalid or damaged file content")
+
+// sniffSize is how much of a file is read to recognise its format.
+const sniffSize = 16
+
+// Extract reads the metadata of a file's content, recognising its format from the content
+// itself. Formats without readable metadata give a record with only ExtractedAt set. When a
+// recognised format turns out to be damaged, whatever was read before the damage is returned
+// together with the error.
+func Extract(r io.ReaderAt, size int64) (*models.FileMetadata, error) {
+	meta := &models.FileMetadata{ExtractedAt: time.Now()}
+
+	header := make([]byte, sniffSize)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	header = header[:n]
+
+	err = nil
+	switch {
+	case isJPEG(header):
+		if err := readImage(r, size, meta); err != nil {
+			return meta, err
+		}
+		err = readJPEG(r, size, meta)
+	case bytes.HasPrefix(header, []byte("\x89PNG")), bytes.HasPrefix(header, []byte("GIF8")), isRIFF(header, "WEBP"):
+		err = readImage(r, size, meta)
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		err = readPDF(r, size, meta)
+	case len(header) >= 8 && string(header[4:8]) == "ftyp":
+		err = readMP4(r, size, meta)
+	case isRIFF(header, "WAVE"):
+		err = readWAV(r, size, meta)
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		err = readFLAC(r, meta)
+	case bytes.HasPrefix(header, []byte("ID3")), len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		err = readMP3(r, size, meta)
+	}
+
+	// Whole milliseconds are all any player shows
+	meta.DurationSeconds = math.Round(meta.DurationSeconds*1000) / 1000
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = ErrInvalid
+	}
+	return meta, err
+}
+
+// readImage records an image's dimensions from its header.
+func readImage(r io.ReaderAt, size int64, meta *models.FileMetadata) error {
+	cfg, _, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return ErrInvalid
+	}
+	meta.Width, meta.Height = cfg.Width, cfg.Height
+	return nil
+}
+
+// isRIFF reports whether content starts with a RIFF header of the given form type.
+func isRIFF(header []byte, form string) bool {
+	return len(header) >= 12 && string(header[:4]) == "RIFF" && string(header[8:12]) == form
+}
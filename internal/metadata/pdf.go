@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"io"
+	"regexp"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// maxPDFScan is the largest PDF whose pages are counted. Counting reads the whole file, and a
+// partial count would be wrong rather than missing.
+const maxPDFScan = 256 << 20
+
+// pdfChunkSize is how much of a PDF is searched at a time.
+const pdfChunkSize = 1 << 20
+
+// pdfOverlap is how much of the previous chunk is searched again, so page objects split between
+// chunks are still found. It is longer than any match.
+const pdfOverlap = 64
+
+// pdfPage matches the type of a page object, but not of the page tree nodes typed /Pages.
+var pdfPage = regexp.MustCompile(`/Type\s{0,16}/Page[^s]`)
+
+// readPDF records the page count of a PDF file by counting its page objects. Page objects
+// compressed into object streams cannot be seen, so files written that way have no count.
+func readPDF(r io.ReaderAt, size int64, meta *models.FileMetadata) error {
+	if size > maxPDFScan {
+		return nil
+	}
+
+	pages := 0
+	buf := make([]byte, pdfOverlap+pdfChunkSize)
+	carried := 0
+	for pos := int64(0); pos < size; {
+		n, err := r.ReadAt(buf[carried:], pos)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		window := buf[:carried+n]
+
+		// Matches ending in the carried part were counted with the previous chunk
+		for _, match := range pdfPage.FindAllIndex(window, -1) {
+			if match[1] > carried {
+				pages++
+			}
+		}
+
+		pos += int64(n)
+		carried = min(pdfOverlap, len(window))
+		copy(buf, window[len(window)-carried:])
+		if n == 0 {
+			break
+		}
+	}
+
+	meta.PageCount = pages
+	return nil
+}
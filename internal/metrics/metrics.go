@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// Storage scrubber counters, published through expvar.
+var (
+	ScrubFilesVerified  = expvar.NewInt("scrub_files_verified_total")
+	ScrubFilesCorrupted = expvar.NewInt("scrub_files_corrupted_total")
+	ScrubBytesRead      = expvar.NewInt("scrub_bytes_read_total")
+	ScrubErrors         = expvar.NewInt("scrub_errors_total")
+)
+
+// Replicated storage counters, published through expvar.
+var (
+	ReplicaTasksQueued    = expvar.NewInt("replica_tasks_queued_total")
+	ReplicaTasksCompleted = expvar.NewInt("replica_tasks_completed_total")
+	ReplicaTasksFailed    = expvar.NewInt("replica_task_failures_total")
+)
+
+// Storage migration counters, published through expvar.
+var (
+	MigrationFilesMoved  = expvar.NewInt("migration_files_moved_total")
+	MigrationBytesCopied = expvar.NewInt("migration_bytes_copied_total")
+	MigrationErrors      = expvar.NewInt("migration_errors_total")
+)
+
+// Lifecycle rule counters, published through expvar.
+var (
+	LifecycleFilesTransitioned = expvar.NewInt("lifecycle_files_transitioned_total")
+	LifecycleFilesExpired      = expvar.NewInt("lifecycle_files_expired_total")
+	LifecycleErrors            = expvar.NewInt("lifecycle_errors_total")
+)
+
+// Thumbnail generation counters, published through expvar.
+var (
+	ThumbnailFilesGenerated = expvar.NewInt("thumbnail_files_generated_total")
+	ThumbnailFilesFailed    = expvar.NewInt("thumbnail_files_failed_total")
+	ThumbnailErrors         = expvar.NewInt("thumbnail_errors_total")
+)
+
+// Malware scan retry counters, published through expvar.
+var (
+	ScanFilesClean    = expvar.NewInt("scan_files_clean_total")
+	ScanFilesInfected = expvar.NewInt("scan_files_infected_total")
+	ScanErrors        = expvar.NewInt("scan_errors_total")
+)
+
+// URL import counters, published through expvar.
+var (
+	ImportsCompleted      = expvar.NewInt("imports_completed_total")
+	ImportsFailed         = expvar.NewInt("imports_failed_total")
+	ImportBytesDownloaded = expvar.NewInt("import_bytes_downloaded_total")
+)
+
+// Handler serves every published metric as JSON.
+func Handler() http.Handler {
+	return expvar.Handler()
+}
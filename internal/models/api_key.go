@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey represents a long-lived, user-managed credential for scripts and CI.
+// Only a hash of the key is stored; the prefix is kept so users can tell keys apart.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"` // References the user who owns the key
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Non-secret leading part of the key, safe to display
+	KeyHash    string     `json:"-"`      // Never expose the key hash in JSON
+	Scopes     []string   `json:"scopes"`
+	Resources  []string   `json:"resources,omitempty"` // Folder prefixes the key is restricted to, empty for all
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// NewAPIKey creates a new APIKey instance.
+func NewAPIKey(id, userID, name, prefix, keyHash string, scopes, resources []string, expiresAt *time.Time) *APIKey {
+	return &APIKey{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		Resources: resources,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// IsExpired checks if the key has passed its expiry time.
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// IsRevoked checks if the key has been revoked by its owner.
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsActive checks if the key can still be used to authenticate.
+func (k *APIKey) IsActive() bool {
+	return !k.IsExpired() && !k.IsRevoked()
+}
+
+// JoinList serializes a list of scopes or resources for storage in a single text column.
+func JoinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+// SplitList parses a list stored by JoinList.
+func SplitList(values string) []string {
+	if values == "" {
+		return []string{}
+	}
+	return strings.Split(values, ",")
+}
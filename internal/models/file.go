@@ -4,34 +4,90 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Scan states of a file. Only clean files may be downloaded or shared.
+const (
+	ScanStatusPending  = "pending"
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+)
+
+// Integrity states of a file, set by the storage scrubber.
+const (
+	IntegrityUnverified = "unverified"
+	IntegrityOK         = "ok"
+	IntegrityCorrupted  = "corrupted"
+)
+
+// Lifecycle states of a file row. Only active files are visible; the others mark a create
+// or delete that is half done and will be finished or rolled back by reconciliation.
+const (
+	FileStatePending  = "pending"
+	FileStateActive   = "active"
+	FileStateDeleting = "deleting"
+)
+
+// Thumbnail states of a file. Files that are not images have no thumbnails; images wait for
+// the thumbnail generator until it has stored every size or given up on them.
+const (
+	ThumbnailNone    = "none"
+	ThumbnailPending = "pending"
+	ThumbnailReady   = "ready"
+	ThumbnailFailed  = "failed"
+)
+
 // File represents a file stored in the system.
 type File struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Path        string    `json:"path"`
-	Url         string    `json:"url"` // URL to access the file
-	Size        int64     `json:"size"`
-	ContentType string    `json:"content_type"`
-	OwnerID     string    `json:"owner_id"` // References the user who uploaded the file
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	Url         string     `json:"url"` // URL to access the file
+	StorageKey  string     `json:"-"`   // Key of the object in the storage bucket
+	Backend     string     `json:"-"`   // Storage provider holding the object, empty if not recorded
+	State       string     `json:"-"`   // "pending", "active" or "deleting"
+	Size        int64      `json:"size"`
+	ContentType string     `json:"content_type"`
+	OwnerID     string     `json:"owner_id"`    // References the user who uploaded the file
+	ScanStatus  string     `json:"scan_status"` // "pending", "clean" or "infected"
+	ScannedAt   *time.Time `json:"scanned_at,omitempty"`
+
+	ChecksumSHA256  string     `json:"checksum_sha256,omitempty"`
+	ChecksumMD5     string     `json:"checksum_md5,omitempty"`
+	ChecksumCRC32C  uint32     `json:"checksum_crc32c,omitempty"`
+	IntegrityStatus string     `json:"integrity_status"` // "unverified", "ok" or "corrupted"
+	VerifiedAt      *time.Time `json:"verified_at,omitempty"`
+
+	LastAccessedAt  *time.Time    `json:"last_accessed_at,omitempty"`
+	StorageClass    string        `json:"storage_class,omitempty"` // Set by lifecycle rules
+	ThumbnailStatus string        `json:"thumbnail_status"`        // "none", "pending", "ready" or "failed"
+	Tags            []string      `json:"tags,omitempty"`          // Only loaded where needed
+	Metadata        *FileMetadata `json:"metadata,omitempty"`      // Only loaded where needed
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // NewFile creates a new File instance.
 func NewFile(id, name, path, url, contentType, ownerID string, size int64) *File {
 	return &File{
-		ID:          id,
-		Name:        name,
-		Path:        path,
-		Url:         url,
-		Size:        size,
-		ContentType: contentType,
-		OwnerID:     ownerID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              id,
+		Name:            name,
+		Path:            path,
+		Url:             url,
+		StorageKey:      StorageKeyFor(id),
+		Size:            size,
+		ContentType:     contentType,
+		OwnerID:         ownerID,
+		ScanStatus:      ScanStatusPending,
+		State:           FileStatePending,
+		IntegrityStatus: IntegrityUnverified,
+		ThumbnailStatus: ThumbnailNone,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 }
 
@@ -47,6 +103,67 @@ func (f *File) UpdateFile(name, path, url, contentType string, size int64) {
 	f.UpdatedAt = time.Now()
 }
 
+// Key prefixes in the storage bucket. File contents are stored under storageKeyPrefix; files
+// stored before that kept their upload name as key, and objects derived from those are kept
+// under legacyDerivedKeyPrefix.
+const (
+	storageKeyPrefix       = "files/"
+	legacyDerivedKeyPrefix = "derived/"
+)
+
+// StorageKeyFor returns the object key a file's content is stored under.
+func StorageKeyFor(fileID string) string {
+	return storageKeyPrefix + fileID
+}
+
+// DerivedKeyPrefix returns the prefix of the objects generated from a file's content, such as
+// thumbnails. They are stored next to the original object and removed with it. Legacy keys are
+// upload names, which may contain dots, so the prefix of one could also match another file's
+// object; their derived objects are kept apart and separated by a slash, which names never hold.
+func DerivedKeyPrefix(storageKey string) string {
+	if !strings.HasPrefix(storageKey, storageKeyPrefix) {
+		return legacyDerivedKeyPrefix + storageKey + "/"
+	}
+	return storageKey + "."
+}
+
+// DerivedKeyFor returns the key of a named object generated from a file's content.
+func DerivedKeyFor(storageKey, name string) string {
+	return DerivedKeyPrefix(storageKey) + name
+}
+
+// ThumbnailKeyFor returns the object key of a file's thumbnail of the given size.
+func ThumbnailKeyFor(storageKey string, size int) string {
+	return DerivedKeyFor(storageKey, "thumb-"+strconv.Itoa(size))
+}
+
+// ParentStorageKey returns the key of the object a derived object was generated from, and
+// false for keys that are not derived objects.
+func ParentStorageKey(key string) (string, bool) {
+	if rest, ok := strings.CutPrefix(key, legacyDerivedKeyPrefix); ok {
+		i := strings.LastIndex(rest, "/")
+		if i <= 0 {
+			return "", false
+		}
+		return rest[:i], true
+	}
+	if !strings.HasPrefix(key, storageKeyPrefix) {
+		return "", false
+	}
+
+	name := key[strings.LastIndex(key, "/")+1:]
+	i := strings.Index(name, ".")
+	if i <= 0 {
+		return "", false
+	}
+	return key[:len(key)-len(name)+i], true
+}
+
+// IsClean reports whether the file has been scanned and found clean.
+func (f *File) IsClean() bool {
+	return f.ScanStatus == ScanStatusClean
+}
+
 // IsOwner checks if the provided userID matches the file's owner ID.
 func (f *File) IsOwner(userID string) bool {
 	return f.OwnerID == userID
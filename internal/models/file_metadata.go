@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// FileMetadata is technical metadata read from a file's content when it was uploaded. Fields
+// that do not apply to the file's format, or that it does not record, are left empty.
+type FileMetadata struct {
+	FileID           string     `json:"-"`
+	Width            int        `json:"width,omitempty"` // Pixels, for images and video
+	Height           int        `json:"height,omitempty"`
+	CameraMake       string     `json:"camera_make,omitempty"`
+	CameraModel      string     `json:"camera_model,omitempty"`
+	CapturedAt       *time.Time `json:"captured_at,omitempty"`
+	Orientation      int        `json:"orientation,omitempty"` // EXIF orientation, 1 to 8
+	HasLocation      bool       `json:"has_location"`          // Whether the stored content records where it was taken
+	LocationStripped bool       `json:"location_stripped"`     // Whether a location was removed before the content was stored
+	PageCount        int        `json:"page_count,omitempty"`
+	DurationSeconds  float64    `json:"duration_seconds,omitempty"`
+	ExtractedAt      time.Time  `json:"extracted_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDerivedKeys(t *testing.T) {
+	tests := []struct {
+		storageKey string
+		thumbnail  string
+	}{
+		{"files/0b7c", "files/0b7c.thumb-128"},
+		{"report", "derived/report/thumb-128"},
+		{"report.pdf", "derived/report.pdf/thumb-128"},
+	}
+	for _, tt := range tests {
+		thumbnail := ThumbnailKeyFor(tt.storageKey, 128)
+		if thumbnail != tt.thumbnail {
+			t.Errorf("ThumbnailKeyFor(%q) = %q, want %q", tt.storageKey, thumbnail, tt.thumbnail)
+		}
+		if parent, ok := ParentStorageKey(thumbnail); !ok || parent != tt.storageKey {
+			t.Errorf("ParentStorageKey(%q) = %q, %v, want %q, true", thumbnail, parent, ok, tt.storageKey)
+		}
+	}
+}
+
+func TestDerivedKeyPrefixMatchesOnlyItsFile(t *testing.T) {
+	// Legacy keys are upload names, so one can be the start of another
+	keys := []string{"report", "report.pdf", "files/0b7c", "files/0b7c1"}
+	for _, key := range keys {
+		prefix := DerivedKeyPrefix(key)
+		for _, other := range keys {
+			if strings.HasPrefix(other, prefix) {
+				t.Errorf("DerivedKeyPrefix(%q) = %q also matches the object of %q", key, prefix, other)
+			}
+			if derived := ThumbnailKeyFor(other, 128); other != key && strings.HasPrefix(derived, prefix) {
+				t.Errorf("DerivedKeyPrefix(%q) = %q also matches %q, derived from %q", key, prefix, derived, other)
+			}
+		}
+	}
+}
+
+func TestParentStorageKeyIgnoresLegacyNames(t *testing.T) {
+	for _, key := range []string{"report.pdf", "archive.tar.gz", "files/0b7c", "derived/report"} {
+		if parent, ok := ParentStorageKey(key); ok {
+			t.Errorf("ParentStorageKey(%q) = %q, true, want a key that is not derived", key, parent)
+		}
+	}
+}
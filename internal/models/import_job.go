@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Statuses of an import job.
+const (
+	ImportPending   = "pending"   // Waiting for the importer
+	ImportRunning   = "running"   // Being downloaded and stored
+	ImportCompleted = "completed" // Stored as FileID
+	ImportFailed    = "failed"    // Gave up, as Error explains
+)
+
+// ImportJob fetches a file from a remote URL in the background and stores it as a new file of
+// its owner.
+type ImportJob struct {
+	ID              string     `json:"id"`
+	OwnerID         string     `json:"owner_id"`
+	URL             string     `json:"url"`
+	Path            string     `json:"path"` // Path of the file to create
+	Status          string     `json:"status"`
+	BytesDownloaded int64      `json:"bytes_downloaded"`
+	TotalBytes      int64      `json:"total_bytes,omitempty"` // From the response's Content-Length, zero if unknown
+	FileID          string     `json:"file_id,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"` // Last change of status or progress
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// NewImportJob creates a new ImportJob instance waiting to be run.
+func NewImportJob(id, ownerID, url, path string) *ImportJob {
+	now := time.Now()
+	return &ImportJob{
+		ID:        id,
+		OwnerID:   ownerID,
+		URL:       url,
+		Path:      path,
+		Status:    ImportPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsFinished reports whether the job has completed or failed.
+func (j *ImportJob) IsFinished() bool {
+	return j.Status == ImportCompleted || j.Status == ImportFailed
+}
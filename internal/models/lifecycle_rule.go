@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Actions a lifecycle rule applies to the files it matches.
+const (
+	LifecycleMoveToCold        = "move_to_cold"        // Move the object to the cold storage backend
+	LifecycleSetStorageClass   = "set_storage_class"   // Change the object's storage class in place
+	LifecycleExpire            = "expire"              // Delete the file
+	LifecycleDeleteOldVersions = "delete_old_versions" // Delete the file if a newer one has its owner and path
+)
+
+// LifecycleRule moves or expires files that match all of its conditions. Zero conditions are
+// ignored, but every rule needs at least one, and expiring rules one based on time. Rules that
+// delete old versions only match files superseded by a newer upload, which is condition enough.
+type LifecycleRule struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Action          string    `json:"action"`
+	StorageClass    string    `json:"storage_class,omitempty"`
+	OlderThanDays   int       `json:"older_than_days,omitempty"`   // Created more than this many days ago
+	NotAccessedDays int       `json:"not_accessed_days,omitempty"` // Not downloaded for this many days
+	MinSize         int64     `json:"min_size,omitempty"`          // At least this many bytes
+	Folder          string    `json:"folder,omitempty"`            // In this folder or below it
+	Tag             string    `json:"tag,omitempty"`
+	DryRun          bool      `json:"dry_run"` // Only report the files the rule matches
+	CreatedBy       string    `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// NewLifecycleRule creates a new LifecycleRule instance.
+func NewLifecycleRule(id, name, action, createdBy string) *LifecycleRule {
+	return &LifecycleRule{
+		ID:        id,
+		Name:      name,
+		Action:    action,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Validate checks that the rule is complete and normalizes its folder.
+func (r *LifecycleRule) Validate() error {
+	switch r.Action {
+	case LifecycleMoveToCold, LifecycleExpire, LifecycleDeleteOldVersions:
+		if r.StorageClass != "" {
+			return errors.New("storage_class only applies to the set_storage_class action")
+		}
+	case LifecycleSetStorageClass:
+		if r.StorageClass == "" {
+			return errors.New("storage_class is required for the set_storage_class action")
+		}
+	default:
+		return errors.New("action must be move_to_cold, set_storage_class, expire or delete_old_versions")
+	}
+
+	if r.OlderThanDays < 0 || r.NotAccessedDays < 0 || r.MinSize < 0 {
+		return errors.New("conditions cannot be negative")
+	}
+
+	r.Folder = strings.TrimSuffix(r.Folder, "/")
+	timed := r.OlderThanDays > 0 || r.NotAccessedDays > 0
+	if !timed && r.MinSize == 0 && r.Folder == "" && r.Tag == "" && r.Action != LifecycleDeleteOldVersions {
+		return errors.New("a rule needs at least one condition")
+	}
+	if r.Action == LifecycleExpire && !timed {
+		return errors.New("expiring rules need older_than_days or not_accessed_days")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// MFASettings holds a user's TOTP second-factor enrollment.
+type MFASettings struct {
+	UserID       string     `json:"user_id"`
+	Secret       string     `json:"-"` // Never expose the TOTP secret after enrollment
+	Enabled      bool       `json:"enabled"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `json:"-"` // Last accepted TOTP time step
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// NewMFASettings creates a new, not yet enabled, MFASettings instance.
+func NewMFASettings(userID, secret string) *MFASettings {
+	return &MFASettings{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// OAuthIdentity links a user to an identity at an OAuth provider, so they can sign in with it.
+type OAuthIdentity struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"` // The provider's stable ID for the user
+	Email     string    `json:"email"`   // Address the provider reported when the identity was linked
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewOAuthIdentity creates a new OAuthIdentity instance.
+func NewOAuthIdentity(id, userID, provider, subject, email string) *OAuthIdentity {
+	return &OAuthIdentity{
+		ID:        id,
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Quota limits how much a user may store. A zero limit means unlimited.
+type Quota struct {
+	MaxBytes int64 `json:"max_bytes"`
+	MaxFiles int64 `json:"max_files"`
+}
+
+// Allows reports whether adding the given bytes and files keeps usage within the quota.
+func (q Quota) Allows(usage *StorageUsage, bytes, files int64) bool {
+	if q.MaxBytes > 0 && usage.BytesUsed+bytes > q.MaxBytes {
+		return false
+	}
+	if q.MaxFiles > 0 && usage.FileCount+files > q.MaxFiles {
+		return false
+	}
+	return true
+}
+
+// StorageUsage tracks how much a user stores, along with any per-user quota override.
+type StorageUsage struct {
+	UserID     string    `json:"user_id"`
+	BytesUsed  int64     `json:"bytes_used"`
+	FileCount  int64     `json:"file_count"`
+	QuotaBytes *int64    `json:"quota_bytes,omitempty"` // Overrides the default byte limit when set
+	QuotaFiles *int64    `json:"quota_files,omitempty"` // Overrides the default file limit when set
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EffectiveQuota applies the user's overrides to the default quota for their role.
+func (u *StorageUsage) EffectiveQuota(defaults Quota) Quota {
+	quota := defaults
+	if u.QuotaBytes != nil {
+		quota.MaxBytes = *u.QuotaBytes
+	}
+	if u.QuotaFiles != nil {
+		quota.MaxFiles = *u.QuotaFiles
+	}
+	return quota
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Operations a replica task repeats on a backend that missed it.
+const (
+	ReplicaOpWrite  = "write"
+	ReplicaOpDelete = "delete"
+)
+
+// ReplicaTask records a write or delete that succeeded on enough replicas to be accepted but
+// failed on one backend, so it can be repeated there later.
+type ReplicaTask struct {
+	ID             string
+	StorageKey     string
+	Backend        string // Name of the replica that missed the operation
+	Operation      string
+	Size           int64  // Size of the object to copy, -1 if unknown
+	ChecksumSHA256 string // Checked when the object is copied, empty to skip
+	Attempts       int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+}
+
+// NewReplicaTask creates a new ReplicaTask instance that is due immediately.
+func NewReplicaTask(id, storageKey, backend, operation string, size int64, checksumSHA256, lastError string) *ReplicaTask {
+	now := time.Now()
+	return &ReplicaTask{
+		ID:             id,
+		StorageKey:     storageKey,
+		Backend:        backend,
+		Operation:      operation,
+		Size:           size,
+		ChecksumSHA256: checksumSHA256,
+		LastError:      lastError,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -9,12 +10,16 @@ import (
 
 // User represents a user in the system.
 type User struct {
-	ID        string    `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"` // Never expose password in JSON
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Password        string     `json:"-"`                           // Never expose password in JSON
+	Role            string     `json:"role"`                        // e.g., "user" or "admin", caps the scopes the user can be granted
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // Nil until the user confirms their email
+	IsAnonymous     bool       `json:"is_anonymous"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"` // When an anonymous user and their files are removed
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // NewUser creates a new User instance.
@@ -33,11 +38,55 @@ func NewUser(id, username, email, password string) (*User, error) {
 		Username:  username,
 		Email:     email,
 		Password:  hashedPassword,
+		Role:      "user",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}, nil
 }
 
+// NewAnonymousUser creates a placeholder User for someone who has not registered yet.
+// The username and email are unique placeholders and the empty password hash never matches.
+func NewAnonymousUser(id string, ttl time.Duration) *User {
+	expiresAt := time.Now().Add(ttl)
+	return &User{
+		ID:          id,
+		Username:    "anonymous-" + id,
+		Email:       id + "@anonymous.invalid",
+		Role:        "user",
+		IsAnonymous: true,
+		ExpiresAt:   &expiresAt,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// Register turns an anonymous user into a regular account, keeping its ID and therefore its files.
+func (u *User) Register(username, email, password string) error {
+	if username == "" || email == "" || password == "" {
+		return errors.New("username, email, and password are required")
+	}
+	if err := u.UpdateUser(username, email, password); err != nil {
+		return err
+	}
+	u.IsAnonymous = false
+	u.ExpiresAt = nil
+	return nil
+}
+
+// RegisterIdentity turns an anonymous user into a regular account that signs in through an OAuth
+// identity instead of a password. The account has no password until the user resets it.
+func (u *User) RegisterIdentity(username, email string) error {
+	if username == "" || email == "" {
+		return errors.New("username and email are required")
+	}
+	if err := u.UpdateUser(username, email, ""); err != nil {
+		return err
+	}
+	u.IsAnonymous = false
+	u.ExpiresAt = nil
+	return nil
+}
+
 // UpdateUser updates the user information.
 func (u *User) UpdateUser(username, email, password string) error {
 	u.Username = username
@@ -59,6 +108,21 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// maxPasswordLength is the most bytes of a password bcrypt can hash.
+const maxPasswordLength = 72
+
+// ValidatePassword checks that a password can be hashed, so it can be rejected before anything
+// that cannot be undone, such as redeeming a single-use token.
+func ValidatePassword(password string) error {
+	if password == "" {
+		return errors.New("password is required")
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
 // hashPassword hashes a plain text password.
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
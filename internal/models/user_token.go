@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Purposes a single-use user token can be issued for.
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+// UserToken is a single-use, expiring token sent to a user by email. It is bound to the address
+// it was sent to, so it stops working if the user's email changes.
+type UserToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Purpose   string     `json:"purpose"`
+	Email     string     `json:"email"` // Address the token was sent to
+	TokenHash string     `json:"-"`     // Never expose the token hash in JSON
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewUserToken creates a new UserToken instance valid for the given duration.
+func NewUserToken(id, userID, purpose, email, tokenHash string, ttl time.Duration) *UserToken {
+	return &UserToken{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   purpose,
+		Email:     email,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+}
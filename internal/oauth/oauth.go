@@ -0,0 +1,255 @@
+// Package oauth verifies OpenID Connect ID tokens, so users can sign in with an identity a
+// provider vouches for. Tokens are checked against the provider's published signing keys and
+// must be issued by the configured issuer for the configured client.
+package oauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// clockSkew is how far the provider's clock may differ from ours when checking token times.
+const clockSkew = time.Minute
+
+// keyRefreshInterval is the least time between fetches of a provider's keys, so tokens signed
+// with unknown keys cannot make the server hammer the provider.
+const keyRefreshInterval = time.Minute
+
+// Errors verifying an ID token.
+var (
+	ErrUnknownProvider     = errors.New("unknown OAuth provider")
+	ErrInvalidToken        = errors.New("ID token is invalid")
+	ErrProviderUnavailable = errors.New("signing keys of the OAuth provider could not be fetched")
+)
+
+// Provider is an OpenID Connect provider whose ID tokens are accepted.
+type Provider struct {
+	Name     string
+	Issuer   string
+	ClientID string
+	JWKSURL  string
+}
+
+// Identity is a user identity a provider has vouched for.
+type Identity struct {
+	Provider      string
+	Subject       string // The provider's stable ID for the user
+	Email         string
+	EmailVerified bool // The provider has checked the user owns Email
+}
+
+// Verifier checks ID tokens issued by a fixed set of providers.
+type Verifier struct {
+	providers map[string]*keySet
+}
+
+// NewVerifier creates a Verifier for the providers, fetching their keys with client.
+func NewVerifier(providers []Provider, client *http.Client) *Verifier {
+	v := &Verifier{providers: make(map[string]*keySet, len(providers))}
+	for _, provider := range providers {
+		v.providers[provider.Name] = &keySet{provider: provider, client: client}
+	}
+	return v
+}
+
+// Verify checks an ID token from the named provider and returns the identity it asserts.
+func (v *Verifier) Verify(ctx context.Context, provider, idToken string) (*Identity, error) {
+	keys, ok := v.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	claims := &idTokenClaims{}
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg()}}
+	_, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys.key(ctx, kid)
+	})
+	if verr, ok := err.(*jwt.ValidationError); ok && errors.Is(verr.Inner, ErrProviderUnavailable) {
+		return nil, verr.Inner
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Issuer != keys.provider.Issuer {
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidToken, claims.Issuer)
+	}
+	if !claims.Audience.contains(keys.provider.ClientID) {
+		return nil, fmt.Errorf("%w: issued for another client", ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+
+	return &Identity{
+		Provider:      provider,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+// idTokenClaims are the claims of an ID token that identify the user and limit its use.
+type idTokenClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	ExpiresAt     int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Email         string   `json:"email"`
+	EmailVerified flag     `json:"email_verified"`
+}
+
+// Valid checks the token is within its lifetime, allowing for clock skew.
+func (c *idTokenClaims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.Add(-clockSkew).After(time.Unix(c.ExpiresAt, 0)) {
+		return errors.New("token has expired")
+	}
+	if c.IssuedAt != 0 && now.Add(clockSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return errors.New("token is issued in the future")
+	}
+	return nil
+}
+
+// audience is the aud claim, which is either a single client ID or a list of them.
+type audience []string
+
+// UnmarshalJSON accepts a string or a list of strings.
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// contains reports whether the token was issued for a client.
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// flag is a boolean claim, which some providers send as the string "true" or "false".
+type flag bool
+
+// UnmarshalJSON accepts a boolean or its string form.
+func (f *flag) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*f = flag(v)
+	case string:
+		*f = flag(v == "true")
+	}
+	return nil
+}
+
+// keySet caches a provider's signing keys, fetching them again when a token names a key it does
+// not know, as happens when the provider rotates its keys.
+type keySet struct {
+	provider Provider
+	client   *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	fetchErr  error // Error of the last fetch, kept until the next one is allowed
+}
+
+// key returns the public key with the given ID.
+func (s *keySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(s.fetchedAt) < keyRefreshInterval {
+		if s.fetchErr != nil {
+			return nil, s.fetchErr
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := s.fetch(ctx)
+	s.fetchedAt, s.fetchErr = time.Now(), err
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// jwk is an RSA signing key in a JSON Web Key Set.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// fetch downloads the provider's RSA signing keys.
+func (s *keySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.provider.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrProviderUnavailable, resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(key.N)
+		e, errE := base64.RawURLEncoding.DecodeString(key.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
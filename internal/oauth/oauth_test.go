@@ -0,0 +1,153 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newProvider(t *testing.T) (Provider, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "key-1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(server.Close)
+	return Provider{Name: "example", Issuer: "https://id.example.com", ClientID: "client-1", JWKSURL: server.URL}, key
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	provider, key := newProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier([]Provider{provider}, http.DefaultClient)
+
+	claims := func(override jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":            provider.Issuer,
+			"sub":            "user-42",
+			"aud":            provider.ClientID,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+			"email":          "alice@example.com",
+			"email_verified": true,
+		}
+		for k, v := range override {
+			c[k] = v
+		}
+		return c
+	}
+
+	identity, err := verifier.Verify(context.Background(), "example", sign(t, key, "key-1", claims(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Provider: "example", Subject: "user-42", Email: "alice@example.com", EmailVerified: true}
+	if *identity != want {
+		t.Errorf("Verify() = %+v, want %+v", *identity, want)
+	}
+
+	identity, err = verifier.Verify(context.Background(), "example",
+		sign(t, key, "key-1", claims(jwt.MapClaims{"aud": []string{"other", provider.ClientID}, "email_verified": "false"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.EmailVerified {
+		t.Error("Verify() reports an unverified email as verified")
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other audience", sign(t, key, "key-1", claims(jwt.MapClaims{"aud": "client-2"}))},
+		{"other issuer", sign(t, key, "key-1", claims(jwt.MapClaims{"iss": "https://evil.example.com"}))},
+		{"expired", sign(t, key, "key-1", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}))},
+		{"no expiry", sign(t, key, "key-1", claims(jwt.MapClaims{"exp": 0}))},
+		{"no subject", sign(t, key, "key-1", claims(jwt.MapClaims{"sub": ""}))},
+		{"other key", sign(t, otherKey, "key-1", claims(nil))},
+		{"unknown key", sign(t, key, "key-2", claims(nil))},
+		{"garbage", "not-a-token"},
+	}
+	for _, tt := range tests {
+		if _, err := verifier.Verify(context.Background(), "example", tt.token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Verify() error = %v, want ErrInvalidToken", tt.name, err)
+		}
+	}
+
+	if _, err := verifier.Verify(context.Background(), "other", sign(t, key, "key-1", claims(nil))); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Verify() with unknown provider error = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestVerifyProviderUnavailable(t *testing.T) {
+	provider, key := newProvider(t)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	provider.JWKSURL = down.URL
+	verifier := NewVerifier([]Provider{provider}, http.DefaultClient)
+
+	token := sign(t, key, "key-1", jwt.MapClaims{
+		"iss": provider.Issuer,
+		"sub": "user-42",
+		"aud": provider.ClientID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := verifier.Verify(context.Background(), "example", token); !errors.Is(err, ErrProviderUnavailable) {
+			t.Errorf("Verify() error = %v, want ErrProviderUnavailable", err)
+		}
+	}
+}
+
+func TestVerifyRejectsOtherAlgorithms(t *testing.T) {
+	provider, _ := newProvider(t)
+	verifier := NewVerifier([]Provider{provider}, http.DefaultClient)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": provider.Issuer,
+		"sub": "user-42",
+		"aud": provider.ClientID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), "example", signed); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() of HS256 token error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,257 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/souvik03-136/Go-Store/internal/config"
+)
+
+// Names of the rules an upload can break, reported in Violation.Rule.
+const (
+	RuleMaxSize             = "max_size"
+	RuleTypeNotAllowed      = "type_not_allowed"
+	RuleTypeBlocked         = "type_blocked"
+	RuleExtensionNotAllowed = "extension_not_allowed"
+	RuleExtensionBlocked    = "extension_blocked"
+)
+
+// Rules restrict what may be uploaded. Zero values impose no restriction. Types may be exact
+// MIME types or wildcards such as "image/*"; extensions include the leading dot.
+// StripLocation removes the location from photos' metadata before they are stored.
+type Rules struct {
+	MaxSize           int64    `json:"max_size"`
+	AllowedTypes      []string `json:"allowed_types"`
+	BlockedTypes      []string `json:"blocked_types"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	BlockedExtensions []string `json:"blocked_extensions"`
+	StripLocation     bool     `json:"strip_location"`
+}
+
+// Override replaces the fields of the rules it is applied to that it sets. An empty list
+// clears the inherited list, while an omitted one keeps it.
+type Override struct {
+	MaxSize           *int64   `json:"max_size"`
+	AllowedTypes      []string `json:"allowed_types"`
+	BlockedTypes      []string `json:"blocked_types"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	BlockedExtensions []string `json:"blocked_extensions"`
+	StripLocation     *bool    `json:"strip_location"`
+}
+
+// Policy holds the default upload rules and the overrides for roles and folders.
+// Folder overrides apply to uploads into that folder or below it and win over role overrides.
+type Policy struct {
+	Default Rules
+	Roles   map[string]Override
+	Folders map[string]Override
+}
+
+// policyFile is the layout of the JSON policy file. Its default section is applied on top
+// of the defaults from the environment.
+type policyFile struct {
+	Default Override            `json:"default"`
+	Roles   map[string]Override `json:"roles"`
+	Folders map[string]Override `json:"folders"`
+}
+
+// Upload describes a file being checked against the policy.
+type Upload struct {
+	Name        string
+	Path        string
+	Role        string
+	Size        int64
+	ContentType string // Sniffed from the content, not taken from the client
+}
+
+// Violation is a single rule an upload broke.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// NewPolicy builds the upload policy from the configured defaults and, if set, the JSON
+// policy file holding role and folder overrides.
+func NewPolicy(cfg config.UploadConfig) (*Policy, error) {
+	p := &Policy{
+		Default: Rules{
+			MaxSize:           cfg.MaxSize,
+			AllowedTypes:      cfg.AllowedTypes,
+			BlockedTypes:      cfg.BlockedTypes,
+			AllowedExtensions: cfg.AllowedExtensions,
+			BlockedExtensions: cfg.BlockedExtensions,
+			StripLocation:     cfg.StripLocation,
+		},
+	}
+
+	if cfg.PolicyFile == "" {
+		return p, nil
+	}
+
+	data, err := os.ReadFile(cfg.PolicyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading upload policy file: %w", err)
+	}
+
+	var fromFile policyFile
+	if err := json.Unmarshal(data, &fromFile); err != nil {
+		return nil, fmt.Errorf("parsing upload policy file: %w", err)
+	}
+
+	p.Default = p.Default.apply(fromFile.Default)
+	p.Roles = fromFile.Roles
+	p.Folders = fromFile.Folders
+	return p, nil
+}
+
+// RulesFor resolves the rules for an upload by applying its role's override and then every
+// folder override containing its path, from the outermost folder to the innermost.
+func (p *Policy) RulesFor(role, filePath string) Rules {
+	rules := p.Default
+
+	if override, ok := p.Roles[role]; ok {
+		rules = rules.apply(override)
+	}
+
+	folders := make([]string, 0, len(p.Folders))
+	for folder := range p.Folders {
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool { return len(folders[i]) < len(folders[j]) })
+
+	cleaned := path.Clean("/" + filePath)
+	for _, folder := range folders {
+		prefix := path.Clean("/" + folder)
+		if prefix == "/" || cleaned == prefix || strings.HasPrefix(cleaned, prefix+"/") {
+			rules = rules.apply(p.Folders[folder])
+		}
+	}
+
+	return rules
+}
+
+// Evaluate returns every rule the upload breaks, or nil if it is allowed.
+func (p *Policy) Evaluate(upload Upload) []Violation {
+	rules := p.RulesFor(upload.Role, upload.Path)
+	var violations []Violation
+
+	if rules.MaxSize > 0 && upload.Size > rules.MaxSize {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxSize,
+			Message: fmt.Sprintf("File is %d bytes, the limit is %d bytes", upload.Size, rules.MaxSize),
+		})
+	}
+
+	mediaType := upload.ContentType
+	if parsed, _, err := mime.ParseMediaType(upload.ContentType); err == nil {
+		mediaType = parsed
+	}
+	if matchesType(mediaType, rules.BlockedTypes) {
+		violations = append(violations, Violation{
+			Rule:    RuleTypeBlocked,
+			Message: fmt.Sprintf("Files of type %s are not allowed", mediaType),
+		})
+	} else if len(rules.AllowedTypes) > 0 && !matchesType(mediaType, rules.AllowedTypes) {
+		violations = append(violations, Violation{
+			Rule:    RuleTypeNotAllowed,
+			Message: fmt.Sprintf("Files of type %s are not allowed, allowed types are %s", mediaType, strings.Join(rules.AllowedTypes, ", ")),
+		})
+	}
+
+	// The stored name and the target path can differ, so both must pass
+	for _, ext := range extensions(upload.Name, upload.Path) {
+		if matchesExtension(ext, rules.BlockedExtensions) {
+			violations = append(violations, Violation{
+				Rule:    RuleExtensionBlocked,
+				Message: fmt.Sprintf("Files with the %s extension are not allowed", displayExtension(ext)),
+			})
+		} else if len(rules.AllowedExtensions) > 0 && !matchesExtension(ext, rules.AllowedExtensions) {
+			violations = append(violations, Violation{
+				Rule:    RuleExtensionNotAllowed,
+				Message: fmt.Sprintf("Files with the %s extension are not allowed, allowed extensions are %s", displayExtension(ext), strings.Join(rules.AllowedExtensions, ", ")),
+			})
+		}
+	}
+
+	return violations
+}
+
+// apply returns a copy of the rules with the override's set fields replaced.
+func (r Rules) apply(o Override) Rules {
+	if o.MaxSize != nil {
+		r.MaxSize = *o.MaxSize
+	}
+	if o.AllowedTypes != nil {
+		r.AllowedTypes = o.AllowedTypes
+	}
+	if o.BlockedTypes != nil {
+		r.BlockedTypes = o.BlockedTypes
+	}
+	if o.AllowedExtensions != nil {
+		r.AllowedExtensions = o.AllowedExtensions
+	}
+	if o.BlockedExtensions != nil {
+		r.BlockedExtensions = o.BlockedExtensions
+	}
+	if o.StripLocation != nil {
+		r.StripLocation = *o.StripLocation
+	}
+	return r
+}
+
+// matchesType reports whether a media type matches one of the patterns.
+func matchesType(mediaType string, patterns []string) bool {
+	mediaType = strings.ToLower(mediaType)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || pattern == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesExtension reports whether an extension is in the list, ignoring case and the leading dot.
+func matchesExtension(ext string, list []string) bool {
+	for _, candidate := range list {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if strings.TrimPrefix(candidate, ".") == strings.TrimPrefix(ext, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// extensions returns the distinct lowercase extensions of the given names. Names without
+// an extension contribute an empty one, so allow lists can reject them.
+func extensions(names ...string) []string {
+	var exts []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(path.Base(name)))
+		if !seen[ext] {
+			seen[ext] = true
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// displayExtension names an extension in a message.
+func displayExtension(ext string) string {
+	if ext == "" {
+		return "(none)"
+	}
+	return ext
+}
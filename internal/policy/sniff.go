@@ -0,0 +1,34 @@
+package policy
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// sniffLength is the most content http.DetectContentType looks at.
+const sniffLength = 512
+
+// Content is uploaded content that can be read from the start as often as needed, such as a
+// multipart file.
+type Content interface {
+	Open() (multipart.File, error)
+}
+
+// DetectContentType determines an uploaded file's MIME type from its first bytes,
+// ignoring the Content-Type the client declared.
+func DetectContentType(file Content) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository.
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// CreateAPIKey inserts a new API key record into the database.
+func (r *APIKeyRepository) CreateAPIKey(key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, resources, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.db.Exec(query, key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash, models.JoinList(key.Scopes), models.JoinList(key.Resources), key.ExpiresAt, key.CreatedAt, key.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAPIKeyByHash retrieves an API key from the database by the hash of its plaintext value.
+func (r *APIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, key_hash, scopes, resources, expires_at, last_used_at, revoked_at, created_at, updated_at
+		FROM api_keys WHERE key_hash = $1
+	`
+	key, err := scanAPIKey(r.db.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("api key not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// ListAPIKeysByUser retrieves all API keys belonging to a user, newest first.
+func (r *APIKeyRepository) ListAPIKeysByUser(userID string) ([]*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, key_hash, scopes, resources, expires_at, last_used_at, revoked_at, created_at, updated_at
+		FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks a user's API key as revoked.
+func (r *APIKeyRepository) RevokeAPIKey(id, userID string) error {
+	query := `UPDATE api_keys SET revoked_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+// TouchAPIKey records the time an API key was last used to authenticate.
+func (r *APIKeyRepository) TouchAPIKey(id string, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, usedAt, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey reads a single api_keys row into a model.
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes, resources sql.NullString
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &resources, &expiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = models.SplitList(scopes.String)
+	key.Resources = models.SplitList(resources.String)
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+
+	return &key, nil
+}
+
+// nullTimePtr converts a nullable column value into an optional time.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
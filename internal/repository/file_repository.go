@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/souvik03-136/Go-Store/internal/models"
@@ -17,56 +19,513 @@ func NewFileRepository(db *sql.DB) *FileRepository {
 	return &FileRepository{db: db}
 }
 
-// CreateFile inserts a new file record into the database.
-func (r *FileRepository) CreateFile(file *models.File) error {
+// fileColumns lists the columns read by scanFile, in order.
+const fileColumns = `id, name, path, url, storage_key, storage_backend, state, size, content_type, owner_id, scan_status, scanned_at,
+	checksum_sha256, checksum_md5, checksum_crc32c, integrity_status, verified_at, last_accessed_at, storage_class, thumbnail_status, created_at, updated_at`
+
+// ErrQuotaExceeded is returned when creating a file would take its owner over their quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// CreateFile inserts a new file record, normally in the pending state before its content is
+// uploaded, and adds it to the owner's storage usage in the same transaction. The quota is the
+// default for the owner's role; per-user overrides stored with the usage take precedence. If the
+// file does not fit, nothing is written and ErrQuotaExceeded is returned.
+func (r *FileRepository) CreateFile(file *models.File, quota models.Quota) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO files (id, name, path, size, content_type, owner_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO files (id, name, path, url, storage_key, state, size, content_type, owner_id, scan_status,
+			checksum_sha256, checksum_md5, checksum_crc32c, integrity_status, thumbnail_status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+	_, err = tx.Exec(query, file.ID, file.Name, file.Path, file.Url, file.StorageKey, file.State, file.Size, file.ContentType, file.OwnerID, file.ScanStatus,
+		nullString(file.ChecksumSHA256), nullString(file.ChecksumMD5), int64(file.ChecksumCRC32C), file.IntegrityStatus, file.ThumbnailStatus, file.CreatedAt, file.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ensure := `INSERT INTO storage_usage (user_id, updated_at) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING`
+	if _, err := tx.Exec(ensure, file.OwnerID, now); err != nil {
+		return err
+	}
+
+	// The conditional update doubles as the quota check, so concurrent uploads cannot overshoot
+	increment := `
+		UPDATE storage_usage SET bytes_used = bytes_used + $1, file_count = file_count + 1, updated_at = $2
+		WHERE user_id = $3
+			AND (COALESCE(quota_bytes, $4) <= 0 OR bytes_used + $1 <= COALESCE(quota_bytes, $4))
+			AND (COALESCE(quota_files, $5) <= 0 OR file_count + 1 <= COALESCE(quota_files, $5))
 	`
-	_, err := r.db.Exec(query, file.ID, file.Name, file.Path, file.Size, file.ContentType, file.OwnerID, file.CreatedAt, file.UpdatedAt)
+	result, err := tx.Exec(increment, file.Size, now, file.OwnerID, quota.MaxBytes, quota.MaxFiles)
 	if err != nil {
 		return err
 	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrQuotaExceeded
+	}
+
+	return tx.Commit()
+}
+
+// ActivateFile completes the creation of a pending file once its content has been stored,
+// recording where it was stored.
+func (r *FileRepository) ActivateFile(id, url, backend string) error {
+	query := `UPDATE files SET state = $1, url = $2, storage_backend = $3, updated_at = $4 WHERE id = $5 AND state = $6`
+	result, err := r.db.Exec(query, models.FileStateActive, url, nullString(backend), time.Now(), id, models.FileStatePending)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("file not found")
+	}
 	return nil
 }
 
-// GetFileByID retrieves a file from the database by its ID.
-func (r *FileRepository) GetFileByID(id string) (*models.File, error) {
-	var file models.File
+// MarkFileDeleting hides an active file and records that its content is being removed.
+func (r *FileRepository) MarkFileDeleting(id string) error {
+	query := `UPDATE files SET state = $1, updated_at = $2 WHERE id = $3 AND state = $4`
+	result, err := r.db.Exec(query, models.FileStateDeleting, time.Now(), id, models.FileStateActive)
+	if err != nil {
+		return err
+	}
 
-	query := `
-		SELECT id, name, path, size, content_type, owner_id, created_at, updated_at
-		FROM files WHERE id = $1
-	`
-	err := r.db.QueryRow(query, id).Scan(&file.ID, &file.Name, &file.Path, &file.Size, &file.ContentType, &file.OwnerID, &file.CreatedAt, &file.UpdatedAt)
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("file not found")
+	}
+	return nil
+}
+
+// GetFileByID retrieves an active file from the database by its ID.
+func (r *FileRepository) GetFileByID(id string) (*models.File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = $1 AND state = $2`
+	file, err := scanFile(r.db.QueryRow(query, id, models.FileStateActive))
 	if err == sql.ErrNoRows {
 		return nil, errors.New("file not found")
 	} else if err != nil {
 		return nil, err
 	}
 
-	return &file, nil
+	return file, nil
+}
+
+// ListFilesByOwner retrieves every file owned by a user, in any state.
+func (r *FileRepository) ListFilesByOwner(ownerID string) ([]*models.File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE owner_id = $1 ORDER BY created_at`
+	return r.listFiles(query, ownerID)
+}
+
+// ListFilesInState retrieves up to limit files that have been in a state since before the given time.
+func (r *FileRepository) ListFilesInState(state string, before time.Time, limit int) ([]*models.File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE state = $1 AND updated_at < $2 ORDER BY updated_at LIMIT $3`
+	return r.listFiles(query, state, before, limit)
+}
+
+// ListStorageKeys returns the state of every file row, keyed by its storage key.
+func (r *FileRepository) ListStorageKeys() (map[string]string, error) {
+	rows, err := r.db.Query(`SELECT storage_key, state FROM files WHERE storage_key IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := map[string]string{}
+	for rows.Next() {
+		var key, state string
+		if err := rows.Scan(&key, &state); err != nil {
+			return nil, err
+		}
+		keys[key] = state
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// ListFilesToMigrate retrieves up to limit active files stored on the source backend, or whose
+// backend was never recorded, in creation order starting after the given file.
+func (r *FileRepository) ListFilesToMigrate(source string, afterCreatedAt time.Time, afterID string, limit int) ([]*models.File, error) {
+	query := `
+		SELECT ` + fileColumns + ` FROM files
+		WHERE state = $1 AND (storage_backend IS NULL OR storage_backend = $2) AND (created_at, id) > ($3, $4)
+		ORDER BY created_at, id LIMIT $5
+	`
+	return r.listFiles(query, models.FileStateActive, source, afterCreatedAt, afterID, limit)
+}
+
+// ListFilesForLifecycleRule retrieves up to limit active files that match a lifecycle rule and
+// have not had its action applied yet, oldest first. Files on the cold backend are left to rules
+// that expire them or change their storage class.
+func (r *FileRepository) ListFilesForLifecycleRule(rule *models.LifecycleRule, coldBackend string, now time.Time, limit int) ([]*models.File, error) {
+	args := []interface{}{models.FileStateActive}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"state = $1"}
+	if rule.OlderThanDays > 0 {
+		conditions = append(conditions, "created_at < "+arg(now.AddDate(0, 0, -rule.OlderThanDays)))
+	}
+	if rule.NotAccessedDays > 0 {
+		conditions = append(conditions, "COALESCE(last_accessed_at, created_at) < "+arg(now.AddDate(0, 0, -rule.NotAccessedDays)))
+	}
+	if rule.MinSize > 0 {
+		conditions = append(conditions, "size >= "+arg(rule.MinSize))
+	}
+	if rule.Folder != "" {
+		folder := arg(rule.Folder)
+		conditions = append(conditions, "(path = "+folder+" OR substr(path, 1, length("+folder+") + 1) = "+folder+" || '/')")
+	}
+	if rule.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM file_tags t WHERE t.file_id = files.id AND t.tag = "+arg(rule.Tag)+")")
+	}
+
+	switch rule.Action {
+	case models.LifecycleMoveToCold:
+		conditions = append(conditions, "storage_backend IS DISTINCT FROM "+arg(coldBackend))
+	case models.LifecycleSetStorageClass:
+		conditions = append(conditions, "storage_class IS DISTINCT FROM "+arg(rule.StorageClass))
+	case models.LifecycleDeleteOldVersions:
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM files n WHERE n.owner_id = files.owner_id AND n.path = files.path AND n.state = $1
+				AND (n.created_at > files.created_at OR (n.created_at = files.created_at AND n.id > files.id)))`)
+	}
+
+	query := `SELECT ` + fileColumns + ` FROM files WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY created_at LIMIT ` + arg(limit)
+	return r.listFiles(query, args...)
 }
 
-// UpdateFile updates a file's information in the database.
+// MoveFileBackend points an active file at a copy of its content on another backend, as long
+// as the file still has the backend and checksum it had when the copy was made. It reports
+// whether the file was moved. Files without a recorded checksum get the one verified by the copy.
+func (r *FileRepository) MoveFileBackend(file *models.File, backend, url, checksumSHA256 string) (bool, error) {
+	query := `
+		UPDATE files SET storage_backend = $1, url = $2, checksum_sha256 = COALESCE(checksum_sha256, $3), updated_at = $4
+		WHERE id = $5 AND state = $6
+			AND storage_backend IS NOT DISTINCT FROM $7
+			AND checksum_sha256 IS NOT DISTINCT FROM $8
+	`
+	result, err := r.db.Exec(query, backend, url, nullString(checksumSHA256), time.Now(), file.ID, models.FileStateActive,
+		nullString(file.Backend), nullString(file.ChecksumSHA256))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateFile updates a file's name and path in the database. The size and content type
+// describe the stored object and only change when it is replaced.
 func (r *FileRepository) UpdateFile(file *models.File) error {
+	query := `UPDATE files SET name = $1, path = $2, updated_at = $3 WHERE id = $4`
+	_, err := r.db.Exec(query, file.Name, file.Path, time.Now(), file.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// TouchFileAccess records that a file was read. Files read within the last interval are not
+// updated again, to avoid a write on every download.
+func (r *FileRepository) TouchFileAccess(id string, accessedAt time.Time, interval time.Duration) error {
+	query := `UPDATE files SET last_accessed_at = $1 WHERE id = $2 AND (last_accessed_at IS NULL OR last_accessed_at < $3)`
+	_, err := r.db.Exec(query, accessedAt, id, accessedAt.Add(-interval))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateStorageClass records the storage class a file's object was moved to.
+func (r *FileRepository) UpdateStorageClass(id, class string) error {
+	query := `UPDATE files SET storage_class = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, class, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListFileTags retrieves a file's tags in alphabetical order.
+func (r *FileRepository) ListFileTags(id string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT tag FROM file_tags WHERE file_id = $1 ORDER BY tag`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// SetFileTags replaces a file's tags.
+func (r *FileRepository) SetFileTags(id string, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM file_tags WHERE file_id = $1`, id); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT INTO file_tags (file_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING`, id, tag); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetFileMetadata retrieves the metadata extracted from a file's content, or nil if none was.
+func (r *FileRepository) GetFileMetadata(id string) (*models.FileMetadata, error) {
 	query := `
-		UPDATE files SET name = $1, path = $2, size = $3, content_type = $4, updated_at = $5
-		WHERE id = $6
+		SELECT file_id, width, height, camera_make, camera_model, captured_at, orientation,
+			has_location, location_stripped, page_count, duration_seconds, extracted_at
+		FROM file_metadata WHERE file_id = $1
 	`
-	_, err := r.db.Exec(query, file.Name, file.Path, file.Size, file.ContentType, time.Now(), file.ID)
+	var meta models.FileMetadata
+	var width, height, orientation, pageCount sql.NullInt64
+	var cameraMake, cameraModel sql.NullString
+	var capturedAt sql.NullTime
+	var duration sql.NullFloat64
+
+	err := r.db.QueryRow(query, id).Scan(&meta.FileID, &width, &height, &cameraMake, &cameraModel, &capturedAt, &orientation,
+		&meta.HasLocation, &meta.LocationStripped, &pageCount, &duration, &meta.ExtractedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Width = int(width.Int64)
+	meta.Height = int(height.Int64)
+	meta.CameraMake = cameraMake.String
+	meta.CameraModel = cameraModel.String
+	meta.CapturedAt = nullTimePtr(capturedAt)
+	meta.Orientation = int(orientation.Int64)
+	meta.PageCount = int(pageCount.Int64)
+	meta.DurationSeconds = duration.Float64
+	return &meta, nil
+}
+
+// SetFileMetadata stores the metadata extracted from a file's content, replacing any stored before.
+func (r *FileRepository) SetFileMetadata(meta *models.FileMetadata) error {
+	query := `
+		INSERT INTO file_metadata (file_id, width, height, camera_make, camera_model, captured_at, orientation,
+			has_location, location_stripped, page_count, duration_seconds, extracted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (file_id) DO UPDATE SET
+			width = EXCLUDED.width, height = EXCLUDED.height, camera_make = EXCLUDED.camera_make,
+			camera_model = EXCLUDED.camera_model, captured_at = EXCLUDED.captured_at, orientation = EXCLUDED.orientation,
+			has_location = EXCLUDED.has_location, location_stripped = EXCLUDED.location_stripped,
+			page_count = EXCLUDED.page_count, duration_seconds = EXCLUDED.duration_seconds, extracted_at = EXCLUDED.extracted_at
+	`
+	duration := sql.NullFloat64{Float64: meta.DurationSeconds, Valid: meta.DurationSeconds > 0}
+	_, err := r.db.Exec(query, meta.FileID, nullInt(int64(meta.Width)), nullInt(int64(meta.Height)), nullString(meta.CameraMake), nullString(meta.CameraModel),
+		meta.CapturedAt, nullInt(int64(meta.Orientation)), meta.HasLocation, meta.LocationStripped, nullInt(int64(meta.PageCount)), duration, meta.ExtractedAt)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// DeleteFile removes a file from the database by its ID.
-func (r *FileRepository) DeleteFile(id string) error {
-	query := `DELETE FROM files WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+// UpdateScanStatus records the outcome of scanning a file for malware.
+func (r *FileRepository) UpdateScanStatus(id, status string, scannedAt time.Time) error {
+	query := `UPDATE files SET scan_status = $1, scanned_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, status, scannedAt, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// ListFilesPendingScan retrieves up to limit active files that have not been scanned yet, those
+// never tried first and then the least recently tried.
+func (r *FileRepository) ListFilesPendingScan(limit int) ([]*models.File, error) {
+	query := `
+		SELECT ` + fileColumns + ` FROM files
+		WHERE scan_status = $1 AND state = $2
+		ORDER BY scanned_at NULLS FIRST, created_at LIMIT $3
+	`
+	return r.listFiles(query, models.ScanStatusPending, models.FileStateActive, limit)
+}
+
+// ListFilesNeedingThumbnails retrieves up to limit clean, active files waiting for thumbnails,
+// oldest first.
+func (r *FileRepository) ListFilesNeedingThumbnails(limit int) ([]*models.File, error) {
+	query := `
+		SELECT ` + fileColumns + ` FROM files
+		WHERE thumbnail_status = $1 AND state = $2 AND scan_status = $3
+		ORDER BY created_at LIMIT $4
+	`
+	return r.listFiles(query, models.ThumbnailPending, models.FileStateActive, models.ScanStatusClean, limit)
+}
+
+// UpdateThumbnailStatus records the progress of generating a file's thumbnails.
+func (r *FileRepository) UpdateThumbnailStatus(id, status string) error {
+	query := `UPDATE files SET thumbnail_status = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, status, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListFilesToVerify retrieves up to limit active files that are not known to be corrupted,
+// least recently verified first.
+func (r *FileRepository) ListFilesToVerify(limit int) ([]*models.File, error) {
+	query := `
+		SELECT ` + fileColumns + ` FROM files
+		WHERE state = $1 AND integrity_status <> $2
+		ORDER BY verified_at NULLS FIRST LIMIT $3
+	`
+	return r.listFiles(query, models.FileStateActive, models.IntegrityCorrupted, limit)
+}
+
+// ListCorruptedFiles retrieves every active file the scrubber has flagged as corrupted.
+func (r *FileRepository) ListCorruptedFiles() ([]*models.File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE state = $1 AND integrity_status = $2 ORDER BY verified_at`
+	return r.listFiles(query, models.FileStateActive, models.IntegrityCorrupted)
+}
+
+// UpdateIntegrity records the outcome of verifying a file's stored content. An empty checksum
+// leaves the recorded one unchanged; files stored before checksums were recorded get theirs
+// from their first verification.
+func (r *FileRepository) UpdateIntegrity(id, status, checksumSHA256 string, verifiedAt time.Time) error {
+	query := `
+		UPDATE files SET integrity_status = $1, checksum_sha256 = COALESCE(checksum_sha256, $2), verified_at = $3
+		WHERE id = $4
+	`
+	_, err := r.db.Exec(query, status, nullString(checksumSHA256), verifiedAt, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteFile removes a file from the database by its ID and subtracts it from the owner's
+// storage usage in the same transaction.
+func (r *FileRepository) DeleteFile(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var ownerID string
+	var size int64
+	query := `DELETE FROM files WHERE id = $1 RETURNING owner_id, size`
+	err = tx.QueryRow(query, id).Scan(&ownerID, &size)
+	if err == sql.ErrNoRows {
+		return errors.New("file not found")
+	} else if err != nil {
+		return err
+	}
+
+	decrement := `
+		UPDATE storage_usage SET bytes_used = GREATEST(bytes_used - $1, 0), file_count = GREATEST(file_count - 1, 0), updated_at = $2
+		WHERE user_id = $3
+	`
+	if _, err := tx.Exec(decrement, size, time.Now(), ownerID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// listFiles runs a query returning files rows selected with fileColumns.
+func (r *FileRepository) listFiles(query string, args ...interface{}) ([]*models.File, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []*models.File{}
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// nullString stores an empty string as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// scanFile reads a single files row selected with fileColumns into a model.
+func scanFile(row rowScanner) (*models.File, error) {
+	var file models.File
+	var url, storageKey, backend, contentType, sha, md5, storageClass sql.NullString
+	var crc sql.NullInt64
+	var scannedAt, verifiedAt, lastAccessedAt sql.NullTime
+
+	err := row.Scan(&file.ID, &file.Name, &file.Path, &url, &storageKey, &backend, &file.State, &file.Size, &contentType, &file.OwnerID, &file.ScanStatus, &scannedAt,
+		&sha, &md5, &crc, &file.IntegrityStatus, &verifiedAt, &lastAccessedAt, &storageClass, &file.ThumbnailStatus, &file.CreatedAt, &file.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Url = url.String
+	file.StorageKey = storageKey.String
+	file.Backend = backend.String
+	file.ContentType = contentType.String
+	file.ScannedAt = nullTimePtr(scannedAt)
+	file.ChecksumSHA256 = sha.String
+	file.ChecksumMD5 = md5.String
+	file.ChecksumCRC32C = uint32(crc.Int64)
+	file.VerifiedAt = nullTimePtr(verifiedAt)
+	file.LastAccessedAt = nullTimePtr(lastAccessedAt)
+	file.StorageClass = storageClass.String
+	return &file, nil
+}
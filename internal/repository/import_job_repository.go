@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type ImportJobRepository struct {
+	db *sql.DB
+}
+
+// NewImportJobRepository creates a new instance of ImportJobRepository.
+func NewImportJobRepository(db *sql.DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+// importJobColumns lists the columns read by scanImportJob, in order.
+const importJobColumns = `id, owner_id, url, path, status, bytes_downloaded, total_bytes, file_id, error, created_at, updated_at, completed_at`
+
+// CreateImportJob inserts a new import job into the database.
+func (r *ImportJobRepository) CreateImportJob(job *models.ImportJob) error {
+	query := `
+		INSERT INTO import_jobs (id, owner_id, url, path, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(query, job.ID, job.OwnerID, job.URL, job.Path, job.Status, job.CreatedAt, job.UpdatedAt)
+	return err
+}
+
+// GetImportJob retrieves an import job by its ID.
+func (r *ImportJobRepository) GetImportJob(id string) (*models.ImportJob, error) {
+	job, err := scanImportJob(r.db.QueryRow(`SELECT `+importJobColumns+` FROM import_jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("import job not found")
+	} else if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListImportJobsByOwner retrieves a user's most recent import jobs, newest first.
+func (r *ImportJobRepository) ListImportJobsByOwner(ownerID string, limit int) ([]*models.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE owner_id = $1 ORDER BY created_at DESC, id LIMIT $2`
+	return r.queryImportJobs(query, ownerID, limit)
+}
+
+// CountActiveImportJobs counts a user's import jobs that have not finished yet.
+func (r *ImportJobRepository) CountActiveImportJobs(ownerID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM import_jobs WHERE owner_id = $1 AND status IN ($2, $3)`
+	err := r.db.QueryRow(query, ownerID, models.ImportPending, models.ImportRunning).Scan(&count)
+	return count, err
+}
+
+// ListImportJobsToRun retrieves the oldest pending import jobs, along with running jobs that have
+// not made progress since staleBefore, whose importer is presumed to have stopped.
+func (r *ImportJobRepository) ListImportJobsToRun(staleBefore time.Time, limit int) ([]*models.ImportJob, error) {
+	query := `
+		SELECT ` + importJobColumns + ` FROM import_jobs
+		WHERE status = $1 OR (status = $2 AND updated_at < $3)
+		ORDER BY created_at, id
+		LIMIT $4
+	`
+	return r.queryImportJobs(query, models.ImportPending, models.ImportRunning, staleBefore, limit)
+}
+
+// ClaimImportJob marks a job returned by ListImportJobsToRun as running, unless another importer
+// claimed it first. It reports whether this caller now owns the job.
+func (r *ImportJobRepository) ClaimImportJob(id string, staleBefore time.Time) (bool, error) {
+	query := `
+		UPDATE import_jobs SET status = $1, bytes_downloaded = 0, total_bytes = NULL, updated_at = $2
+		WHERE id = $3 AND (status = $4 OR (status = $1 AND updated_at < $5))
+	`
+	result, err := r.db.Exec(query, models.ImportRunning, time.Now(), id, models.ImportPending, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateImportProgress records how much of a running job has been downloaded. A total of zero
+// means the size is unknown.
+func (r *ImportJobRepository) UpdateImportProgress(id string, downloaded, total int64) error {
+	query := `UPDATE import_jobs SET bytes_downloaded = $1, total_bytes = $2, updated_at = $3 WHERE id = $4 AND status = $5`
+	_, err := r.db.Exec(query, downloaded, nullInt(total), time.Now(), id, models.ImportRunning)
+	return err
+}
+
+// FinishImportJob records the final status, progress, file and error of a job.
+func (r *ImportJobRepository) FinishImportJob(job *models.ImportJob) error {
+	query := `
+		UPDATE import_jobs
+		SET status = $1, bytes_downloaded = $2, total_bytes = $3, file_id = $4, error = $5, updated_at = $6, completed_at = $7
+		WHERE id = $8
+	`
+	result, err := r.db.Exec(query, job.Status, job.BytesDownloaded, nullInt(job.TotalBytes), nullString(job.FileID), nullString(job.Error),
+		job.UpdatedAt, job.CompletedAt, job.ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("import job not found")
+	}
+	return nil
+}
+
+// queryImportJobs runs a query selecting importJobColumns and reads every row.
+func (r *ImportJobRepository) queryImportJobs(query string, args ...interface{}) ([]*models.ImportJob, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*models.ImportJob{}
+	for rows.Next() {
+		job, err := scanImportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// scanImportJob reads a single import_jobs row selected with importJobColumns into a model.
+func scanImportJob(row rowScanner) (*models.ImportJob, error) {
+	var job models.ImportJob
+	var totalBytes sql.NullInt64
+	var fileID, jobError sql.NullString
+	var completedAt sql.NullTime
+
+	err := row.Scan(&job.ID, &job.OwnerID, &job.URL, &job.Path, &job.Status, &job.BytesDownloaded, &totalBytes, &fileID, &jobError,
+		&job.CreatedAt, &job.UpdatedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	job.TotalBytes = totalBytes.Int64
+	job.FileID = fileID.String
+	job.Error = jobError.String
+	job.CompletedAt = nullTimePtr(completedAt)
+	return &job, nil
+}
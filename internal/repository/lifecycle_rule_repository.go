@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type LifecycleRuleRepository struct {
+	db *sql.DB
+}
+
+// NewLifecycleRuleRepository creates a new instance of LifecycleRuleRepository.
+func NewLifecycleRuleRepository(db *sql.DB) *LifecycleRuleRepository {
+	return &LifecycleRuleRepository{db: db}
+}
+
+// lifecycleRuleColumns lists the columns read by scanLifecycleRule, in order.
+const lifecycleRuleColumns = `id, name, action, storage_class, older_than_days, not_accessed_days, min_size, folder, tag, dry_run, created_by, created_at`
+
+// CreateLifecycleRule inserts a new lifecycle rule into the database.
+func (r *LifecycleRuleRepository) CreateLifecycleRule(rule *models.LifecycleRule) error {
+	query := `
+		INSERT INTO lifecycle_rules (id, name, action, storage_class, older_than_days, not_accessed_days, min_size, folder, tag, dry_run, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := r.db.Exec(query, rule.ID, rule.Name, rule.Action, nullString(rule.StorageClass), nullInt(int64(rule.OlderThanDays)), nullInt(int64(rule.NotAccessedDays)),
+		nullInt(rule.MinSize), nullString(rule.Folder), nullString(rule.Tag), rule.DryRun, rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListLifecycleRules retrieves every lifecycle rule in the order they were created.
+func (r *LifecycleRuleRepository) ListLifecycleRules() ([]*models.LifecycleRule, error) {
+	rows, err := r.db.Query(`SELECT ` + lifecycleRuleColumns + ` FROM lifecycle_rules ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*models.LifecycleRule{}
+	for rows.Next() {
+		rule, err := scanLifecycleRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// DeleteLifecycleRule removes a lifecycle rule from the database by its ID.
+func (r *LifecycleRuleRepository) DeleteLifecycleRule(id string) error {
+	result, err := r.db.Exec(`DELETE FROM lifecycle_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("lifecycle rule not found")
+	}
+	return nil
+}
+
+// nullInt stores zero as NULL.
+func nullInt(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: n != 0}
+}
+
+// scanLifecycleRule reads a single lifecycle_rules row selected with lifecycleRuleColumns into a model.
+func scanLifecycleRule(row rowScanner) (*models.LifecycleRule, error) {
+	var rule models.LifecycleRule
+	var storageClass, folder, tag sql.NullString
+	var olderThan, notAccessed, minSize sql.NullInt64
+
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Action, &storageClass, &olderThan, &notAccessed, &minSize, &folder, &tag,
+		&rule.DryRun, &rule.CreatedBy, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.StorageClass = storageClass.String
+	rule.OlderThanDays = int(olderThan.Int64)
+	rule.NotAccessedDays = int(notAccessed.Int64)
+	rule.MinSize = minSize.Int64
+	rule.Folder = folder.String
+	rule.Tag = tag.String
+	return &rule, nil
+}
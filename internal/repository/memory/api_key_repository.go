@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// APIKeyRepository is the in-memory counterpart of repository.APIKeyRepository.
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository.
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// CreateAPIKey inserts a new API key, enforcing unique IDs and key hashes.
+func (r *APIKeyRepository) CreateAPIKey(key *models.APIKey) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.apiKeys[key.ID]; ok {
+		return errors.New("api key already exists")
+	}
+	for _, other := range r.db.apiKeys {
+		if other.KeyHash == key.KeyHash {
+			return errors.New("api key already exists")
+		}
+	}
+	if _, ok := r.db.users[key.UserID]; !ok {
+		return errors.New("user not found")
+	}
+
+	r.db.apiKeys[key.ID] = copyAPIKey(*key)
+	return nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of its plaintext value.
+func (r *APIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, key := range r.db.apiKeys {
+		if key.KeyHash == keyHash {
+			key = copyAPIKey(key)
+			return &key, nil
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+// ListAPIKeysByUser retrieves all API keys belonging to a user, newest first.
+func (r *APIKeyRepository) ListAPIKeysByUser(userID string) ([]*models.APIKey, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	keys := []*models.APIKey{}
+	for _, key := range r.db.apiKeys {
+		if key.UserID == userID {
+			key := copyAPIKey(key)
+			keys = append(keys, &key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// RevokeAPIKey marks a user's API key as revoked.
+func (r *APIKeyRepository) RevokeAPIKey(id, userID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	key, ok := r.db.apiKeys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return errors.New("api key not found")
+	}
+
+	now := time.Now()
+	key.RevokedAt = timePtr(now)
+	key.UpdatedAt = now
+	r.db.apiKeys[id] = key
+	return nil
+}
+
+// TouchAPIKey records the time an API key was last used to authenticate.
+func (r *APIKeyRepository) TouchAPIKey(id string, usedAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if key, ok := r.db.apiKeys[id]; ok {
+		key.LastUsedAt = timePtr(usedAt)
+		r.db.apiKeys[id] = key
+	}
+	return nil
+}
+
+// copyAPIKey copies a key along with its scope and resource lists.
+func copyAPIKey(key models.APIKey) models.APIKey {
+	key.Scopes = copyStrings(key.Scopes)
+	key.Resources = copyStrings(key.Resources)
+	return key
+}
@@ -0,0 +1,125 @@
+// Package memory implements the repository stores in memory, so the API can be run end to end
+// without a database. The repositories mirror the behaviour of their SQL counterparts, including
+// the unique and foreign key constraints and cascading deletes of the schema.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// DB holds the tables shared by the in-memory repositories. A single lock serializes every
+// operation, which makes each one atomic like a transaction.
+type DB struct {
+	mu sync.Mutex
+
+	users          map[string]models.User
+	files          map[string]models.File
+	fileTags       map[string]map[string]bool // File ID to its tags
+	fileMetadata   map[string]models.FileMetadata
+	permissions    map[string]models.Permission
+	apiKeys        map[string]models.APIKey
+	mfa            map[string]models.MFASettings
+	recoveryCodes  map[string]map[string]bool // User ID to code hash to whether it was used
+	userTokens     map[string]models.UserToken
+	identities     map[string]models.OAuthIdentity
+	sessions       map[string]models.Session
+	usage          map[string]models.StorageUsage
+	replicaTasks   map[replicaTaskKey]models.ReplicaTask
+	lifecycleRules map[string]models.LifecycleRule
+	importJobs     map[string]models.ImportJob
+}
+
+// NewDB creates a new, empty DB.
+func NewDB() *DB {
+	return &DB{
+		users:          map[string]models.User{},
+		files:          map[string]models.File{},
+		fileTags:       map[string]map[string]bool{},
+		fileMetadata:   map[string]models.FileMetadata{},
+		permissions:    map[string]models.Permission{},
+		apiKeys:        map[string]models.APIKey{},
+		mfa:            map[string]models.MFASettings{},
+		recoveryCodes:  map[string]map[string]bool{},
+		userTokens:     map[string]models.UserToken{},
+		identities:     map[string]models.OAuthIdentity{},
+		sessions:       map[string]models.Session{},
+		usage:          map[string]models.StorageUsage{},
+		replicaTasks:   map[replicaTaskKey]models.ReplicaTask{},
+		lifecycleRules: map[string]models.LifecycleRule{},
+		importJobs:     map[string]models.ImportJob{},
+	}
+}
+
+// deleteUserLocked removes a user and every row that references them, like the schema's
+// ON DELETE CASCADE foreign keys. The caller must hold the lock.
+func (db *DB) deleteUserLocked(id string) {
+	delete(db.users, id)
+	for fileID, file := range db.files {
+		if file.OwnerID == id {
+			db.deleteFileLocked(fileID)
+		}
+	}
+	for permissionID, permission := range db.permissions {
+		if permission.UserID == id {
+			delete(db.permissions, permissionID)
+		}
+	}
+	for keyID, key := range db.apiKeys {
+		if key.UserID == id {
+			delete(db.apiKeys, keyID)
+		}
+	}
+	for tokenID, token := range db.userTokens {
+		if token.UserID == id {
+			delete(db.userTokens, tokenID)
+		}
+	}
+	for identityID, identity := range db.identities {
+		if identity.UserID == id {
+			delete(db.identities, identityID)
+		}
+	}
+	for jobID, job := range db.importJobs {
+		if job.OwnerID == id {
+			delete(db.importJobs, jobID)
+		}
+	}
+	delete(db.mfa, id)
+	delete(db.recoveryCodes, id)
+	delete(db.usage, id)
+}
+
+// deleteFileLocked removes a file with its tags, metadata and permissions, and forgets it in the
+// import jobs that created it. The caller must hold the lock.
+func (db *DB) deleteFileLocked(id string) {
+	delete(db.files, id)
+	delete(db.fileTags, id)
+	delete(db.fileMetadata, id)
+	for permissionID, permission := range db.permissions {
+		if permission.FileID == id {
+			delete(db.permissions, permissionID)
+		}
+	}
+	for jobID, job := range db.importJobs {
+		if job.FileID == id {
+			job.FileID = ""
+			db.importJobs[jobID] = job
+		}
+	}
+}
+
+// timePtr returns a pointer to a copy of t.
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// copyStrings copies a slice, so callers cannot change a stored row through it.
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
@@ -0,0 +1,466 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// FileRepository is the in-memory counterpart of repository.FileRepository. It keeps each
+// owner's storage usage up to date as files are created and deleted.
+type FileRepository struct {
+	db *DB
+}
+
+// NewFileRepository creates a new instance of FileRepository.
+func NewFileRepository(db *DB) *FileRepository {
+	return &FileRepository{db: db}
+}
+
+// CreateFile inserts a new file and adds it to the owner's storage usage. The quota is the
+// default for the owner's role; per-user overrides take precedence. If the file does not fit,
+// nothing is written and repository.ErrQuotaExceeded is returned.
+func (r *FileRepository) CreateFile(file *models.File, quota models.Quota) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.files[file.ID]; ok {
+		return errors.New("file already exists")
+	}
+	if _, ok := r.db.users[file.OwnerID]; !ok {
+		return errors.New("file owner not found")
+	}
+
+	usage, ok := r.db.usage[file.OwnerID]
+	if !ok {
+		usage = models.StorageUsage{UserID: file.OwnerID}
+	}
+	maxBytes, maxFiles := quota.MaxBytes, quota.MaxFiles
+	if usage.QuotaBytes != nil {
+		maxBytes = *usage.QuotaBytes
+	}
+	if usage.QuotaFiles != nil {
+		maxFiles = *usage.QuotaFiles
+	}
+	if (maxBytes > 0 && usage.BytesUsed+file.Size > maxBytes) || (maxFiles > 0 && usage.FileCount+1 > maxFiles) {
+		return repository.ErrQuotaExceeded
+	}
+
+	usage.BytesUsed += file.Size
+	usage.FileCount++
+	usage.UpdatedAt = time.Now()
+	r.db.usage[file.OwnerID] = usage
+
+	stored := *file
+	stored.Tags = nil
+	r.db.files[file.ID] = stored
+	return nil
+}
+
+// ActivateFile completes the creation of a pending file once its content has been stored,
+// recording where it was stored.
+func (r *FileRepository) ActivateFile(id, url, backend string) error {
+	updated := r.updateFile(id, models.FileStatePending, func(file *models.File) {
+		file.State = models.FileStateActive
+		file.Url = url
+		file.Backend = backend
+		file.UpdatedAt = time.Now()
+	})
+	if !updated {
+		return errors.New("file not found")
+	}
+	return nil
+}
+
+// MarkFileDeleting hides an active file and records that its content is being removed.
+func (r *FileRepository) MarkFileDeleting(id string) error {
+	updated := r.updateFile(id, models.FileStateActive, func(file *models.File) {
+		file.State = models.FileStateDeleting
+		file.UpdatedAt = time.Now()
+	})
+	if !updated {
+		return errors.New("file not found")
+	}
+	return nil
+}
+
+// GetFileByID retrieves an active file by its ID.
+func (r *FileRepository) GetFileByID(id string) (*models.File, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	file, ok := r.db.files[id]
+	if !ok || file.State != models.FileStateActive {
+		return nil, errors.New("file not found")
+	}
+	return &file, nil
+}
+
+// ListFilesByOwner retrieves every file owned by a user, in any state.
+func (r *FileRepository) ListFilesByOwner(ownerID string) ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool { return file.OwnerID == ownerID })
+	sortFiles(files, func(file models.File) time.Time { return file.CreatedAt })
+	return limitFiles(files, -1), nil
+}
+
+// ListFilesInState retrieves up to limit files that have been in a state since before the given time.
+func (r *FileRepository) ListFilesInState(state string, before time.Time, limit int) ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool { return file.State == state && file.UpdatedAt.Before(before) })
+	sortFiles(files, func(file models.File) time.Time { return file.UpdatedAt })
+	return limitFiles(files, limit), nil
+}
+
+// ListStorageKeys returns the state of every file, keyed by its storage key.
+func (r *FileRepository) ListStorageKeys() (map[string]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	keys := map[string]string{}
+	for _, file := range r.db.files {
+		if file.StorageKey != "" {
+			keys[file.StorageKey] = file.State
+		}
+	}
+	return keys, nil
+}
+
+// ListFilesToMigrate retrieves up to limit active files stored on the source backend, or whose
+// backend was never recorded, in creation order starting after the given file.
+func (r *FileRepository) ListFilesToMigrate(source string, afterCreatedAt time.Time, afterID string, limit int) ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool {
+		if file.State != models.FileStateActive || (file.Backend != "" && file.Backend != source) {
+			return false
+		}
+		return file.CreatedAt.After(afterCreatedAt) || (file.CreatedAt.Equal(afterCreatedAt) && file.ID > afterID)
+	})
+	sortFiles(files, func(file models.File) time.Time { return file.CreatedAt })
+	return limitFiles(files, limit), nil
+}
+
+// ListFilesForLifecycleRule retrieves up to limit active files that match a lifecycle rule and
+// have not had its action applied yet, oldest first. Files on the cold backend are left to rules
+// that expire them or change their storage class.
+func (r *FileRepository) ListFilesForLifecycleRule(rule *models.LifecycleRule, coldBackend string, now time.Time, limit int) ([]*models.File, error) {
+	r.db.mu.Lock()
+	tags := map[string]bool{}
+	for id, fileTags := range r.db.fileTags {
+		tags[id] = fileTags[rule.Tag]
+	}
+	latest := map[[2]string]models.File{} // Newest active file at each owner and path
+	for _, file := range r.db.files {
+		key := [2]string{file.OwnerID, file.Path}
+		newest, ok := latest[key]
+		if file.State == models.FileStateActive && (!ok || file.CreatedAt.After(newest.CreatedAt) ||
+			(file.CreatedAt.Equal(newest.CreatedAt) && file.ID > newest.ID)) {
+			latest[key] = file
+		}
+	}
+	r.db.mu.Unlock()
+
+	files := r.selectFiles(func(file models.File) bool {
+		if file.State != models.FileStateActive {
+			return false
+		}
+		if rule.OlderThanDays > 0 && !file.CreatedAt.Before(now.AddDate(0, 0, -rule.OlderThanDays)) {
+			return false
+		}
+		lastAccess := file.CreatedAt
+		if file.LastAccessedAt != nil {
+			lastAccess = *file.LastAccessedAt
+		}
+		if rule.NotAccessedDays > 0 && !lastAccess.Before(now.AddDate(0, 0, -rule.NotAccessedDays)) {
+			return false
+		}
+		if rule.MinSize > 0 && file.Size < rule.MinSize {
+			return false
+		}
+		if rule.Folder != "" && file.Path != rule.Folder && !strings.HasPrefix(file.Path, rule.Folder+"/") {
+			return false
+		}
+		if rule.Tag != "" && !tags[file.ID] {
+			return false
+		}
+
+		switch rule.Action {
+		case models.LifecycleMoveToCold:
+			return file.Backend != coldBackend
+		case models.LifecycleSetStorageClass:
+			return file.StorageClass != rule.StorageClass
+		case models.LifecycleDeleteOldVersions:
+			return latest[[2]string{file.OwnerID, file.Path}].ID != file.ID
+		}
+		return true
+	})
+	sortFiles(files, func(file models.File) time.Time { return file.CreatedAt })
+	return limitFiles(files, limit), nil
+}
+
+// MoveFileBackend points an active file at a copy of its content on another backend, as long
+// as the file still has the backend and checksum it had when the copy was made. It reports
+// whether the file was moved. Files without a recorded checksum get the one verified by the copy.
+func (r *FileRepository) MoveFileBackend(file *models.File, backend, url, checksumSHA256 string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.files[file.ID]
+	if !ok || stored.State != models.FileStateActive || stored.Backend != file.Backend || stored.ChecksumSHA256 != file.ChecksumSHA256 {
+		return false, nil
+	}
+
+	stored.Backend = backend
+	stored.Url = url
+	if stored.ChecksumSHA256 == "" {
+		stored.ChecksumSHA256 = checksumSHA256
+	}
+	stored.UpdatedAt = time.Now()
+	r.db.files[file.ID] = stored
+	return true, nil
+}
+
+// UpdateFile updates a file's name and path.
+func (r *FileRepository) UpdateFile(file *models.File) error {
+	r.updateFile(file.ID, "", func(stored *models.File) {
+		stored.Name = file.Name
+		stored.Path = file.Path
+		stored.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+// TouchFileAccess records that a file was read, unless it was already read within the last interval.
+func (r *FileRepository) TouchFileAccess(id string, accessedAt time.Time, interval time.Duration) error {
+	r.updateFile(id, "", func(file *models.File) {
+		if file.LastAccessedAt == nil || file.LastAccessedAt.Before(accessedAt.Add(-interval)) {
+			file.LastAccessedAt = timePtr(accessedAt)
+		}
+	})
+	return nil
+}
+
+// UpdateStorageClass records the storage class a file's object was moved to.
+func (r *FileRepository) UpdateStorageClass(id, class string) error {
+	r.updateFile(id, "", func(file *models.File) {
+		file.StorageClass = class
+		file.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+// ListFileTags retrieves a file's tags in alphabetical order.
+func (r *FileRepository) ListFileTags(id string) ([]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	tags := []string{}
+	for tag := range r.db.fileTags[id] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// SetFileTags replaces a file's tags.
+func (r *FileRepository) SetFileTags(id string, tags []string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.files[id]; !ok {
+		return errors.New("file not found")
+	}
+
+	set := map[string]bool{}
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	r.db.fileTags[id] = set
+	return nil
+}
+
+// GetFileMetadata retrieves the metadata extracted from a file's content, or nil if none was.
+func (r *FileRepository) GetFileMetadata(id string) (*models.FileMetadata, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	meta, ok := r.db.fileMetadata[id]
+	if !ok {
+		return nil, nil
+	}
+	return &meta, nil
+}
+
+// SetFileMetadata stores the metadata extracted from a file's content, replacing any stored before.
+func (r *FileRepository) SetFileMetadata(meta *models.FileMetadata) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.files[meta.FileID]; !ok {
+		return errors.New("file not found")
+	}
+	r.db.fileMetadata[meta.FileID] = *meta
+	return nil
+}
+
+// UpdateScanStatus records the outcome of scanning a file for malware.
+func (r *FileRepository) UpdateScanStatus(id, status string, scannedAt time.Time) error {
+	r.updateFile(id, "", func(file *models.File) {
+		file.ScanStatus = status
+		file.ScannedAt = timePtr(scannedAt)
+	})
+	return nil
+}
+
+// ListFilesPendingScan retrieves up to limit active files that have not been scanned yet, those
+// never tried first and then the least recently tried.
+func (r *FileRepository) ListFilesPendingScan(limit int) ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool {
+		return file.ScanStatus == models.ScanStatusPending && file.State == models.FileStateActive
+	})
+	sortFiles(files, func(file models.File) time.Time { return file.CreatedAt })
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].ScannedAt == nil || files[j].ScannedAt == nil {
+			return files[i].ScannedAt == nil && files[j].ScannedAt != nil
+		}
+		return files[i].ScannedAt.Before(*files[j].ScannedAt)
+	})
+	return limitFiles(files, limit), nil
+}
+
+// ListFilesNeedingThumbnails retrieves up to limit clean, active files waiting for thumbnails,
+// oldest first.
+func (r *FileRepository) ListFilesNeedingThumbnails(limit int) ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool {
+		return file.ThumbnailStatus == models.ThumbnailPending && file.State == models.FileStateActive && file.ScanStatus == models.ScanStatusClean
+	})
+	sortFiles(files, func(file models.File) time.Time { return file.CreatedAt })
+	return limitFiles(files, limit), nil
+}
+
+// UpdateThumbnailStatus records the progress of generating a file's thumbnails.
+func (r *FileRepository) UpdateThumbnailStatus(id, status string) error {
+	r.updateFile(id, "", func(file *models.File) {
+		file.ThumbnailStatus = status
+	})
+	return nil
+}
+
+// ListFilesToVerify retrieves up to limit active files that are not known to be corrupted,
+// least recently verified first.
+func (r *FileRepository) ListFilesToVerify(limit int) ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool {
+		return file.State == models.FileStateActive && file.IntegrityStatus != models.IntegrityCorrupted
+	})
+	sortFiles(files, verifiedAt)
+	return limitFiles(files, limit), nil
+}
+
+// ListCorruptedFiles retrieves every active file the scrubber has flagged as corrupted.
+func (r *FileRepository) ListCorruptedFiles() ([]*models.File, error) {
+	files := r.selectFiles(func(file models.File) bool {
+		return file.State == models.FileStateActive && file.IntegrityStatus == models.IntegrityCorrupted
+	})
+	sortFiles(files, verifiedAt)
+	return limitFiles(files, -1), nil
+}
+
+// UpdateIntegrity records the outcome of verifying a file's stored content. An empty checksum
+// leaves the recorded one unchanged.
+func (r *FileRepository) UpdateIntegrity(id, status, checksumSHA256 string, verifiedAt time.Time) error {
+	r.updateFile(id, "", func(file *models.File) {
+		file.IntegrityStatus = status
+		if file.ChecksumSHA256 == "" {
+			file.ChecksumSHA256 = checksumSHA256
+		}
+		file.VerifiedAt = timePtr(verifiedAt)
+	})
+	return nil
+}
+
+// DeleteFile removes a file by its ID and subtracts it from the owner's storage usage.
+func (r *FileRepository) DeleteFile(id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	file, ok := r.db.files[id]
+	if !ok {
+		return errors.New("file not found")
+	}
+	r.db.deleteFileLocked(id)
+
+	if usage, ok := r.db.usage[file.OwnerID]; ok {
+		usage.BytesUsed -= file.Size
+		if usage.BytesUsed < 0 {
+			usage.BytesUsed = 0
+		}
+		if usage.FileCount > 0 {
+			usage.FileCount--
+		}
+		usage.UpdatedAt = time.Now()
+		r.db.usage[file.OwnerID] = usage
+	}
+	return nil
+}
+
+// updateFile applies a change to a file if it is in the required state, where an empty state
+// matches any state, and reports whether it did. Like an UPDATE matching no rows, changing a
+// file that does not exist is not an error for most callers.
+func (r *FileRepository) updateFile(id, state string, update func(*models.File)) bool {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	file, ok := r.db.files[id]
+	if !ok || (state != "" && file.State != state) {
+		return false
+	}
+	update(&file)
+	r.db.files[id] = file
+	return true
+}
+
+// selectFiles returns copies of the files matching a condition.
+func (r *FileRepository) selectFiles(match func(models.File) bool) []models.File {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var files []models.File
+	for _, file := range r.db.files {
+		if match(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// verifiedAt orders files never verified before the others.
+func verifiedAt(file models.File) time.Time {
+	if file.VerifiedAt == nil {
+		return time.Time{}
+	}
+	return *file.VerifiedAt
+}
+
+// sortFiles orders files by a timestamp, breaking ties by ID.
+func sortFiles(files []models.File, at func(models.File) time.Time) {
+	sort.Slice(files, func(i, j int) bool {
+		ti, tj := at(files[i]), at(files[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i].ID < files[j].ID
+	})
+}
+
+// limitFiles returns pointers to up to limit files, or to all of them for a negative limit.
+func limitFiles(files []models.File, limit int) []*models.File {
+	result := []*models.File{}
+	for i := range files {
+		if limit >= 0 && i >= limit {
+			break
+		}
+		result = append(result, &files[i])
+	}
+	return result
+}
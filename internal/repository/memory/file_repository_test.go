@@ -0,0 +1,186 @@
+package memory_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/repository/memory"
+)
+
+// newOwner creates a database with one user to own files.
+func newOwner(t *testing.T) (*memory.DB, *models.User) {
+	t.Helper()
+	db := memory.NewDB()
+	user := &models.User{ID: "user-1", Username: "alice", Email: "alice@example.com", Role: "user"}
+	if err := memory.NewUserRepository(db).CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	return db, user
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestCreateFileQuota(t *testing.T) {
+	tests := []struct {
+		name          string
+		quota         models.Quota
+		overrideBytes *int64
+		overrideFiles *int64
+		existing      []int64 // Sizes of files already stored
+		size          int64
+		wantErr       error
+	}{
+		{"unlimited", models.Quota{}, nil, nil, []int64{1 << 40}, 1 << 40, nil},
+		{"fits", models.Quota{MaxBytes: 100}, nil, nil, []int64{60}, 40, nil},
+		{"bytes exceeded", models.Quota{MaxBytes: 100}, nil, nil, []int64{60}, 41, repository.ErrQuotaExceeded},
+		{"single file over the limit", models.Quota{MaxBytes: 100}, nil, nil, nil, 101, repository.ErrQuotaExceeded},
+		{"file count exceeded", models.Quota{MaxFiles: 2}, nil, nil, []int64{1, 1}, 1, repository.ErrQuotaExceeded},
+		{"override raises bytes", models.Quota{MaxBytes: 100}, int64Ptr(1000), nil, []int64{60}, 500, nil},
+		{"override lowers bytes", models.Quota{MaxBytes: 1000}, int64Ptr(100), nil, []int64{60}, 41, repository.ErrQuotaExceeded},
+		{"override removes the file limit", models.Quota{MaxFiles: 1}, nil, int64Ptr(0), []int64{1, 1}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, owner := newOwner(t)
+			files := memory.NewFileRepository(db)
+			usageRepo := memory.NewUsageRepository(db)
+
+			for i, size := range tt.existing {
+				file := models.NewFile(fmt.Sprintf("existing-%d", i), "old", "old", "", "text/plain", owner.ID, size)
+				if err := files.CreateFile(file, models.Quota{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.overrideBytes != nil || tt.overrideFiles != nil {
+				if err := usageRepo.SetQuotaOverride(owner.ID, tt.overrideBytes, tt.overrideFiles); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, _ := usageRepo.GetUsage(owner.ID)
+
+			err := files.CreateFile(models.NewFile("new", "new", "new", "", "text/plain", owner.ID, tt.size), tt.quota)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateFile() error = %v, want %v", err, tt.wantErr)
+			}
+
+			after, _ := usageRepo.GetUsage(owner.ID)
+			if tt.wantErr != nil {
+				if after.BytesUsed != before.BytesUsed || after.FileCount != before.FileCount {
+					t.Errorf("rejected file changed usage from %d bytes in %d files to %d in %d",
+						before.BytesUsed, before.FileCount, after.BytesUsed, after.FileCount)
+				}
+				if _, err := files.GetFileByID("new"); err == nil {
+					t.Error("rejected file was stored")
+				}
+				return
+			}
+			if after.BytesUsed != before.BytesUsed+tt.size || after.FileCount != before.FileCount+1 {
+				t.Errorf("usage is %d bytes in %d files, want %d in %d",
+					after.BytesUsed, after.FileCount, before.BytesUsed+tt.size, before.FileCount+1)
+			}
+		})
+	}
+}
+
+func TestCreateFileQuotaConcurrent(t *testing.T) {
+	db, owner := newOwner(t)
+	files := memory.NewFileRepository(db)
+	quota := models.Quota{MaxBytes: 10 * 100}
+
+	// Twice as many uploads as fit race for the quota; exactly the ones that fit must win
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := files.CreateFile(models.NewFile(fmt.Sprintf("file-%d", i), "f", "f", "", "text/plain", owner.ID, 100), quota)
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			} else if !errors.Is(err, repository.ErrQuotaExceeded) {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	usage, _ := memory.NewUsageRepository(db).GetUsage(owner.ID)
+	if created != 10 || usage.BytesUsed != 1000 || usage.FileCount != 10 {
+		t.Errorf("created %d files using %d bytes in %d files, want 10 using 1000 in 10", created, usage.BytesUsed, usage.FileCount)
+	}
+}
+
+func TestDeleteFileReleasesQuota(t *testing.T) {
+	db, owner := newOwner(t)
+	files := memory.NewFileRepository(db)
+	quota := models.Quota{MaxBytes: 100}
+
+	if err := files.CreateFile(models.NewFile("first", "f", "f", "", "text/plain", owner.ID, 100), quota); err != nil {
+		t.Fatal(err)
+	}
+	second := models.NewFile("second", "f", "f", "", "text/plain", owner.ID, 100)
+	if err := files.CreateFile(second, quota); !errors.Is(err, repository.ErrQuotaExceeded) {
+		t.Fatalf("CreateFile() over quota error = %v, want ErrQuotaExceeded", err)
+	}
+	if err := files.DeleteFile("first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := files.CreateFile(second, quota); err != nil {
+		t.Errorf("CreateFile() after a delete freed the quota: %v", err)
+	}
+}
+
+func TestListOldVersions(t *testing.T) {
+	db, owner := newOwner(t)
+	other := &models.User{ID: "user-2", Username: "bob", Email: "bob@example.com", Role: "user"}
+	if err := memory.NewUserRepository(db).CreateUser(other); err != nil {
+		t.Fatal(err)
+	}
+	files := memory.NewFileRepository(db)
+
+	created := time.Now().Add(-time.Hour)
+	store := func(id, path, ownerID string, age time.Duration, active bool) {
+		file := models.NewFile(id, "report.pdf", path, "", "application/pdf", ownerID, 10)
+		file.CreatedAt = created.Add(-age)
+		if err := files.CreateFile(file, models.Quota{}); err != nil {
+			t.Fatal(err)
+		}
+		if active {
+			if err := files.ActivateFile(id, "", ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	store("v1", "/docs/report.pdf", owner.ID, 3*time.Hour, true)
+	store("v2", "/docs/report.pdf", owner.ID, 2*time.Hour, true)
+	store("v3", "/docs/report.pdf", owner.ID, time.Hour, true)
+	store("v4", "/docs/report.pdf", owner.ID, 0, false) // Upload not finished, so not yet a version
+	store("only", "/docs/notes.txt", owner.ID, 3*time.Hour, true)
+	store("bob", "/docs/report.pdf", other.ID, 4*time.Hour, true) // Same path, other owner
+
+	rule := models.NewLifecycleRule("rule-1", "prune", models.LifecycleDeleteOldVersions, "admin")
+	if err := rule.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	old, err := files.ListFilesForLifecycleRule(rule, "", time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, file := range old {
+		ids = append(ids, file.ID)
+	}
+	if fmt.Sprint(ids) != "[v1 v2]" {
+		t.Errorf("old versions are %v, want [v1 v2]", ids)
+	}
+}
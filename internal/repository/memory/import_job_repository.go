@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// ImportJobRepository is the in-memory counterpart of repository.ImportJobRepository.
+type ImportJobRepository struct {
+	db *DB
+}
+
+// NewImportJobRepository creates a new instance of ImportJobRepository.
+func NewImportJobRepository(db *DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+// CreateImportJob inserts a new import job.
+func (r *ImportJobRepository) CreateImportJob(job *models.ImportJob) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.importJobs[job.ID]; ok {
+		return errors.New("import job already exists")
+	}
+	if _, ok := r.db.users[job.OwnerID]; !ok {
+		return errors.New("user not found")
+	}
+	r.db.importJobs[job.ID] = *job
+	return nil
+}
+
+// GetImportJob retrieves an import job by its ID.
+func (r *ImportJobRepository) GetImportJob(id string) (*models.ImportJob, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	job, ok := r.db.importJobs[id]
+	if !ok {
+		return nil, errors.New("import job not found")
+	}
+	return &job, nil
+}
+
+// ListImportJobsByOwner retrieves a user's most recent import jobs, newest first.
+func (r *ImportJobRepository) ListImportJobsByOwner(ownerID string, limit int) ([]*models.ImportJob, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	jobs := r.db.selectImportJobsLocked(func(job models.ImportJob) bool { return job.OwnerID == ownerID })
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// CountActiveImportJobs counts a user's import jobs that have not finished yet.
+func (r *ImportJobRepository) CountActiveImportJobs(ownerID string) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	jobs := r.db.selectImportJobsLocked(func(job models.ImportJob) bool { return job.OwnerID == ownerID && !job.IsFinished() })
+	return len(jobs), nil
+}
+
+// ListImportJobsToRun retrieves the oldest pending import jobs, along with running jobs that have
+// not made progress since staleBefore.
+func (r *ImportJobRepository) ListImportJobsToRun(staleBefore time.Time, limit int) ([]*models.ImportJob, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	jobs := r.db.selectImportJobsLocked(func(job models.ImportJob) bool { return importJobRunnable(job, staleBefore) })
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// ClaimImportJob marks a runnable job as running, reporting false if it is no longer runnable.
+func (r *ImportJobRepository) ClaimImportJob(id string, staleBefore time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	job, ok := r.db.importJobs[id]
+	if !ok || !importJobRunnable(job, staleBefore) {
+		return false, nil
+	}
+	job.Status = models.ImportRunning
+	job.BytesDownloaded, job.TotalBytes = 0, 0
+	job.UpdatedAt = time.Now()
+	r.db.importJobs[id] = job
+	return true, nil
+}
+
+// UpdateImportProgress records how much of a running job has been downloaded.
+func (r *ImportJobRepository) UpdateImportProgress(id string, downloaded, total int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	job, ok := r.db.importJobs[id]
+	if !ok || job.Status != models.ImportRunning {
+		return nil
+	}
+	job.BytesDownloaded, job.TotalBytes = downloaded, total
+	job.UpdatedAt = time.Now()
+	r.db.importJobs[id] = job
+	return nil
+}
+
+// FinishImportJob records the final status, progress, file and error of a job.
+func (r *ImportJobRepository) FinishImportJob(job *models.ImportJob) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.importJobs[job.ID]; !ok {
+		return errors.New("import job not found")
+	}
+	r.db.importJobs[job.ID] = *job
+	return nil
+}
+
+// importJobRunnable reports whether a job is pending, or running without progress since staleBefore.
+func importJobRunnable(job models.ImportJob, staleBefore time.Time) bool {
+	return job.Status == models.ImportPending || job.Status == models.ImportRunning && job.UpdatedAt.Before(staleBefore)
+}
+
+// selectImportJobsLocked copies the import jobs that match. The caller must hold the lock.
+func (db *DB) selectImportJobsLocked(match func(job models.ImportJob) bool) []*models.ImportJob {
+	jobs := []*models.ImportJob{}
+	for _, job := range db.importJobs {
+		if match(job) {
+			job := job
+			jobs = append(jobs, &job)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// LifecycleRuleRepository is the in-memory counterpart of repository.LifecycleRuleRepository.
+type LifecycleRuleRepository struct {
+	db *DB
+}
+
+// NewLifecycleRuleRepository creates a new instance of LifecycleRuleRepository.
+func NewLifecycleRuleRepository(db *DB) *LifecycleRuleRepository {
+	return &LifecycleRuleRepository{db: db}
+}
+
+// CreateLifecycleRule inserts a new lifecycle rule.
+func (r *LifecycleRuleRepository) CreateLifecycleRule(rule *models.LifecycleRule) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.lifecycleRules[rule.ID]; ok {
+		return errors.New("lifecycle rule already exists")
+	}
+	r.db.lifecycleRules[rule.ID] = *rule
+	return nil
+}
+
+// ListLifecycleRules retrieves every lifecycle rule in the order they were created.
+func (r *LifecycleRuleRepository) ListLifecycleRules() ([]*models.LifecycleRule, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rules := []*models.LifecycleRule{}
+	for _, rule := range r.db.lifecycleRules {
+		rule := rule
+		rules = append(rules, &rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].CreatedAt.Before(rules[j].CreatedAt) })
+	return rules, nil
+}
+
+// DeleteLifecycleRule removes a lifecycle rule by its ID.
+func (r *LifecycleRuleRepository) DeleteLifecycleRule(id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.lifecycleRules[id]; !ok {
+		return errors.New("lifecycle rule not found")
+	}
+	delete(r.db.lifecycleRules, id)
+	return nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/repository"
+)
+
+// MFARepository is the in-memory counterpart of repository.MFARepository.
+type MFARepository struct {
+	db *DB
+}
+
+// NewMFARepository creates a new instance of MFARepository.
+func NewMFARepository(db *DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// GetMFA retrieves a user's MFA settings.
+func (r *MFARepository) GetMFA(userID string) (*models.MFASettings, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	settings, ok := r.db.mfa[userID]
+	if !ok {
+		return nil, repository.ErrMFANotConfigured
+	}
+	return &settings, nil
+}
+
+// SaveMFASecret stores a new, not yet enabled, TOTP secret for a user.
+// Any previous enrollment that was never enabled is replaced.
+func (r *MFARepository) SaveMFASecret(settings *models.MFASettings) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[settings.UserID]; !ok {
+		return errors.New("user not found")
+	}
+
+	stored, ok := r.db.mfa[settings.UserID]
+	if ok && stored.Enabled {
+		return errors.New("mfa is already enabled")
+	}
+	if !ok {
+		stored = models.MFASettings{UserID: settings.UserID, CreatedAt: settings.CreatedAt}
+	}
+	stored.Secret = settings.Secret
+	stored.LastUsedStep = 0
+	stored.UpdatedAt = settings.UpdatedAt
+	r.db.mfa[settings.UserID] = stored
+	return nil
+}
+
+// EnableMFA turns on MFA for a user and replaces their recovery codes with the given hashes.
+func (r *MFARepository) EnableMFA(userID string, step int64, codeHashes []string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	if settings, ok := r.db.mfa[userID]; ok {
+		settings.Enabled = true
+		settings.EnabledAt = timePtr(now)
+		settings.LastUsedStep = step
+		settings.UpdatedAt = now
+		r.db.mfa[userID] = settings
+	}
+	r.replaceRecoveryCodesLocked(userID, codeHashes)
+	return nil
+}
+
+// ReplaceRecoveryCodes discards a user's remaining recovery codes and stores new ones.
+func (r *MFARepository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.replaceRecoveryCodesLocked(userID, codeHashes)
+	return nil
+}
+
+// ConsumeTOTPStep records a TOTP time step as used. It fails if the same or a later
+// step has already been accepted, so a code cannot be replayed.
+func (r *MFARepository) ConsumeTOTPStep(userID string, step int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	settings, ok := r.db.mfa[userID]
+	if !ok || settings.LastUsedStep >= step {
+		return errors.New("code has already been used")
+	}
+	settings.LastUsedStep = step
+	settings.UpdatedAt = time.Now()
+	r.db.mfa[userID] = settings
+	return nil
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used.
+func (r *MFARepository) ConsumeRecoveryCode(userID, codeHash string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	codes := r.db.recoveryCodes[userID]
+	used, ok := codes[codeHash]
+	if !ok || used {
+		return errors.New("invalid recovery code")
+	}
+	codes[codeHash] = true
+	return nil
+}
+
+// DeleteMFA removes a user's MFA enrollment and recovery codes.
+func (r *MFARepository) DeleteMFA(userID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.mfa, userID)
+	delete(r.db.recoveryCodes, userID)
+	return nil
+}
+
+// replaceRecoveryCodesLocked replaces a user's recovery codes. The caller must hold the lock.
+func (r *MFARepository) replaceRecoveryCodesLocked(userID string, codeHashes []string) {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	r.db.recoveryCodes[userID] = codes
+}
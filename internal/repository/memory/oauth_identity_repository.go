@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"errors"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// OAuthIdentityRepository is the in-memory counterpart of repository.OAuthIdentityRepository.
+type OAuthIdentityRepository struct {
+	db *DB
+}
+
+// NewOAuthIdentityRepository creates a new instance of OAuthIdentityRepository.
+func NewOAuthIdentityRepository(db *DB) *OAuthIdentityRepository {
+	return &OAuthIdentityRepository{db: db}
+}
+
+// CreateOAuthIdentity links an identity to a user. It fails if the identity is already linked.
+func (r *OAuthIdentityRepository) CreateOAuthIdentity(identity *models.OAuthIdentity) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[identity.UserID]; !ok {
+		return errors.New("user not found")
+	}
+	for _, other := range r.db.identities {
+		if other.ID == identity.ID || (other.Provider == identity.Provider && other.Subject == identity.Subject) {
+			return errors.New("identity already exists")
+		}
+	}
+
+	r.db.identities[identity.ID] = *identity
+	return nil
+}
+
+// GetOAuthIdentity retrieves the identity a provider knows by subject.
+func (r *OAuthIdentityRepository) GetOAuthIdentity(provider, subject string) (*models.OAuthIdentity, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, identity := range r.db.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, errors.New("identity not found")
+}
+
+// DeleteOAuthIdentity unlinks an identity.
+func (r *OAuthIdentityRepository) DeleteOAuthIdentity(id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.identities, id)
+	return nil
+}
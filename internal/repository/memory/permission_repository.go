@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"errors"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// PermissionRepository is the in-memory counterpart of repository.PermissionRepository.
+type PermissionRepository struct {
+	db *DB
+}
+
+// NewPermissionRepository creates a new instance of PermissionRepository.
+func NewPermissionRepository(db *DB) *PermissionRepository {
+	return &PermissionRepository{db: db}
+}
+
+// GrantPermission grants a user a specific set of permissions for a file.
+func (r *PermissionRepository) GrantPermission(permission *models.Permission) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.permissions[permission.ID]; ok {
+		return errors.New("permission already exists")
+	}
+	if _, ok := r.db.files[permission.FileID]; !ok {
+		return errors.New("file not found")
+	}
+	if _, ok := r.db.users[permission.UserID]; !ok {
+		return errors.New("user not found")
+	}
+
+	r.db.permissions[permission.ID] = *permission
+	return nil
+}
+
+// GetPermission checks if a user has permission to access a file.
+func (r *PermissionRepository) GetPermission(userID, fileID string) (*models.Permission, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, permission := range r.db.permissions {
+		if permission.UserID == userID && permission.FileID == fileID {
+			return &permission, nil
+		}
+	}
+	return nil, errors.New("no permission found")
+}
+
+// RevokePermission revokes a user's permission for a file.
+func (r *PermissionRepository) RevokePermission(userID, fileID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, permission := range r.db.permissions {
+		if permission.UserID == userID && permission.FileID == fileID {
+			delete(r.db.permissions, id)
+		}
+	}
+	return nil
+}
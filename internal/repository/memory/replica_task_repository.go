@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// replicaTaskKey identifies the single task kept per object and replica.
+type replicaTaskKey struct {
+	storageKey string
+	backend    string
+}
+
+// ReplicaTaskRepository is the in-memory counterpart of repository.ReplicaTaskRepository.
+type ReplicaTaskRepository struct {
+	db *DB
+}
+
+// NewReplicaTaskRepository creates a new instance of ReplicaTaskRepository.
+func NewReplicaTaskRepository(db *DB) *ReplicaTaskRepository {
+	return &ReplicaTaskRepository{db: db}
+}
+
+// EnqueueReplicaTask records an operation a replica missed. A task already queued for the same
+// object and replica is replaced, keeping its ID, since only the latest operation matters.
+func (r *ReplicaTaskRepository) EnqueueReplicaTask(task *models.ReplicaTask) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	key := replicaTaskKey{storageKey: task.StorageKey, backend: task.Backend}
+	stored := *task
+	if existing, ok := r.db.replicaTasks[key]; ok {
+		stored.ID = existing.ID
+	}
+	r.db.replicaTasks[key] = stored
+	return nil
+}
+
+// ListDueReplicaTasks retrieves up to limit tasks whose next attempt is due, oldest first.
+func (r *ReplicaTaskRepository) ListDueReplicaTasks(now time.Time, limit int) ([]*models.ReplicaTask, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	tasks := []*models.ReplicaTask{}
+	for _, task := range r.db.replicaTasks {
+		if !task.NextAttemptAt.After(now) {
+			task := task
+			tasks = append(tasks, &task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].NextAttemptAt.Before(tasks[j].NextAttemptAt) })
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
+// CompleteReplicaTask removes a task once its operation has succeeded. A task that was replaced
+// by a newer operation while it ran is left in place.
+func (r *ReplicaTaskRepository) CompleteReplicaTask(task *models.ReplicaTask) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if key, ok := r.findLocked(task); ok {
+		delete(r.db.replicaTasks, key)
+	}
+	return nil
+}
+
+// RescheduleReplicaTask records a failed attempt and when to try again.
+func (r *ReplicaTaskRepository) RescheduleReplicaTask(task *models.ReplicaTask) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if key, ok := r.findLocked(task); ok {
+		stored := r.db.replicaTasks[key]
+		stored.Attempts = task.Attempts
+		stored.LastError = task.LastError
+		stored.NextAttemptAt = task.NextAttemptAt
+		r.db.replicaTasks[key] = stored
+	}
+	return nil
+}
+
+// CancelReplicaTasks removes every task queued for an object, e.g. once it has been deleted everywhere.
+func (r *ReplicaTaskRepository) CancelReplicaTasks(storageKey string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for key := range r.db.replicaTasks {
+		if key.storageKey == storageKey {
+			delete(r.db.replicaTasks, key)
+		}
+	}
+	return nil
+}
+
+// findLocked finds the stored version of a task, unless it has since been replaced by a newer
+// operation. The caller must hold the lock.
+func (r *ReplicaTaskRepository) findLocked(task *models.ReplicaTask) (replicaTaskKey, bool) {
+	for key, stored := range r.db.replicaTasks {
+		if stored.ID == task.ID && stored.Operation == task.Operation && stored.CreatedAt.Equal(task.CreatedAt) {
+			return key, true
+		}
+	}
+	return replicaTaskKey{}, false
+}
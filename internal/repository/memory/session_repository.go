@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// SessionRepository is the in-memory counterpart of repository.SessionRepository.
+type SessionRepository struct {
+	db *DB
+}
+
+// NewSessionRepository creates a new instance of SessionRepository.
+func NewSessionRepository(db *DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// CreateSession inserts a new session.
+func (r *SessionRepository) CreateSession(session *models.Session) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.sessions[session.ID]; ok {
+		return errors.New("session already exists")
+	}
+	r.db.sessions[session.ID] = *session
+	return nil
+}
+
+// GetSessionByID retrieves a session by its ID.
+func (r *SessionRepository) GetSessionByID(id string) (*models.Session, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session, ok := r.db.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	return &session, nil
+}
+
+// ListActiveSessionsByUser retrieves a user's unexpired, unrevoked sessions, most recently used first.
+func (r *SessionRepository) ListActiveSessionsByUser(userID string) ([]*models.Session, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	sessions := []*models.Session{}
+	for _, session := range r.db.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			session := session
+			sessions = append(sessions, &session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// TouchSession records the time a session was last used to authenticate.
+func (r *SessionRepository) TouchSession(id string, seenAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if session, ok := r.db.sessions[id]; ok {
+		session.LastSeenAt = seenAt
+		r.db.sessions[id] = session
+	}
+	return nil
+}
+
+// RevokeSession marks one of a user's sessions as revoked.
+func (r *SessionRepository) RevokeSession(id, userID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session, ok := r.db.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return errors.New("session not found")
+	}
+	session.RevokedAt = timePtr(time.Now())
+	r.db.sessions[id] = session
+	return nil
+}
+
+// RevokeUserSessions revokes every active session belonging to a user.
+func (r *SessionRepository) RevokeUserSessions(userID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.db.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = timePtr(now)
+			r.db.sessions[id] = session
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+var (
+	_ repository.UserStore          = (*UserRepository)(nil)
+	_ repository.FileStore          = (*FileRepository)(nil)
+	_ repository.PermissionStore    = (*PermissionRepository)(nil)
+	_ repository.APIKeyStore        = (*APIKeyRepository)(nil)
+	_ repository.MFAStore           = (*MFARepository)(nil)
+	_ repository.UserTokenStore     = (*UserTokenRepository)(nil)
+	_ repository.OAuthIdentityStore = (*OAuthIdentityRepository)(nil)
+	_ repository.SessionStore       = (*SessionRepository)(nil)
+	_ repository.UsageStore         = (*UsageRepository)(nil)
+	_ repository.LifecycleRuleStore = (*LifecycleRuleRepository)(nil)
+	_ repository.ImportJobStore     = (*ImportJobRepository)(nil)
+	_ storage.ReplicaQueue          = (*ReplicaTaskRepository)(nil)
+)
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// UsageRepository is the in-memory counterpart of repository.UsageRepository. The usage
+// counters themselves are maintained by FileRepository as files are created and deleted.
+type UsageRepository struct {
+	db *DB
+}
+
+// NewUsageRepository creates a new instance of UsageRepository.
+func NewUsageRepository(db *DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// GetUsage retrieves a user's storage usage. Users who have never uploaded have zero usage.
+func (r *UsageRepository) GetUsage(userID string) (*models.StorageUsage, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	usage, ok := r.db.usage[userID]
+	if !ok {
+		usage = models.StorageUsage{UserID: userID}
+	}
+	return &usage, nil
+}
+
+// SetQuotaOverride sets a user's quota overrides. A nil limit falls back to the role default.
+func (r *UsageRepository) SetQuotaOverride(userID string, quotaBytes, quotaFiles *int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; !ok {
+		return errors.New("user not found")
+	}
+
+	usage, ok := r.db.usage[userID]
+	if !ok {
+		usage = models.StorageUsage{UserID: userID}
+	}
+	usage.QuotaBytes = quotaBytes
+	usage.QuotaFiles = quotaFiles
+	usage.UpdatedAt = time.Now()
+	r.db.usage[userID] = usage
+	return nil
+}
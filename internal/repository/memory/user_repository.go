@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// UserRepository is the in-memory counterpart of repository.UserRepository.
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new instance of UserRepository.
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// CreateUser inserts a new user, enforcing unique IDs, usernames and emails.
+func (r *UserRepository) CreateUser(user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[user.ID]; ok {
+		return errors.New("user already exists")
+	}
+	if err := r.checkUniqueLocked(user); err != nil {
+		return err
+	}
+
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+// GetUserByID retrieves a user by their ID.
+func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
+	return r.findUser(func(user models.User) bool { return user.ID == id })
+}
+
+// GetUserByUsername retrieves a user by their username.
+func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+	return r.findUser(func(user models.User) bool { return user.Username == username })
+}
+
+// GetUserByEmail retrieves a user by their email address.
+func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
+	return r.findUser(func(user models.User) bool { return user.Email == email })
+}
+
+// UpdateUser updates a user's information. Updating a user that does not exist does nothing.
+func (r *UserRepository) UpdateUser(user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.users[user.ID]
+	if !ok {
+		return nil
+	}
+	if err := r.checkUniqueLocked(user); err != nil {
+		return err
+	}
+
+	stored.Username = user.Username
+	stored.Email = user.Email
+	stored.Password = user.Password
+	stored.Role = user.Role
+	stored.EmailVerifiedAt = user.EmailVerifiedAt
+	stored.IsAnonymous = user.IsAnonymous
+	stored.ExpiresAt = user.ExpiresAt
+	stored.UpdatedAt = time.Now()
+	r.db.users[user.ID] = stored
+	return nil
+}
+
+// ListExpiredAnonymousUsers retrieves up to limit anonymous users whose expiry has passed.
+func (r *UserRepository) ListExpiredAnonymousUsers(now time.Time, limit int) ([]*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var expired []models.User
+	for _, user := range r.db.users {
+		if user.IsAnonymous && user.ExpiresAt != nil && !user.ExpiresAt.After(now) {
+			expired = append(expired, user)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(*expired[j].ExpiresAt) })
+
+	users := []*models.User{}
+	for i := 0; i < len(expired) && i < limit; i++ {
+		user := expired[i]
+		users = append(users, &user)
+	}
+	return users, nil
+}
+
+// MarkEmailVerified records that a user has confirmed their email address.
+func (r *UserRepository) MarkEmailVerified(id string, verifiedAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if user, ok := r.db.users[id]; ok {
+		user.EmailVerifiedAt = timePtr(verifiedAt)
+		user.UpdatedAt = verifiedAt
+		r.db.users[id] = user
+	}
+	return nil
+}
+
+// DeleteUser removes a user and everything that belongs to them.
+func (r *UserRepository) DeleteUser(id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.deleteUserLocked(id)
+	return nil
+}
+
+// findUser returns a copy of the first user matching a condition.
+func (r *UserRepository) findUser(match func(models.User) bool) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, user := range r.db.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+// checkUniqueLocked rejects a username or email already used by another user.
+func (r *UserRepository) checkUniqueLocked(user *models.User) error {
+	for _, other := range r.db.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Username == user.Username {
+			return errors.New("username already exists")
+		}
+		if other.Email == user.Email {
+			return errors.New("email already exists")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// UserTokenRepository is the in-memory counterpart of repository.UserTokenRepository.
+type UserTokenRepository struct {
+	db *DB
+}
+
+// NewUserTokenRepository creates a new instance of UserTokenRepository.
+func NewUserTokenRepository(db *DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// CreateUserToken stores a new token, invalidating any unused tokens the user has for the same purpose.
+func (r *UserTokenRepository) CreateUserToken(token *models.UserToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[token.UserID]; !ok {
+		return errors.New("user not found")
+	}
+	for _, other := range r.db.userTokens {
+		if other.ID == token.ID || other.TokenHash == token.TokenHash {
+			return errors.New("token already exists")
+		}
+	}
+
+	now := time.Now()
+	for id, other := range r.db.userTokens {
+		if other.UserID == token.UserID && other.Purpose == token.Purpose && other.UsedAt == nil {
+			other.UsedAt = timePtr(now)
+			r.db.userTokens[id] = other
+		}
+	}
+
+	r.db.userTokens[token.ID] = *token
+	return nil
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns it.
+func (r *UserTokenRepository) ConsumeUserToken(purpose, tokenHash string) (*models.UserToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.db.userTokens {
+		if token.Purpose == purpose && token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			token.UsedAt = timePtr(now)
+			r.db.userTokens[id] = token
+			return &token, nil
+		}
+	}
+	return nil, errors.New("token is invalid, expired or already used")
+}
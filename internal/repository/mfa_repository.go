@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// ErrMFANotConfigured is returned when a user has never enrolled in MFA.
+var ErrMFANotConfigured = errors.New("mfa not configured")
+
+type MFARepository struct {
+	db *sql.DB
+}
+
+// NewMFARepository creates a new instance of MFARepository.
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// GetMFA retrieves a user's MFA settings from the database.
+func (r *MFARepository) GetMFA(userID string) (*models.MFASettings, error) {
+	var settings models.MFASettings
+	var enabledAt sql.NullTime
+
+	query := `
+		SELECT user_id, secret, enabled, enabled_at, last_used_step, created_at, updated_at
+		FROM user_mfa WHERE user_id = $1
+	`
+	err := r.db.QueryRow(query, userID).Scan(&settings.UserID, &settings.Secret, &settings.Enabled, &enabledAt, &settings.LastUsedStep, &settings.CreatedAt, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFANotConfigured
+	} else if err != nil {
+		return nil, err
+	}
+
+	settings.EnabledAt = nullTimePtr(enabledAt)
+	return &settings, nil
+}
+
+// SaveMFASecret stores a new, not yet enabled, TOTP secret for a user.
+// Any previous enrollment that was never enabled is replaced.
+func (r *MFARepository) SaveMFASecret(settings *models.MFASettings) error {
+	query := `
+		INSERT INTO user_mfa (user_id, secret, enabled, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, FALSE, 0, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = EXCLUDED.updated_at
+		WHERE user_mfa.enabled = FALSE
+	`
+	result, err := r.db.Exec(query, settings.UserID, settings.Secret, settings.CreatedAt, settings.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("mfa is already enabled")
+	}
+	return nil
+}
+
+// EnableMFA turns on MFA for a user and replaces their recovery codes with the given hashes.
+func (r *MFARepository) EnableMFA(userID string, step int64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `UPDATE user_mfa SET enabled = TRUE, enabled_at = $1, last_used_step = $2, updated_at = $1 WHERE user_id = $3`
+	if _, err := tx.Exec(query, now, step, userID); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes discards a user's remaining recovery codes and stores new ones.
+func (r *MFARepository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeTOTPStep records a TOTP time step as used. It fails if the same or a later
+// step has already been accepted, so a code cannot be replayed.
+func (r *MFARepository) ConsumeTOTPStep(userID string, step int64) error {
+	query := `UPDATE user_mfa SET last_used_step = $1, updated_at = $2 WHERE user_id = $3 AND last_used_step < $1`
+	result, err := r.db.Exec(query, step, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("code has already been used")
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used.
+func (r *MFARepository) ConsumeRecoveryCode(userID, codeHash string) error {
+	query := `UPDATE mfa_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("invalid recovery code")
+	}
+	return nil
+}
+
+// DeleteMFA removes a user's MFA enrollment and recovery codes.
+func (r *MFARepository) DeleteMFA(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and inserts the given hashes within a transaction.
+func replaceRecoveryCodes(tx *sql.Tx, userID string, codeHashes []string, createdAt time.Time) error {
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(query, uuid.New().String(), userID, hash, createdAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
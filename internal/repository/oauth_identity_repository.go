@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type OAuthIdentityRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthIdentityRepository creates a new instance of OAuthIdentityRepository.
+func NewOAuthIdentityRepository(db *sql.DB) *OAuthIdentityRepository {
+	return &OAuthIdentityRepository{db: db}
+}
+
+// CreateOAuthIdentity links an identity to a user. It fails if the identity is already linked.
+func (r *OAuthIdentityRepository) CreateOAuthIdentity(identity *models.OAuthIdentity) error {
+	query := `
+		INSERT INTO oauth_identities (id, user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.Exec(query, identity.ID, identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt)
+	return err
+}
+
+// GetOAuthIdentity retrieves the identity a provider knows by subject.
+func (r *OAuthIdentityRepository) GetOAuthIdentity(provider, subject string) (*models.OAuthIdentity, error) {
+	var identity models.OAuthIdentity
+
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM oauth_identities WHERE provider = $1 AND subject = $2
+	`
+	err := r.db.QueryRow(query, provider, subject).Scan(&identity.ID, &identity.UserID, &identity.Provider,
+		&identity.Subject, &identity.Email, &identity.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("identity not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// DeleteOAuthIdentity unlinks an identity.
+func (r *OAuthIdentityRepository) DeleteOAuthIdentity(id string) error {
+	_, err := r.db.Exec(`DELETE FROM oauth_identities WHERE id = $1`, id)
+	return err
+}
@@ -3,14 +3,9 @@ package repository
 import (
 	"database/sql"
 	"errors"
-)
 
-type Permission struct {
-	ID     string
-	UserID string
-	FileID string
-	Level  string // e.g., "read", "write", "admin"
-}
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
 
 type PermissionRepository struct {
 	db *sql.DB
@@ -21,13 +16,13 @@ func NewPermissionRepository(db *sql.DB) *PermissionRepository {
 	return &PermissionRepository{db: db}
 }
 
-// GrantPermission grants a user a specific level of permission for a file.
-func (r *PermissionRepository) GrantPermission(permission *Permission) error {
+// GrantPermission grants a user a specific set of permissions for a file.
+func (r *PermissionRepository) GrantPermission(permission *models.Permission) error {
 	query := `
-		INSERT INTO permissions (id, user_id, file_id, level)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO permissions (id, file_id, user_id, can_read, can_write, can_delete, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
-	_, err := r.db.Exec(query, permission.ID, permission.UserID, permission.FileID, permission.Level)
+	_, err := r.db.Exec(query, permission.ID, permission.FileID, permission.UserID, permission.CanRead, permission.CanWrite, permission.CanDelete, permission.CreatedAt, permission.UpdatedAt)
 	if err != nil {
 		return err
 	}
@@ -35,20 +30,25 @@ func (r *PermissionRepository) GrantPermission(permission *Permission) error {
 }
 
 // GetPermission checks if a user has permission to access a file.
-func (r *PermissionRepository) GetPermission(userID, fileID string) (*Permission, error) {
-	var permission Permission
+func (r *PermissionRepository) GetPermission(userID, fileID string) (*models.Permission, error) {
+	var permission models.Permission
+	var canRead, canWrite, canDelete sql.NullBool
 
 	query := `
-		SELECT id, user_id, file_id, level
+		SELECT id, file_id, user_id, can_read, can_write, can_delete, created_at, updated_at
 		FROM permissions WHERE user_id = $1 AND file_id = $2
 	`
-	err := r.db.QueryRow(query, userID, fileID).Scan(&permission.ID, &permission.UserID, &permission.FileID, &permission.Level)
+	err := r.db.QueryRow(query, userID, fileID).Scan(&permission.ID, &permission.FileID, &permission.UserID, &canRead, &canWrite, &canDelete, &permission.CreatedAt, &permission.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("no permission found")
 	} else if err != nil {
 		return nil, err
 	}
 
+	permission.CanRead = canRead.Bool
+	permission.CanWrite = canWrite.Bool
+	permission.CanDelete = canDelete.Bool
+
 	return &permission, nil
 }
 
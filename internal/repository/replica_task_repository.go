@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type ReplicaTaskRepository struct {
+	db *sql.DB
+}
+
+// NewReplicaTaskRepository creates a new instance of ReplicaTaskRepository.
+func NewReplicaTaskRepository(db *sql.DB) *ReplicaTaskRepository {
+	return &ReplicaTaskRepository{db: db}
+}
+
+// replicaTaskColumns lists the columns read by scanReplicaTask, in order.
+const replicaTaskColumns = `id, storage_key, backend, operation, size, checksum_sha256, attempts, last_error, next_attempt_at, created_at`
+
+// EnqueueReplicaTask records an operation a replica missed. A task already queued for the same
+// object and replica is replaced, since only the latest operation matters.
+func (r *ReplicaTaskRepository) EnqueueReplicaTask(task *models.ReplicaTask) error {
+	query := `
+		INSERT INTO replica_tasks (id, storage_key, backend, operation, size, checksum_sha256, attempts, last_error, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (storage_key, backend) DO UPDATE SET
+			operation = EXCLUDED.operation, size = EXCLUDED.size, checksum_sha256 = EXCLUDED.checksum_sha256,
+			attempts = EXCLUDED.attempts, last_error = EXCLUDED.last_error,
+			next_attempt_at = EXCLUDED.next_attempt_at, created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.Exec(query, task.ID, task.StorageKey, task.Backend, task.Operation, task.Size, nullString(task.ChecksumSHA256),
+		task.Attempts, nullString(task.LastError), task.NextAttemptAt, task.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListDueReplicaTasks retrieves up to limit tasks whose next attempt is due, oldest first.
+func (r *ReplicaTaskRepository) ListDueReplicaTasks(now time.Time, limit int) ([]*models.ReplicaTask, error) {
+	query := `SELECT ` + replicaTaskColumns + ` FROM replica_tasks WHERE next_attempt_at <= $1 ORDER BY next_attempt_at LIMIT $2`
+	rows, err := r.db.Query(query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*models.ReplicaTask{}
+	for rows.Next() {
+		task, err := scanReplicaTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// CompleteReplicaTask removes a task once its operation has succeeded. A task that was replaced
+// by a newer operation while it ran is left in place.
+func (r *ReplicaTaskRepository) CompleteReplicaTask(task *models.ReplicaTask) error {
+	query := `DELETE FROM replica_tasks WHERE id = $1 AND operation = $2 AND created_at = $3`
+	_, err := r.db.Exec(query, task.ID, task.Operation, task.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RescheduleReplicaTask records a failed attempt and when to try again.
+func (r *ReplicaTaskRepository) RescheduleReplicaTask(task *models.ReplicaTask) error {
+	query := `
+		UPDATE replica_tasks SET attempts = $1, last_error = $2, next_attempt_at = $3
+		WHERE id = $4 AND operation = $5 AND created_at = $6
+	`
+	_, err := r.db.Exec(query, task.Attempts, nullString(task.LastError), task.NextAttemptAt, task.ID, task.Operation, task.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CancelReplicaTasks removes every task queued for an object, e.g. once it has been deleted everywhere.
+func (r *ReplicaTaskRepository) CancelReplicaTasks(storageKey string) error {
+	query := `DELETE FROM replica_tasks WHERE storage_key = $1`
+	_, err := r.db.Exec(query, storageKey)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// scanReplicaTask reads a single replica_tasks row selected with replicaTaskColumns into a model.
+func scanReplicaTask(row rowScanner) (*models.ReplicaTask, error) {
+	var task models.ReplicaTask
+	var sha, lastError sql.NullString
+
+	err := row.Scan(&task.ID, &task.StorageKey, &task.Backend, &task.Operation, &task.Size, &sha, &task.Attempts, &lastError,
+		&task.NextAttemptAt, &task.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	task.ChecksumSHA256 = sha.String
+	task.LastError = lastError.String
+	return &task, nil
+}
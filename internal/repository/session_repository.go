@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new instance of SessionRepository.
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// sessionColumns lists the columns read by scanSession, in order.
+const sessionColumns = `id, user_id, ip_address, user_agent, created_at, last_seen_at, expires_at, revoked_at`
+
+// CreateSession inserts a new session record into the database.
+func (r *SessionRepository) CreateSession(session *models.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, ip_address, user_agent, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(query, session.ID, session.UserID, session.IPAddress, session.UserAgent, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSessionByID retrieves a session from the database by its ID.
+func (r *SessionRepository) GetSessionByID(id string) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+	session, err := scanSession(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("session not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListActiveSessionsByUser retrieves a user's unexpired, unrevoked sessions, most recently used first.
+func (r *SessionRepository) ListActiveSessionsByUser(userID string) ([]*models.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := r.db.Query(query, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records the time a session was last used to authenticate.
+func (r *SessionRepository) TouchSession(id string, seenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, seenAt, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RevokeSession marks one of a user's sessions as revoked.
+func (r *SessionRepository) RevokeSession(id, userID string) error {
+	query := `UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every active session belonging to a user.
+func (r *SessionRepository) RevokeUserSessions(userID string) error {
+	query := `UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// scanSession reads a single sessions row selected with sessionColumns into a model.
+func scanSession(row rowScanner) (*models.Session, error) {
+	var session models.Session
+	var ipAddress, userAgent sql.NullString
+	var revokedAt sql.NullTime
+
+	err := row.Scan(&session.ID, &session.UserID, &ipAddress, &userAgent, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session.IPAddress = ipAddress.String
+	session.UserAgent = userAgent.String
+	session.RevokedAt = nullTimePtr(revokedAt)
+	return &session, nil
+}
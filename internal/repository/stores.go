@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// The controllers and jobs depend on these interfaces rather than on the SQL repositories, so
+// they can also run against the in-memory repositories in the memory package.
+
+// UserStore is implemented by UserRepository.
+type UserStore interface {
+	CreateUser(user *models.User) error
+	GetUserByID(id string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	UpdateUser(user *models.User) error
+	ListExpiredAnonymousUsers(now time.Time, limit int) ([]*models.User, error)
+	MarkEmailVerified(id string, verifiedAt time.Time) error
+	DeleteUser(id string) error
+}
+
+// FileStore is implemented by FileRepository.
+type FileStore interface {
+	CreateFile(file *models.File, quota models.Quota) error
+	ActivateFile(id, url, backend string) error
+	MarkFileDeleting(id string) error
+	GetFileByID(id string) (*models.File, error)
+	ListFilesByOwner(ownerID string) ([]*models.File, error)
+	ListFilesInState(state string, before time.Time, limit int) ([]*models.File, error)
+	ListStorageKeys() (map[string]string, error)
+	ListFilesToMigrate(source string, afterCreatedAt time.Time, afterID string, limit int) ([]*models.File, error)
+	ListFilesForLifecycleRule(rule *models.LifecycleRule, coldBackend string, now time.Time, limit int) ([]*models.File, error)
+	MoveFileBackend(file *models.File, backend, url, checksumSHA256 string) (bool, error)
+	UpdateFile(file *models.File) error
+	TouchFileAccess(id string, accessedAt time.Time, interval time.Duration) error
+	UpdateStorageClass(id, class string) error
+	ListFileTags(id string) ([]string, error)
+	SetFileTags(id string, tags []string) error
+	GetFileMetadata(id string) (*models.FileMetadata, error)
+	SetFileMetadata(meta *models.FileMetadata) error
+	UpdateScanStatus(id, status string, scannedAt time.Time) error
+	ListFilesPendingScan(limit int) ([]*models.File, error)
+	ListFilesNeedingThumbnails(limit int) ([]*models.File, error)
+	UpdateThumbnailStatus(id, status string) error
+	ListFilesToVerify(limit int) ([]*models.File, error)
+	ListCorruptedFiles() ([]*models.File, error)
+	UpdateIntegrity(id, status, checksumSHA256 string, verifiedAt time.Time) error
+	DeleteFile(id string) error
+}
+
+// PermissionStore is implemented by PermissionRepository.
+type PermissionStore interface {
+	GrantPermission(permission *models.Permission) error
+	GetPermission(userID, fileID string) (*models.Permission, error)
+	RevokePermission(userID, fileID string) error
+}
+
+// APIKeyStore is implemented by APIKeyRepository.
+type APIKeyStore interface {
+	CreateAPIKey(key *models.APIKey) error
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	ListAPIKeysByUser(userID string) ([]*models.APIKey, error)
+	RevokeAPIKey(id, userID string) error
+	TouchAPIKey(id string, usedAt time.Time) error
+}
+
+// MFAStore is implemented by MFARepository.
+type MFAStore interface {
+	GetMFA(userID string) (*models.MFASettings, error)
+	SaveMFASecret(settings *models.MFASettings) error
+	EnableMFA(userID string, step int64, codeHashes []string) error
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	ConsumeTOTPStep(userID string, step int64) error
+	ConsumeRecoveryCode(userID, codeHash string) error
+	DeleteMFA(userID string) error
+}
+
+// UserTokenStore is implemented by UserTokenRepository.
+type UserTokenStore interface {
+	CreateUserToken(token *models.UserToken) error
+	ConsumeUserToken(purpose, tokenHash string) (*models.UserToken, error)
+}
+
+// OAuthIdentityStore is implemented by OAuthIdentityRepository.
+type OAuthIdentityStore interface {
+	CreateOAuthIdentity(identity *models.OAuthIdentity) error
+	GetOAuthIdentity(provider, subject string) (*models.OAuthIdentity, error)
+	DeleteOAuthIdentity(id string) error
+}
+
+// SessionStore is implemented by SessionRepository.
+type SessionStore interface {
+	CreateSession(session *models.Session) error
+	GetSessionByID(id string) (*models.Session, error)
+	ListActiveSessionsByUser(userID string) ([]*models.Session, error)
+	TouchSession(id string, seenAt time.Time) error
+	RevokeSession(id, userID string) error
+	RevokeUserSessions(userID string) error
+}
+
+// UsageStore is implemented by UsageRepository.
+type UsageStore interface {
+	GetUsage(userID string) (*models.StorageUsage, error)
+	SetQuotaOverride(userID string, quotaBytes, quotaFiles *int64) error
+}
+
+// LifecycleRuleStore is implemented by LifecycleRuleRepository.
+type LifecycleRuleStore interface {
+	CreateLifecycleRule(rule *models.LifecycleRule) error
+	ListLifecycleRules() ([]*models.LifecycleRule, error)
+	DeleteLifecycleRule(id string) error
+}
+
+// ImportJobStore is implemented by ImportJobRepository.
+type ImportJobStore interface {
+	CreateImportJob(job *models.ImportJob) error
+	GetImportJob(id string) (*models.ImportJob, error)
+	ListImportJobsByOwner(ownerID string, limit int) ([]*models.ImportJob, error)
+	CountActiveImportJobs(ownerID string) (int, error)
+	ListImportJobsToRun(staleBefore time.Time, limit int) ([]*models.ImportJob, error)
+	ClaimImportJob(id string, staleBefore time.Time) (bool, error)
+	UpdateImportProgress(id string, downloaded, total int64) error
+	FinishImportJob(job *models.ImportJob) error
+}
+
+// The replica task queue is used through storage.ReplicaQueue.
+
+var (
+	_ UserStore          = (*UserRepository)(nil)
+	_ FileStore          = (*FileRepository)(nil)
+	_ PermissionStore    = (*PermissionRepository)(nil)
+	_ APIKeyStore        = (*APIKeyRepository)(nil)
+	_ MFAStore           = (*MFARepository)(nil)
+	_ UserTokenStore     = (*UserTokenRepository)(nil)
+	_ OAuthIdentityStore = (*OAuthIdentityRepository)(nil)
+	_ SessionStore       = (*SessionRepository)(nil)
+	_ UsageStore         = (*UsageRepository)(nil)
+	_ LifecycleRuleStore = (*LifecycleRuleRepository)(nil)
+	_ ImportJobStore     = (*ImportJobRepository)(nil)
+)
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// UsageRepository reads storage usage and manages per-user quota overrides. The usage
+// counters themselves are maintained by FileRepository as files are created and deleted.
+type UsageRepository struct {
+	db *sql.DB
+}
+
+// NewUsageRepository creates a new instance of UsageRepository.
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// GetUsage retrieves a user's storage usage. Users who have never uploaded have zero usage.
+func (r *UsageRepository) GetUsage(userID string) (*models.StorageUsage, error) {
+	usage := models.StorageUsage{UserID: userID}
+	var quotaBytes, quotaFiles sql.NullInt64
+
+	query := `SELECT bytes_used, file_count, quota_bytes, quota_files, updated_at FROM storage_usage WHERE user_id = $1`
+	err := r.db.QueryRow(query, userID).Scan(&usage.BytesUsed, &usage.FileCount, &quotaBytes, &quotaFiles, &usage.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return &usage, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	usage.QuotaBytes = nullInt64Ptr(quotaBytes)
+	usage.QuotaFiles = nullInt64Ptr(quotaFiles)
+	return &usage, nil
+}
+
+// SetQuotaOverride sets a user's quota overrides. A nil limit falls back to the role default.
+func (r *UsageRepository) SetQuotaOverride(userID string, quotaBytes, quotaFiles *int64) error {
+	query := `
+		INSERT INTO storage_usage (user_id, quota_bytes, quota_files, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET quota_bytes = $2, quota_files = $3, updated_at = $4
+	`
+	_, err := r.db.Exec(query, userID, quotaBytes, quotaFiles, time.Now())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// nullInt64Ptr converts a nullable column value into an optional integer.
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
@@ -17,13 +17,16 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// userColumns lists the columns read by scanUser, in order.
+const userColumns = `id, username, email, password_hash, role, email_verified_at, is_anonymous, expires_at, created_at, updated_at`
+
 // CreateUser inserts a new user record into the database.
 func (r *UserRepository) CreateUser(user *models.User) error {
 	query := `
-		INSERT INTO users (id, username, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, username, email, password_hash, role, is_anonymous, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err := r.db.Exec(query, user.ID, user.Username, user.Email, user.Password, user.CreatedAt, user.UpdatedAt)
+	_, err := r.db.Exec(query, user.ID, user.Username, user.Email, user.Password, user.Role, user.IsAnonymous, user.ExpiresAt, user.CreatedAt, user.UpdatedAt)
 	if err != nil {
 		return err
 	}
@@ -32,29 +35,62 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 
 // GetUserByID retrieves a user from the database by their ID.
 func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
-	var user models.User
+	return r.getUser(`SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+}
 
-	query := `
-		SELECT id, username, email, password, created_at, updated_at
-		FROM users WHERE id = $1
-	`
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
-	} else if err != nil {
-		return nil, err
-	}
+// GetUserByUsername retrieves a user from the database by their username.
+func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+	return r.getUser(`SELECT `+userColumns+` FROM users WHERE username = $1`, username)
+}
 
-	return &user, nil
+// GetUserByEmail retrieves a user from the database by their email address.
+func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
+	return r.getUser(`SELECT `+userColumns+` FROM users WHERE email = $1`, email)
 }
 
 // UpdateUser updates a user's information in the database.
 func (r *UserRepository) UpdateUser(user *models.User) error {
 	query := `
-		UPDATE users SET username = $1, email = $2, password = $3, updated_at = $4
-		WHERE id = $5
+		UPDATE users SET username = $1, email = $2, password_hash = $3, role = $4, email_verified_at = $5,
+			is_anonymous = $6, expires_at = $7, updated_at = $8
+		WHERE id = $9
 	`
-	_, err := r.db.Exec(query, user.Username, user.Email, user.Password, time.Now(), user.ID)
+	_, err := r.db.Exec(query, user.Username, user.Email, user.Password, user.Role, user.EmailVerifiedAt, user.IsAnonymous, user.ExpiresAt, time.Now(), user.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListExpiredAnonymousUsers retrieves up to limit anonymous users whose expiry has passed.
+func (r *UserRepository) ListExpiredAnonymousUsers(now time.Time, limit int) ([]*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE is_anonymous = TRUE AND expires_at <= $1 ORDER BY expires_at LIMIT $2`
+	rows, err := r.db.Query(query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// MarkEmailVerified records that a user has confirmed their email address.
+func (r *UserRepository) MarkEmailVerified(id string, verifiedAt time.Time) error {
+	query := `UPDATE users SET email_verified_at = $1, updated_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, verifiedAt, id)
 	if err != nil {
 		return err
 	}
@@ -70,3 +106,29 @@ func (r *UserRepository) DeleteUser(id string) error {
 	}
 	return nil
 }
+
+// getUser runs a query expected to return a single user row.
+func (r *UserRepository) getUser(query string, arg interface{}) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("user not found")
+	} else if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// scanUser reads a single users row selected with userColumns into a model.
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var emailVerifiedAt, expiresAt sql.NullTime
+
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &emailVerifiedAt, &user.IsAnonymous, &expiresAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user.EmailVerifiedAt = nullTimePtr(emailVerifiedAt)
+	user.ExpiresAt = nullTimePtr(expiresAt)
+	return &user, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+type UserTokenRepository struct {
+	db *sql.DB
+}
+
+// NewUserTokenRepository creates a new instance of UserTokenRepository.
+func NewUserTokenRepository(db *sql.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// CreateUserToken stores a new token, invalidating any unused tokens the user has for the same purpose.
+func (r *UserTokenRepository) CreateUserToken(token *models.UserToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	invalidate := `UPDATE user_tokens SET used_at = $1 WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`
+	if _, err := tx.Exec(invalidate, time.Now(), token.UserID, token.Purpose); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_tokens (id, user_id, purpose, email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.Exec(query, token.ID, token.UserID, token.Purpose, token.Email, token.TokenHash, token.ExpiresAt, token.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns it.
+func (r *UserTokenRepository) ConsumeUserToken(purpose, tokenHash string) (*models.UserToken, error) {
+	var token models.UserToken
+	now := time.Now()
+
+	query := `
+		UPDATE user_tokens SET used_at = $1
+		WHERE purpose = $2 AND token_hash = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING id, user_id, purpose, email, token_hash, expires_at, used_at, created_at
+	`
+	err := r.db.QueryRow(query, now, purpose, tokenHash).Scan(&token.ID, &token.UserID, &token.Purpose, &token.Email,
+		&token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("token is invalid, expired or already used")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
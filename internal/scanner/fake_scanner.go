@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// FakeScanner is a test double that returns a fixed verdict and records what it was given.
+type FakeScanner struct {
+	Result Result
+	Err    error
+
+	mu      sync.Mutex
+	scanned [][]byte
+}
+
+// NewFakeScanner initializes a scanner that always returns the given result and error
+func NewFakeScanner(result Result, err error) *FakeScanner {
+	return &FakeScanner{Result: result, Err: err}
+}
+
+// Scan reads the content, records it and returns the configured verdict
+func (s *FakeScanner) Scan(ctx context.Context, content io.Reader) (Result, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return Result{}, err
+	}
+
+	s.mu.Lock()
+	s.scanned = append(s.scanned, data)
+	s.mu.Unlock()
+
+	return s.Result, s.Err
+}
+
+// Scanned returns the content of every file scanned so far, in order.
+func (s *FakeScanner) Scanned() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte(nil), s.scanned...)
+}
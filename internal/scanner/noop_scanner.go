@@ -0,0 +1,19 @@
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// NoopScanner reports every file as clean without looking at it.
+type NoopScanner struct{}
+
+// NewNoopScanner initializes a scanner that accepts everything
+func NewNoopScanner() *NoopScanner {
+	return &NoopScanner{}
+}
+
+// Scan always reports the file as clean
+func (s *NoopScanner) Scan(ctx context.Context, content io.Reader) (Result, error) {
+	return Result{Clean: true}, nil
+}
@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/souvik03-136/Go-Store/internal/config"
+)
+
+// Result is the verdict for a scanned file.
+type Result struct {
+	Clean     bool
+	Signature string // Name of the detected malware when the file is not clean
+}
+
+// Scanner interface that the clamd, no-op and fake scanners will implement
+type Scanner interface {
+	Scan(ctx context.Context, content io.Reader) (Result, error)
+}
+
+// NewScanner creates the scanner selected by SCANNER_PROVIDER ("clamd" or "noop").
+// The no-op scanner is the default so development works without a virus scanner.
+func NewScanner(cfg *config.Config) (Scanner, error) {
+	switch cfg.Scanner.Provider {
+	case "clamd":
+		return NewClamdScanner(cfg.Scanner.ClamdAddress, cfg.Scanner.Timeout)
+	case "", "noop":
+		return NewNoopScanner(), nil
+	default:
+		return nil, fmt.Errorf("unsupported scanner provider: %s", cfg.Scanner.Provider)
+	}
+}
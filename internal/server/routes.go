@@ -1,17 +1,51 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"                              // or another appropriate driver for your DB
+	_ "github.com/lib/pq" // or another appropriate driver for your DB
+	"github.com/souvik03-136/Go-Store/internal/auth"
 	"github.com/souvik03-136/Go-Store/internal/config" // Assuming you have a config package
 	"github.com/souvik03-136/Go-Store/internal/controllers"
+	"github.com/souvik03-136/Go-Store/internal/fetch"
+	"github.com/souvik03-136/Go-Store/internal/jobs"
+	"github.com/souvik03-136/Go-Store/internal/mailer"
+	"github.com/souvik03-136/Go-Store/internal/oauth"
+	"github.com/souvik03-136/Go-Store/internal/policy"
 	"github.com/souvik03-136/Go-Store/internal/repository"
+	"github.com/souvik03-136/Go-Store/internal/scanner"
+	"github.com/souvik03-136/Go-Store/internal/storage"
 )
 
-func InitRoutes(router *gin.Engine) {
+// Dependencies are the stores and services the routes and background jobs are built from.
+// NewDependencies connects them to PostgreSQL and the configured providers; tests can
+// substitute in-memory implementations instead.
+type Dependencies struct {
+	Config         *config.Config
+	Users          repository.UserStore
+	Files          repository.FileStore
+	Permissions    repository.PermissionStore
+	APIKeys        repository.APIKeyStore
+	MFA            repository.MFAStore
+	UserTokens     repository.UserTokenStore
+	Identities     repository.OAuthIdentityStore
+	Sessions       repository.SessionStore
+	Usage          repository.UsageStore
+	LifecycleRules repository.LifecycleRuleStore
+	ImportJobs     repository.ImportJobStore
+	Storage        storage.Storage
+	Mailer         mailer.Mailer
+	Scanner        scanner.Scanner
+	UploadPolicy   *policy.Policy
+}
+
+// NewDependencies loads the configuration and connects the repositories and services it selects.
+func NewDependencies() *Dependencies {
 	// Initialize database connection (PostgreSQL in this example)
 	connStr := "user=your_user password=your_password dbname=your_db sslmode=disable"
 	db, err := sql.Open("postgres", connStr)
@@ -19,44 +53,207 @@ func InitRoutes(router *gin.Engine) {
 		log.Fatalf("Could not connect to the database: %v", err)
 	}
 
-	// Don't forget to close the DB connection when the application stops
-	defer db.Close()
-
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	fileRepo := repository.NewFileRepository(db)
-
 	// Initialize configuration (assuming you have a config structure)
 	cfg, err := config.LoadConfig() // You should implement this function to load your config
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Initialize the mailer used for account emails
+	mail, err := mailer.NewMailer(cfg)
+	if err != nil {
+		log.Fatalf("Could not create mailer: %v", err)
+	}
+
+	// Initialize the storage backend for file contents
+	store, err := storage.NewStorage(context.Background(), cfg, repository.NewReplicaTaskRepository(db))
+	if err != nil {
+		log.Fatalf("Could not create storage: %v", err)
+	}
+
+	// Initialize the upload policy checked before files are stored
+	uploadPolicy, err := policy.NewPolicy(cfg.Upload)
+	if err != nil {
+		log.Fatalf("Could not load upload policy: %v", err)
+	}
+
+	// Initialize the malware scanner run on every upload
+	fileScanner, err := scanner.NewScanner(cfg)
+	if err != nil {
+		log.Fatalf("Could not create scanner: %v", err)
+	}
+
+	return &Dependencies{
+		Config:         cfg,
+		Users:          repository.NewUserRepository(db),
+		Files:          repository.NewFileRepository(db),
+		Permissions:    repository.NewPermissionRepository(db),
+		APIKeys:        repository.NewAPIKeyRepository(db),
+		MFA:            repository.NewMFARepository(db),
+		UserTokens:     repository.NewUserTokenRepository(db),
+		Identities:     repository.NewOAuthIdentityRepository(db),
+		Sessions:       repository.NewSessionRepository(db),
+		Usage:          repository.NewUsageRepository(db),
+		LifecycleRules: repository.NewLifecycleRuleRepository(db),
+		ImportJobs:     repository.NewImportJobRepository(db),
+		Storage:        store,
+		Mailer:         mail,
+		Scanner:        fileScanner,
+		UploadPolicy:   uploadPolicy,
+	}
+}
+
+// InitRoutes wires the controllers into the router and registers background jobs on the scheduler.
+func InitRoutes(router *gin.Engine, scheduler *jobs.Scheduler, deps *Dependencies) {
+	cfg := deps.Config
+	userRepo := deps.Users
+	fileRepo := deps.Files
+	permissionRepo := deps.Permissions
+	apiKeyRepo := deps.APIKeys
+	mfaRepo := deps.MFA
+	userTokenRepo := deps.UserTokens
+	identityRepo := deps.Identities
+	sessionRepo := deps.Sessions
+	usageRepo := deps.Usage
+	lifecycleRuleRepo := deps.LifecycleRules
+	importJobRepo := deps.ImportJobs
+	store := deps.Storage
+
+	// Record issued tokens as sessions so they can be revoked
+	auth.SetSessionStore(sessionRepo)
+
+	// Verifier of ID tokens from the OpenID Connect providers users can sign in with
+	var oauthProviders []oauth.Provider
+	for _, provider := range cfg.OAuth {
+		if provider.Issuer == "" || provider.ClientID == "" || provider.JWKSURL == "" {
+			log.Fatalf("OAuth provider %q needs an issuer, client ID and JWKS URL", provider.Name)
+		}
+		oauthProviders = append(oauthProviders, oauth.Provider(provider))
+	}
+	oauthVerifier := oauth.NewVerifier(oauthProviders, &http.Client{Timeout: 10 * time.Second})
+
 	// Initialize controllers
-	userController := controllers.NewUserController(userRepo)
+	userController := controllers.NewUserController(userRepo, usageRepo, cfg)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyRepo, userRepo)
+	authController := controllers.NewAuthController(userRepo, mfaRepo, identityRepo, oauthVerifier, cfg)
+	mfaController := controllers.NewMFAController(userRepo, mfaRepo)
+	sessionController := controllers.NewSessionController(sessionRepo)
+	adminController := controllers.NewAdminController(fileRepo)
+	lifecycleController := controllers.NewLifecycleController(lifecycleRuleRepo, fileRepo, cfg.Lifecycle.ColdProvider)
+	accountController := controllers.NewAccountController(userRepo, userTokenRepo, sessionRepo, deps.Mailer, cfg.AppBaseURL)
+	fileController := controllers.NewFileController(fileRepo, permissionRepo, userRepo, store, deps.UploadPolicy, deps.Scanner, cfg)
+	archiveController := controllers.NewArchiveController(fileRepo, permissionRepo, store, fileController, cfg.Archive)
 
-	// Handle the two return values from NewFileController
-	fileController, err := controllers.NewFileController(fileRepo, cfg)
+	// Client for imports from remote URLs, refusing internal addresses outside the allowed networks
+	allowedNetworks, err := fetch.ParseNetworks(cfg.Import.AllowedNetworks)
 	if err != nil {
-		log.Fatalf("Could not create file controller: %v", err)
+		log.Fatalf("Invalid IMPORT_ALLOWED_NETWORKS: %v", err)
+	}
+	importClient := fetch.NewClient(fetch.Options{
+		MaxSize:         cfg.Import.MaxSize,
+		Timeout:         cfg.Import.Timeout,
+		MaxRedirects:    int(cfg.Import.MaxRedirects),
+		AllowedNetworks: allowedNetworks,
+	})
+	importController := controllers.NewImportController(importJobRepo, importClient, cfg.Import)
+
+	// Background jobs
+	scheduler.Every(time.Hour, jobs.NewAnonymousUserCleanup(userRepo, fileRepo, store))
+	scheduler.Every(cfg.Scrub.Interval, jobs.NewStorageScrubber(fileRepo, store, int(cfg.Scrub.BatchSize), cfg.Scrub.BytesPerSecond))
+	scheduler.Every(cfg.Reconcile.Interval, jobs.NewStorageReconciler(fileRepo, store, cfg.Reconcile.GracePeriod, cfg.Reconcile.DeleteOrphans))
+	scheduler.Every(cfg.Scanner.RetryInterval, jobs.NewScanRetry(fileRepo, store, deps.Scanner, int(cfg.Scanner.RetryBatchSize)))
+	scheduler.Every(cfg.Thumbnail.Interval, jobs.NewThumbnailGenerator(fileRepo, store, cfg.Thumbnail.Sizes,
+		int(cfg.Thumbnail.BatchSize), cfg.Thumbnail.MaxPixels))
+	scheduler.Every(cfg.Lifecycle.Interval, jobs.NewLifecycleEnforcer(lifecycleRuleRepo, fileRepo, store, cfg.Lifecycle.ColdProvider,
+		int(cfg.Lifecycle.BatchSize), cfg.Lifecycle.BytesPerSecond))
+	scheduler.Every(cfg.Import.Interval, jobs.NewURLImporter(importJobRepo, fileController, importClient, int(cfg.Import.Concurrency), cfg.Import.Timeout))
+
+	// Jobs specific to the storage layers in use, outermost first
+	hot := store
+	if tiered, ok := hot.(*storage.TieredStorage); ok {
+		hot = tiered.Hot()
+	}
+	if migrating, ok := hot.(*storage.MigratingStorage); ok {
+		scheduler.Every(cfg.Migration.Interval, jobs.NewStorageMigration(fileRepo, migrating, cfg.Migration.Source, cfg.StorageProvider,
+			int(cfg.Migration.BatchSize), int(cfg.Migration.Concurrency), cfg.Migration.BytesPerSecond, cfg.Migration.DeleteSource))
+		hot = migrating.Target()
+	}
+	if replicated, ok := hot.(*storage.ReplicatedStorage); ok {
+		scheduler.Every(cfg.Replication.RetryInterval, jobs.NewReplicaRetry(replicated))
 	}
 
 	// Auth routes
-	router.POST("/v1/auth/oauth/register", controllers.RegisterOAuthUser)
-	router.POST("/v1/auth/oauth/login", controllers.LoginOAuthUser)
-	router.POST("/v1/auth/anonymous/register", controllers.RegisterAnonymousUser)
-	router.POST("/v1/auth/logout", controllers.LogoutUser)
+	router.POST("/v1/auth/anonymous/register", authController.RegisterAnonymousUser)
+	router.POST("/v1/auth/anonymous/upgrade", auth.AuthMiddleware(apiKeyRepo), authController.UpgradeAnonymousUser)
+	router.POST("/v1/auth/logout", auth.AuthMiddleware(apiKeyRepo), sessionController.Logout)
 	router.GET("/v1/auth/validate", controllers.ValidateTokenHandler)
+	router.POST("/v1/auth/login", authController.Login)
+	router.POST("/v1/auth/mfa/verify", authController.CompleteMFALogin)
+	router.POST("/v1/auth/oauth/login", authController.OAuthLogin)
+	router.POST("/v1/auth/email/verify", accountController.VerifyEmail)
+	router.POST("/v1/auth/password/forgot", accountController.ForgotPassword)
+	router.POST("/v1/auth/password/reset", accountController.ResetPassword)
+	router.POST("/v1/auth/email/verification", auth.AuthMiddleware(apiKeyRepo), accountController.RequestEmailVerification)
+
+	// MFA enrollment routes (require an authenticated user)
+	mfa := router.Group("/v1/auth/mfa", auth.AuthMiddleware(apiKeyRepo))
+	mfa.POST("/enroll", mfaController.EnrollMFA)                       // Generate a TOTP secret
+	mfa.POST("/enable", mfaController.EnableMFA)                       // Confirm a code and enable MFA
+	mfa.POST("/recovery-codes", mfaController.RegenerateRecoveryCodes) // Replace recovery codes
+	mfa.DELETE("", mfaController.DisableMFA)                           // Disable MFA
+
+	// Session routes (require an authenticated user)
+	sessions := router.Group("/v1/auth/sessions", auth.AuthMiddleware(apiKeyRepo))
+	sessions.GET("", sessionController.ListSessions)         // List the user's active sessions
+	sessions.DELETE("/:id", sessionController.RevokeSession) // Sign a session out
+
+	// API key routes (require an authenticated user)
+	apiKeys := router.Group("/v1/auth/api-keys", auth.AuthMiddleware(apiKeyRepo))
+	apiKeys.POST("", apiKeyController.CreateAPIKey)       // Create a new API key
+	apiKeys.GET("", apiKeyController.ListAPIKeys)         // List the user's API keys
+	apiKeys.DELETE("/:id", apiKeyController.RevokeAPIKey) // Revoke an API key
+
+	// User routes (registration is public, everything else requires an authenticated user)
+	router.POST("/v1/users", userController.CreateUser) // Create a new user
+	users := router.Group("/v1/users", auth.AuthMiddleware(apiKeyRepo))
+	users.GET("/:id", userController.GetUserByID)    // Get a user by ID
+	users.PUT("/:id", userController.UpdateUser)     // Update a user by ID
+	users.DELETE("/:id", userController.DeleteUser)  // Delete a user by ID
+	users.DELETE("/:id/mfa", mfaController.ResetMFA) // Reset a user's MFA (admin only)
+	users.GET("/:id/usage", userController.GetUsage) // Get a user's storage usage and quota
+	users.PUT("/:id/quota", userController.SetQuota) // Override a user's quota (admin only)
+
+	// File routes (require an authenticated user)
+	files := router.Group("/v1/files", auth.AuthMiddleware(apiKeyRepo))
+	files.POST("", fileController.CreateFile)                            // Create a new file
+	files.POST("/batch", fileController.CreateFiles)                     // Upload several files, each created or failing on its own
+	files.POST("/import", importController.CreateImport)                 // Download a file from a URL in the background
+	files.GET("/:id", fileController.GetFileByID)                        // Get a file by ID
+	files.GET("/:id/download", fileController.DownloadFile)              // Download a clean file
+	files.GET("/:id/thumbnail", fileController.GetThumbnail)             // Thumbnail of a clean image
+	files.GET("/:id/image", fileController.TransformImage)               // Resized, cropped or converted clean image
+	files.POST("/:id/share", fileController.ShareFile)                   // Share a clean file with another user
+	files.GET("/:id/archive", archiveController.ListEntries)             // List the entries of a clean ZIP or tar.gz file
+	files.GET("/:id/archive/entry", archiveController.GetEntry)          // Download one entry of a clean archive
+	files.POST("/:id/archive/extract", archiveController.ExtractArchive) // Extract a clean archive into a folder as new files
+	files.PUT("/:id", fileController.UpdateFile)                         // Update a file by ID
+	files.DELETE("/:id", fileController.DeleteFile)                      // Delete a file by ID
+
+	// Archive routes
+	archives := router.Group("/v1/archives", auth.AuthMiddleware(apiKeyRepo))
+	archives.POST("", archiveController.CreateArchive) // Download files and folders as one archive
+
+	// Import routes
+	imports := router.Group("/v1/imports", auth.AuthMiddleware(apiKeyRepo))
+	imports.GET("", importController.ListImports)   // Recent imports from remote URLs
+	imports.GET("/:id", importController.GetImport) // Status and progress of an import
 
-	// User routes
-	router.POST("/v1/users", userController.CreateUser)       // Create a new user
-	router.GET("/v1/users/:id", userController.GetUserByID)   // Get a user by ID
-	router.PUT("/v1/users/:id", userController.UpdateUser)    // Update a user by ID
-	router.DELETE("/v1/users/:id", userController.DeleteUser) // Delete a user by ID
-
-	// File routes
-	router.POST("/v1/files", fileController.CreateFile)       // Create a new file
-	router.GET("/v1/files/:id", fileController.GetFileByID)   // Get a file by ID
-	router.PUT("/v1/files/:id", fileController.UpdateFile)    // Update a file by ID
-	router.DELETE("/v1/files/:id", fileController.DeleteFile) // Delete a file by ID
+	// Admin routes (require the users:admin scope)
+	admin := router.Group("/v1/admin", auth.AuthMiddleware(apiKeyRepo))
+	admin.GET("/files/corrupted", adminController.ListCorruptedFiles)    // Files that failed integrity checks
+	admin.GET("/metrics", adminController.Metrics)                       // Application metrics
+	admin.POST("/lifecycle/rules", lifecycleController.CreateRule)       // Add a lifecycle rule
+	admin.GET("/lifecycle/rules", lifecycleController.ListRules)         // List lifecycle rules
+	admin.DELETE("/lifecycle/rules/:id", lifecycleController.DeleteRule) // Remove a lifecycle rule
+	admin.GET("/lifecycle/report", lifecycleController.Report)           // Dry-run report of what the rules would do
 }
@@ -9,16 +9,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/souvik03-136/Go-Store/internal/jobs"
 )
 
 type Server struct {
 	httpServer *http.Server
+	scheduler  *jobs.Scheduler
 }
 
 // NewServer creates and returns a new HTTP server with routes initialized.
 func NewServer() *Server {
 	router := gin.Default()
-	InitRoutes(router)
+	scheduler := jobs.NewScheduler()
+	InitRoutes(router, scheduler, NewDependencies())
 
 	httpServer := &http.Server{
 		Addr:         ":8080",          // Port the server will run on
@@ -28,11 +31,13 @@ func NewServer() *Server {
 		IdleTimeout:  30 * time.Second,
 	}
 
-	return &Server{httpServer: httpServer}
+	return &Server{httpServer: httpServer, scheduler: scheduler}
 }
 
 // Start begins the server and handles graceful shutdown.
 func (s *Server) Start() error {
+	s.scheduler.Start(context.Background())
+
 	go func() {
 		log.Println("Starting server on port 8080...")
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -41,7 +46,9 @@ func (s *Server) Start() error {
 	}()
 
 	// Graceful shutdown handling
-	return waitForShutdown(s.httpServer)
+	err := waitForShutdown(s.httpServer)
+	s.scheduler.Stop()
+	return err
 }
 
 // waitForShutdown gracefully shuts down the server.
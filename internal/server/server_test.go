@@ -0,0 +1,532 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/souvik03-136/Go-Store/internal/config"
+	"github.com/souvik03-136/Go-Store/internal/jobs"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/server/servertest"
+)
+
+const testPassword = "correct horse battery staple"
+
+// newServer boots the API against in-memory dependencies.
+func newServer(t *testing.T, configure func(cfg *config.Config)) *servertest.Server {
+	t.Helper()
+	s, err := servertest.New(configure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// signUp creates a user and logs them in.
+func signUp(t *testing.T, s *servertest.Server, username string) (*models.User, *servertest.Credentials) {
+	t.Helper()
+	user, err := s.CreateUser(username, username+"@example.com", testPassword, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := s.Login(username, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, creds
+}
+
+// upload sends a file to the single-file upload route.
+func upload(t *testing.T, s *servertest.Server, creds *servertest.Credentials, name string, content []byte) (*models.File, int) {
+	t.Helper()
+	body, contentType, err := servertest.Multipart("file", name, content, url.Values{"path": {"/docs/" + name}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := s.Request(http.MethodPost, "/v1/files", body, creds)
+	req.Header.Set("Content-Type", contentType)
+	recorder := s.Do(req)
+	if recorder.Code != http.StatusCreated {
+		return nil, recorder.Code
+	}
+
+	var file models.File
+	if err := json.Unmarshal(recorder.Body.Bytes(), &file); err != nil {
+		t.Fatal(err)
+	}
+	return &file, recorder.Code
+}
+
+func TestLogin(t *testing.T) {
+	s := newServer(t, nil)
+	user, err := s.CreateUser("alice", "alice@example.com", testPassword, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		scope    string
+		status   int
+	}{
+		{"valid password", "alice", testPassword, "", http.StatusOK},
+		{"narrower scope", "alice", testPassword, "files:read", http.StatusOK},
+		{"wrong password", "alice", "wrong password", "", http.StatusUnauthorized},
+		{"unknown user", "bob", testPassword, "", http.StatusUnauthorized},
+		{"scope the role lacks", "alice", testPassword, "users:admin", http.StatusUnprocessableEntity},
+		{"missing password", "alice", "", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := s.JSON(http.MethodPost, "/v1/auth/login", map[string]string{
+				"username": tt.username,
+				"password": tt.password,
+				"scope":    tt.scope,
+			}, nil)
+			if recorder.Code != tt.status {
+				t.Fatalf("login responded %d, want %d: %s", recorder.Code, tt.status, recorder.Body.String())
+			}
+		})
+	}
+
+	// The issued token authenticates the user it was issued to
+	creds, err := s.Login("alice", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := s.Do(s.Request(http.MethodGet, "/v1/users/"+user.ID, nil, creds))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("reading your own account responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := s.Do(s.Request(http.MethodGet, "/v1/users/"+user.ID, nil, nil)); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("reading an account without a token responded %d, want 401", recorder.Code)
+	}
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	s := newServer(t, nil)
+	owner, creds := signUp(t, s, "alice")
+	_, otherCreds := signUp(t, s, "bob")
+
+	content := []byte("hello from the test harness")
+	file, status := upload(t, s, creds, "hello.txt", content)
+	if file == nil {
+		t.Fatalf("upload responded %d, want 201", status)
+	}
+	if file.OwnerID != owner.ID || file.Size != int64(len(content)) || file.ScanStatus != models.ScanStatusClean {
+		t.Errorf("uploaded file is owned by %s with %d bytes and scan status %s", file.OwnerID, file.Size, file.ScanStatus)
+	}
+
+	// The stored content is what was uploaded
+	record, err := s.Deps.Files.GetFileByID(file.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.Storage.Open(context.Background(), record.StorageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(stored)
+	stored.Close()
+	if err != nil || string(data) != string(content) {
+		t.Errorf("stored content is %q (%v), want %q", data, err, content)
+	}
+
+	recorder := s.Do(s.Request(http.MethodGet, "/v1/files/"+file.ID+"/download", nil, creds))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != string(content) {
+		t.Errorf("download responded %d with %q, want 200 with %q", recorder.Code, recorder.Body.String(), content)
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); disposition != `attachment; filename=hello.txt` {
+		t.Errorf("download was sent with Content-Disposition %q, want an attachment named hello.txt", disposition)
+	}
+
+	// Other users cannot download a file that was not shared with them
+	recorder = s.Do(s.Request(http.MethodGet, "/v1/files/"+file.ID+"/download", nil, otherCreds))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("download by another user responded %d, want 403", recorder.Code)
+	}
+	recorder = s.Do(s.Request(http.MethodGet, "/v1/files/does-not-exist/download", nil, creds))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("download of a missing file responded %d, want 404", recorder.Code)
+	}
+}
+
+func TestMissingScopeIsDenied(t *testing.T) {
+	s := newServer(t, nil)
+	_, creds := signUp(t, s, "alice")
+	file, status := upload(t, s, creds, "notes.txt", []byte("some notes"))
+	if file == nil {
+		t.Fatalf("upload responded %d, want 201", status)
+	}
+
+	recorder := s.JSON(http.MethodPost, "/v1/auth/login", map[string]string{
+		"username": "alice",
+		"password": testPassword,
+		"scope":    "files:read",
+	}, nil)
+	var readOnly servertest.Credentials
+	if err := json.Unmarshal(recorder.Body.Bytes(), &readOnly); err != nil || readOnly.Token == "" {
+		t.Fatalf("read-only login failed with %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	if _, status := upload(t, s, &readOnly, "more.txt", []byte("more notes")); status != http.StatusForbidden {
+		t.Errorf("upload with a read-only token responded %d, want 403", status)
+	}
+	recorder = s.Do(s.Request(http.MethodDelete, "/v1/files/"+file.ID, nil, &readOnly))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("delete with a read-only token responded %d, want 403", recorder.Code)
+	}
+	recorder = s.Do(s.Request(http.MethodGet, "/v1/files/"+file.ID, nil, &readOnly))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("read with a read-only token responded %d, want 200", recorder.Code)
+	}
+}
+
+func TestQuotaRejection(t *testing.T) {
+	s := newServer(t, func(cfg *config.Config) {
+		cfg.Quota.User = models.Quota{MaxBytes: 32, MaxFiles: 10}
+	})
+	owner, creds := signUp(t, s, "alice")
+
+	if file, status := upload(t, s, creds, "first.txt", []byte("twenty bytes of text")); file == nil {
+		t.Fatalf("upload within the quota responded %d, want 201", status)
+	}
+	if _, status := upload(t, s, creds, "second.txt", []byte("twenty more bytes...")); status != http.StatusForbidden {
+		t.Fatalf("upload over the quota responded %d, want 403", status)
+	}
+
+	// The rejected upload is neither stored nor counted
+	usage, err := s.Deps.Usage.GetUsage(owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.BytesUsed != 20 || usage.FileCount != 1 {
+		t.Errorf("usage is %d bytes in %d files, want 20 in 1", usage.BytesUsed, usage.FileCount)
+	}
+	objects, err := s.Storage.List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 {
+		t.Errorf("storage holds %d objects, want 1", len(objects))
+	}
+
+	recorder := s.Do(s.Request(http.MethodGet, "/v1/users/"+owner.ID+"/usage", nil, creds))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("usage responded %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestPendingScanIsRetried(t *testing.T) {
+	s := newServer(t, nil)
+	_, creds := signUp(t, s, "alice")
+
+	// An upload the scanner fails on stays quarantined
+	s.Scanner.Err = errors.New("clamd is unreachable")
+	file, status := upload(t, s, creds, "report.txt", []byte("quarterly report"))
+	if file == nil {
+		t.Fatalf("upload while the scanner is down responded %d, want 201", status)
+	}
+	if file.ScanStatus != models.ScanStatusPending || file.Url != "" {
+		t.Errorf("upload while the scanner is down has scan status %s and url %q, want pending without a url", file.ScanStatus, file.Url)
+	}
+	recorder := s.Do(s.Request(http.MethodGet, "/v1/files/"+file.ID+"/download", nil, creds))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("download of a pending file responded %d, want 403", recorder.Code)
+	}
+
+	retry := jobs.NewScanRetry(s.Deps.Files, s.Storage, s.Scanner, 10)
+	if err := retry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if record, _ := s.Deps.Files.GetFileByID(file.ID); record.ScanStatus != models.ScanStatusPending || record.ScannedAt == nil {
+		t.Errorf("failed retry left scan status %s, want pending with the attempt recorded", record.ScanStatus)
+	}
+
+	// Once the scanner is back the retry job releases the file
+	s.Scanner.Err = nil
+	if err := retry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if record, _ := s.Deps.Files.GetFileByID(file.ID); record.ScanStatus != models.ScanStatusClean {
+		t.Errorf("retry left scan status %s, want clean", record.ScanStatus)
+	}
+	recorder = s.Do(s.Request(http.MethodGet, "/v1/files/"+file.ID+"/download", nil, creds))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("download after the retry responded %d, want 200", recorder.Code)
+	}
+}
+
+// emailedToken returns the token in the link of the last email sent.
+func emailedToken(t *testing.T, s *servertest.Server) string {
+	t.Helper()
+	sent := s.Mailer.Sent()
+	if len(sent) == 0 {
+		t.Fatal("no email was sent")
+	}
+	match := regexp.MustCompile(`token=(\S+)`).FindStringSubmatch(sent[len(sent)-1].Body)
+	if match == nil {
+		t.Fatalf("email has no token link: %s", sent[len(sent)-1].Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerificationTokenIsBoundToEmail(t *testing.T) {
+	s := newServer(t, nil)
+	user, creds := signUp(t, s, "alice")
+
+	recorder := s.JSON(http.MethodPost, "/v1/auth/email/verification", nil, creds)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("requesting verification responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	token := emailedToken(t, s)
+
+	// Changing the address before redeeming the token must not verify the new one
+	recorder = s.JSON(http.MethodPut, "/v1/users/"+user.ID, map[string]string{"username": "alice", "email": "mallory@example.com"}, creds)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("changing the email responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	recorder = s.JSON(http.MethodPost, "/v1/auth/email/verify", map[string]string{"token": token}, nil)
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("redeeming a token sent to the old address responded %d, want 422", recorder.Code)
+	}
+	if record, _ := s.Deps.Users.GetUserByID(user.ID); record.EmailVerifiedAt != nil {
+		t.Error("a token sent to the old address verified the new one")
+	}
+
+	// A token sent to the current address verifies it
+	if recorder := s.JSON(http.MethodPost, "/v1/auth/email/verification", nil, creds); recorder.Code != http.StatusOK {
+		t.Fatalf("requesting verification responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	recorder = s.JSON(http.MethodPost, "/v1/auth/email/verify", map[string]string{"token": emailedToken(t, s)}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("redeeming a token sent to the current address responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if record, _ := s.Deps.Users.GetUserByID(user.ID); record.EmailVerifiedAt == nil {
+		t.Error("a token sent to the current address did not verify it")
+	}
+}
+
+func TestResetPasswordKeepsTokenForInvalidPassword(t *testing.T) {
+	s := newServer(t, nil)
+	signUp(t, s, "alice")
+
+	recorder := s.JSON(http.MethodPost, "/v1/auth/password/forgot", map[string]string{"email": "alice@example.com"}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("requesting a reset responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	token := emailedToken(t, s)
+
+	// bcrypt cannot hash more than 72 bytes; the token must survive the rejection
+	recorder = s.JSON(http.MethodPost, "/v1/auth/password/reset", map[string]string{"token": token, "password": strings.Repeat("x", 73)}, nil)
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reset with a password too long to hash responded %d, want 422", recorder.Code)
+	}
+	recorder = s.JSON(http.MethodPost, "/v1/auth/password/reset", map[string]string{"token": token, "password": "a new passphrase"}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("reset after a rejected password responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if _, err := s.Login("alice", "a new passphrase"); err != nil {
+		t.Errorf("login with the new password failed: %v", err)
+	}
+}
+
+func TestFolderArchiveSkipsDeletedFiles(t *testing.T) {
+	s := newServer(t, nil)
+	_, creds := signUp(t, s, "alice")
+
+	kept, _ := upload(t, s, creds, "kept.txt", []byte("still here"))
+	deleting, _ := upload(t, s, creds, "deleting.txt", []byte("on its way out"))
+	if kept == nil || deleting == nil {
+		t.Fatal("upload failed")
+	}
+	if err := s.Deps.Files.MarkFileDeleting(deleting.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := s.JSON(http.MethodPost, "/v1/archives", map[string]interface{}{"folders": []string{"/docs"}}, creds)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("archive responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range archive.File {
+		names = append(names, entry.Name)
+	}
+	if len(names) != 1 || names[0] != "docs/kept.txt" {
+		t.Errorf("archive holds %v, want only docs/kept.txt", names)
+	}
+}
+
+func TestCreateAPIKeyForMissingUser(t *testing.T) {
+	s := newServer(t, nil)
+	user, creds := signUp(t, s, "alice")
+
+	recorder := s.JSON(http.MethodPost, "/v1/auth/api-keys", map[string]string{"name": "ci"}, creds)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("creating an API key responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// A token outliving its account must not mint keys with a guessed role
+	if err := s.Deps.Users.DeleteUser(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	recorder = s.JSON(http.MethodPost, "/v1/auth/api-keys", map[string]string{"name": "ci"}, creds)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("creating an API key for a deleted account responded %d, want 403", recorder.Code)
+	}
+}
+
+// newOAuthProvider serves the signing key of an OpenID Connect provider and returns its
+// configuration with a function that issues ID tokens for a subject.
+func newOAuthProvider(t *testing.T) (config.OAuthProviderConfig, func(subject, email string) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "key-1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(jwks.Close)
+
+	provider := config.OAuthProviderConfig{Name: "example", Issuer: "https://id.example.com", ClientID: "go-store", JWKSURL: jwks.URL}
+	issue := func(subject, email string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            provider.Issuer,
+			"sub":            subject,
+			"aud":            provider.ClientID,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"email":          email,
+			"email_verified": true,
+		})
+		token.Header["kid"] = "key-1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	return provider, issue
+}
+
+// registerAnonymous creates an anonymous user and returns their credentials.
+func registerAnonymous(t *testing.T, s *servertest.Server) *servertest.Credentials {
+	t.Helper()
+	recorder := s.JSON(http.MethodPost, "/v1/auth/anonymous/register", nil, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("registering an anonymous user responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var creds servertest.Credentials
+	if err := json.Unmarshal(recorder.Body.Bytes(), &creds); err != nil {
+		t.Fatal(err)
+	}
+	return &creds
+}
+
+func TestUpgradeAnonymousUserWithOAuthIdentity(t *testing.T) {
+	provider, issue := newOAuthProvider(t)
+	s := newServer(t, func(cfg *config.Config) {
+		cfg.OAuth = []config.OAuthProviderConfig{provider}
+	})
+	idToken := issue("subject-1", "alice@example.com")
+
+	anonymous := registerAnonymous(t, s)
+	file, code := upload(t, s, anonymous, "notes.txt", []byte("kept across the upgrade"))
+	if code != http.StatusCreated {
+		t.Fatalf("anonymous upload responded %d", code)
+	}
+
+	// A password and an identity cannot both be attached
+	recorder := s.JSON(http.MethodPost, "/v1/auth/anonymous/upgrade", map[string]string{
+		"username": "alice", "password": testPassword, "provider": "example", "id_token": idToken,
+	}, anonymous)
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("upgrading with a password and an identity responded %d, want 422", recorder.Code)
+	}
+	recorder = s.JSON(http.MethodPost, "/v1/auth/anonymous/upgrade", map[string]string{
+		"username": "alice", "provider": "example", "id_token": issue("subject-1", "alice@example.com") + "x",
+	}, anonymous)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("upgrading with a forged ID token responded %d, want 401", recorder.Code)
+	}
+
+	recorder = s.JSON(http.MethodPost, "/v1/auth/anonymous/upgrade", map[string]string{
+		"username": "alice", "provider": "example", "id_token": idToken,
+	}, anonymous)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("upgrading with an identity responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	user, err := s.Deps.Users.GetUserByID(file.OwnerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsAnonymous || user.Username != "alice" || user.Email != "alice@example.com" || user.EmailVerifiedAt == nil {
+		t.Errorf("upgraded user is %+v, want registered alice with the verified address of the identity", user)
+	}
+
+	// The identity signs in to the upgraded account, which still owns its files
+	recorder = s.JSON(http.MethodPost, "/v1/auth/oauth/login", map[string]string{"provider": "example", "id_token": idToken}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("OAuth login responded %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var creds servertest.Credentials
+	if err := json.Unmarshal(recorder.Body.Bytes(), &creds); err != nil {
+		t.Fatal(err)
+	}
+	if recorder := s.Do(s.Request(http.MethodGet, "/v1/files/"+file.ID, nil, &creds)); recorder.Code != http.StatusOK {
+		t.Errorf("reading the file after the upgrade responded %d", recorder.Code)
+	}
+
+	// The account has no password to sign in with
+	if _, err := s.Login("alice", testPassword); err == nil {
+		t.Error("password login succeeded for an account without a password")
+	}
+
+	// An identity belongs to one account only
+	recorder = s.JSON(http.MethodPost, "/v1/auth/anonymous/upgrade", map[string]string{
+		"username": "mallory", "email": "mallory@example.com", "provider": "example", "id_token": idToken,
+	}, registerAnonymous(t, s))
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("linking an identity twice responded %d, want 409", recorder.Code)
+	}
+
+	// Identities that are not linked do not sign in
+	recorder = s.JSON(http.MethodPost, "/v1/auth/oauth/login", map[string]string{
+		"provider": "example", "id_token": issue("subject-2", "bob@example.com"),
+	}, nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("OAuth login with an unlinked identity responded %d, want 401", recorder.Code)
+	}
+}
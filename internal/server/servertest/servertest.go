@@ -0,0 +1,215 @@
+// Package servertest boots the full API router against in-memory repositories, storage, mailer
+// and scanner, so end-to-end HTTP behaviour can be exercised without a database or network.
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/config"
+	"github.com/souvik03-136/Go-Store/internal/jobs"
+	"github.com/souvik03-136/Go-Store/internal/mailer"
+	"github.com/souvik03-136/Go-Store/internal/models"
+	"github.com/souvik03-136/Go-Store/internal/policy"
+	"github.com/souvik03-136/Go-Store/internal/repository/memory"
+	"github.com/souvik03-136/Go-Store/internal/scanner"
+	"github.com/souvik03-136/Go-Store/internal/server"
+	"github.com/souvik03-136/Go-Store/internal/storage"
+)
+
+// Server is the API wired to in-memory dependencies. The fields give tests direct access to
+// the state behind the routes, to set up fixtures and check side effects.
+type Server struct {
+	Router    *gin.Engine
+	Scheduler *jobs.Scheduler // Jobs are registered but not started
+	Deps      *server.Dependencies
+	DB        *memory.DB
+	Storage   *storage.MemoryStorage
+	Mailer    *mailer.FakeMailer
+	Scanner   *scanner.FakeScanner // Reports every file clean unless its Result is changed
+}
+
+// Credentials are a token issued by the login route, with the salt needed to validate it.
+type Credentials struct {
+	Token string
+	Salt  string
+}
+
+// New builds a Server from the environment's configuration, with the storage, mail and
+// scanner providers replaced by in-memory ones. configure, when given, can adjust the
+// configuration before the routes are built.
+func New(configure func(cfg *config.Config)) (*Server, error) {
+	if os.Getenv("JWT_SECRET_KEY") == "" {
+		os.Setenv("JWT_SECRET_KEY", "servertest-secret")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	cfg.StorageProvider = "memory"
+	cfg.Migration.Source = ""
+	cfg.Lifecycle.ColdProvider = ""
+	if configure != nil {
+		configure(cfg)
+	}
+
+	uploadPolicy, err := policy.NewPolicy(cfg.Upload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload policy: %v", err)
+	}
+
+	db := memory.NewDB()
+	store := storage.NewMemoryStorage()
+	mail := mailer.NewFakeMailer(nil)
+	fileScanner := scanner.NewFakeScanner(scanner.Result{Clean: true}, nil)
+
+	deps := &server.Dependencies{
+		Config:         cfg,
+		Users:          memory.NewUserRepository(db),
+		Files:          memory.NewFileRepository(db),
+		Permissions:    memory.NewPermissionRepository(db),
+		APIKeys:        memory.NewAPIKeyRepository(db),
+		MFA:            memory.NewMFARepository(db),
+		UserTokens:     memory.NewUserTokenRepository(db),
+		Identities:     memory.NewOAuthIdentityRepository(db),
+		Sessions:       memory.NewSessionRepository(db),
+		Usage:          memory.NewUsageRepository(db),
+		LifecycleRules: memory.NewLifecycleRuleRepository(db),
+		ImportJobs:     memory.NewImportJobRepository(db),
+		Storage:        store,
+		Mailer:         mail,
+		Scanner:        fileScanner,
+		UploadPolicy:   uploadPolicy,
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	scheduler := jobs.NewScheduler()
+	server.InitRoutes(router, scheduler, deps)
+
+	return &Server{
+		Router:    router,
+		Scheduler: scheduler,
+		Deps:      deps,
+		DB:        db,
+		Storage:   store,
+		Mailer:    mail,
+		Scanner:   fileScanner,
+	}, nil
+}
+
+// Do sends a request through the router and returns the recorded response.
+func (s *Server) Do(req *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	s.Router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// Request builds a request, authorized with creds when they are given.
+func (s *Server) Request(method, path string, body io.Reader, creds *Credentials) *http.Request {
+	req := httptest.NewRequest(method, path, body)
+	if creds != nil {
+		creds.Authorize(req)
+	}
+	return req
+}
+
+// JSON sends payload encoded as JSON, authorized with creds when they are given.
+func (s *Server) JSON(method, path string, payload interface{}, creds *Credentials) *httptest.ResponseRecorder {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			panic(fmt.Sprintf("servertest: failed to encode request payload: %v", err))
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req := s.Request(method, path, body, creds)
+	req.Header.Set("Content-Type", "application/json")
+	return s.Do(req)
+}
+
+// CreateUser stores a user with the given role directly, bypassing registration.
+func (s *Server) CreateUser(username, email, password, role string) (*models.User, error) {
+	user, err := models.NewUser(uuid.New().String(), username, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" {
+		user.Role = role
+	}
+	if err := s.Deps.Users.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login signs a user without MFA in through the login route.
+func (s *Server) Login(username, password string) (*Credentials, error) {
+	recorder := s.JSON(http.MethodPost, "/v1/auth/login", map[string]string{
+		"username": username,
+		"password": password,
+	}, nil)
+	if recorder.Code != http.StatusOK {
+		return nil, fmt.Errorf("login failed with status %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+		Salt  string `json:"salt"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode login response: %v", err)
+	}
+	if resp.Token == "" {
+		return nil, fmt.Errorf("login did not issue a token: %s", recorder.Body.String())
+	}
+	return &Credentials{Token: resp.Token, Salt: resp.Salt}, nil
+}
+
+// Authorize adds the bearer token to a request and its salt to the query string.
+func (c *Credentials) Authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	query := req.URL.Query()
+	query.Set("salt", c.Salt)
+	req.URL.RawQuery = query.Encode()
+}
+
+// Multipart builds a multipart/form-data body with one file field and any extra fields, and
+// returns it with its content type.
+func Multipart(field, filename string, content []byte, fields url.Values) (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, values := range fields {
+		for _, value := range values {
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return &body, writer.FormDataContentType(), nil
+}
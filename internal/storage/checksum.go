@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// ErrChecksumMismatch is returned when content read back does not match its recorded checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// castagnoli is the CRC32C table used by Google Cloud Storage.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksums identify an object's content. SHA-256 is the checksum the application trusts;
+// MD5 and CRC32C are the digests S3 and GCS compute natively, so the backends can verify
+// uploads against them.
+type Checksums struct {
+	SHA256 string // Hex encoded
+	MD5    string // Hex encoded
+	CRC32C uint32
+}
+
+// ComputeChecksums reads r to the end and returns its checksums and length.
+func ComputeChecksums(r io.Reader) (Checksums, int64, error) {
+	sha := sha256.New()
+	md := md5.New()
+	crc := crc32.New(castagnoli)
+
+	n, err := io.Copy(io.MultiWriter(sha, md, crc), r)
+	if err != nil {
+		return Checksums{}, n, err
+	}
+
+	return Checksums{
+		SHA256: hex.EncodeToString(sha.Sum(nil)),
+		MD5:    hex.EncodeToString(md.Sum(nil)),
+		CRC32C: crc.Sum32(),
+	}, n, nil
+}
+
+// verifyingReader hashes content as it is read and fails the final read on a mismatch.
+type verifyingReader struct {
+	r        io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+// NewVerifyingReader wraps r so the SHA-256 of everything read is compared with the expected
+// hex digest once r is exhausted. A mismatch is reported as ErrChecksumMismatch in place of io.EOF.
+func NewVerifyingReader(r io.Reader, expectedSHA256 string) io.Reader {
+	return &verifyingReader{r: r, hash: sha256.New(), expected: expectedSHA256}
+}
+
+// Read implements io.Reader
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.hash.Sum(nil)) != v.expected {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
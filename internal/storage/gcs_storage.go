@@ -2,52 +2,207 @@ package storage
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"mime/multipart"
+	"hash/crc32"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"cloud.google.com/go/storage"
+	"github.com/souvik03-136/Go-Store/internal/config"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
 // GC3Storage struct to hold the Google Cloud Storage client and bucket details
 type GC3Storage struct {
-	client *storage.Client
-	bucket string
+	client       *storage.Client
+	bucket       string
+	chunkSize    int
+	emulatorHost string
 }
 
-// NewGC3Storage initializes a new Google Cloud Storage client
-func NewGC3Storage(ctx context.Context, credentialsFile, bucket string) (*GC3Storage, error) {
-	client, err := storage.NewClient(ctx, option.WithCredentialsFile(credentialsFile))
+// NewGC3Storage initializes a new Google Cloud Storage client. Without a credentials file the
+// application default credentials are used. When STORAGE_EMULATOR_HOST is set the client talks
+// to that emulator, such as fake-gcs-server, without authenticating.
+func NewGC3Storage(ctx context.Context, cfg config.GoogleCloudConfig) (*GC3Storage, error) {
+	var opts []option.ClientOption
+	if cfg.CredentialsKey != "" && cfg.EmulatorHost == "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsKey))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GCS client: %v", err)
 	}
-	return &GC3Storage{client: client, bucket: bucket}, nil
+	return &GC3Storage{client: client, bucket: cfg.BucketName, chunkSize: int(cfg.ChunkSize), emulatorHost: cfg.EmulatorHost}, nil
 }
 
-// UploadFile uploads a file to Google Cloud Storage
-func (g *GC3Storage) UploadFile(ctx context.Context, file *multipart.FileHeader, destination string) (string, error) {
-	f, err := file.Open()
-	if err != nil {
-		return "", err
+// Put streams content read from r to Google Cloud Storage in chunks. A known CRC32C is sent with
+// the upload so GCS rejects altered content; either way the CRC32C and size GCS reports for the
+// stored object are compared with what was read, and a mismatched object is deleted.
+func (g *GC3Storage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	sums := opts.Checksums
+	if sums.SHA256 != "" {
+		r = NewVerifyingReader(r, sums.SHA256)
 	}
-	defer f.Close()
 
-	wc := g.client.Bucket(g.bucket).Object(destination).NewWriter(ctx)
-	if _, err = wc.Write([]byte{}); err != nil {
-		return "", err
+	// Cancelling the context is the only way to abandon a write without committing the object
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	object := g.client.Bucket(g.bucket).Object(key)
+	wc := object.NewWriter(writeCtx)
+	wc.ChunkSize = g.chunkSize
+	wc.ContentType = opts.ContentType
+	wc.Metadata = opts.Metadata
+	// The digests are computed together, so an MD5 means the CRC32C is known too, even if it is zero
+	if sums.MD5 != "" {
+		wc.CRC32C = sums.CRC32C
+		wc.SendCRC32C = true
+	}
+
+	crc := crc32.New(castagnoli)
+	n, err := io.Copy(wc, io.TeeReader(r, crc))
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to upload file to GCS: %v", err)
 	}
 	if err := wc.Close(); err != nil {
-		return "", err
+		return ObjectInfo{}, fmt.Errorf("failed to upload file to GCS: %v", err)
+	}
+
+	attrs := wc.Attrs()
+	if attrs.CRC32C != crc.Sum32() || attrs.Size != n || (opts.Size >= 0 && n != opts.Size) {
+		if err := object.Delete(ctx); err != nil {
+			log.Printf("Failed to remove mismatched GCS object %s: %v", key, err)
+		}
+		return ObjectInfo{}, fmt.Errorf("failed to upload file to GCS: %w", ErrChecksumMismatch)
+	}
+
+	info := gcsObjectInfo(attrs)
+	info.Checksums.SHA256 = sums.SHA256
+	info.URL = g.objectURL(key)
+	return info, nil
+}
+
+// objectURL builds an object's URL, pointing at the emulator's download endpoint when one is used.
+func (g *GC3Storage) objectURL(key string) string {
+	if g.emulatorHost == "" {
+		return fmt.Sprintf("https://storage.googleapis.com/%s/%s", g.bucket, key)
+	}
+
+	host := g.emulatorHost
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
 	}
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", strings.TrimSuffix(host, "/"), g.bucket, url.PathEscape(key))
+}
 
-	fileURL := fmt.Sprintf("https://storage.googleapis.com/%s/%s", g.bucket, destination)
-	return fileURL, nil
+// Open streams a file from Google Cloud Storage. The client verifies the object's CRC32C
+// when it is read to the end.
+func (g *GC3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := g.client.Bucket(g.bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from GCS: %w", gcsError(err))
+	}
+	return r, nil
+}
+
+// Stat describes an object in Google Cloud Storage
+func (g *GC3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	attrs, err := g.client.Bucket(g.bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat file in GCS: %w", gcsError(err))
+	}
+	return gcsObjectInfo(attrs), nil
+}
+
+// Exists reports whether an object is stored in Google Cloud Storage
+func (g *GC3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	return exists(g.Stat(ctx, key))
+}
+
+// Copy duplicates an object within the bucket on the server side
+func (g *GC3Storage) Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error) {
+	bucket := g.client.Bucket(g.bucket)
+	attrs, err := bucket.Object(dstKey).CopierFrom(bucket.Object(srcKey)).Run(ctx)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to copy file in GCS: %w", gcsError(err))
+	}
+
+	info := gcsObjectInfo(attrs)
+	info.URL = g.objectURL(dstKey)
+	return info, nil
+}
+
+// SetStorageClass moves an object to another Google Cloud Storage class by rewriting it in place
+func (g *GC3Storage) SetStorageClass(ctx context.Context, filePath, class string) error {
+	object := g.client.Bucket(g.bucket).Object(filePath)
+	copier := object.CopierFrom(object)
+	copier.StorageClass = class
+	if _, err := copier.Run(ctx); err != nil {
+		return fmt.Errorf("failed to change storage class in GCS: %v", err)
+	}
+	return nil
 }
 
 // DeleteFile deletes a file from Google Cloud Storage
-func (g *GC3Storage) DeleteFile(ctx context.Context, filePath string) error {
-	if err := g.client.Bucket(g.bucket).Object(filePath).Delete(ctx); err != nil {
+func (g *GC3Storage) DeleteFile(ctx context.Context, key string) error {
+	err := g.client.Bucket(g.bucket).Object(key).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
 		return fmt.Errorf("failed to delete file from GCS: %v", err)
 	}
 	return nil
 }
+
+// List lists the objects in the Google Cloud Storage bucket under a prefix
+func (g *GC3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	it := g.client.Bucket(g.bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files in GCS: %v", err)
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          attrs.Name,
+			Size:         attrs.Size,
+			LastModified: attrs.Updated,
+		})
+	}
+	return objects, nil
+}
+
+// gcsObjectInfo describes an object from its attributes. Composite objects have no MD5, and
+// then their CRC32C is not reported either, since a zero CRC32C cannot be told from a missing one.
+func gcsObjectInfo(attrs *storage.ObjectAttrs) ObjectInfo {
+	info := ObjectInfo{
+		Key:          attrs.Name,
+		Size:         attrs.Size,
+		LastModified: attrs.Updated,
+		ContentType:  attrs.ContentType,
+		ETag:         attrs.Etag,
+		Version:      strconv.FormatInt(attrs.Generation, 10),
+		Metadata:     attrs.Metadata,
+	}
+	if len(attrs.MD5) > 0 {
+		info.Checksums.MD5 = hex.EncodeToString(attrs.MD5)
+		info.Checksums.CRC32C = attrs.CRC32C
+	}
+	return info
+}
+
+// gcsError maps the error GCS returns for missing objects to ErrObjectNotFound
+func gcsError(err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return err
+}
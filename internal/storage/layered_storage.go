@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// layeredStorage writes new objects to an upper backend and reads objects from it, falling back
+// to a lower backend for objects that are only stored there. Deletes remove both copies.
+type layeredStorage struct {
+	upper Storage
+	lower Storage
+}
+
+// Put stores content on the upper backend
+func (l *layeredStorage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	return l.upper.Put(ctx, key, r, opts)
+}
+
+// Open streams an object from the upper backend, or from the lower one if it is only stored there
+func (l *layeredStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := l.upper.Open(ctx, key)
+	if err == nil {
+		return rc, nil
+	}
+
+	rc, lowerErr := l.lower.Open(ctx, key)
+	if lowerErr != nil {
+		return nil, fmt.Errorf("%v; %w", err, lowerErr)
+	}
+	return rc, nil
+}
+
+// Stat describes an object on the upper backend, or on the lower one if it is only stored there
+func (l *layeredStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	info, err := l.upper.Stat(ctx, key)
+	if !errors.Is(err, ErrObjectNotFound) {
+		return info, err
+	}
+	return l.lower.Stat(ctx, key)
+}
+
+// Exists reports whether an object is stored on either backend
+func (l *layeredStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return exists(l.Stat(ctx, key))
+}
+
+// Copy duplicates an object onto the upper backend, where all new objects are written. An object
+// only stored on the lower backend is streamed across.
+func (l *layeredStorage) Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error) {
+	info, err := l.upper.Copy(ctx, srcKey, dstKey)
+	if !errors.Is(err, ErrObjectNotFound) {
+		return info, err
+	}
+
+	src, err := l.lower.Stat(ctx, srcKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	rc, err := l.lower.Open(ctx, srcKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer rc.Close()
+
+	return l.upper.Put(ctx, dstKey, rc, PutOptions{Size: src.Size, ContentType: src.ContentType, Metadata: src.Metadata})
+}
+
+// DeleteFile deletes an object from both backends
+func (l *layeredStorage) DeleteFile(ctx context.Context, key string) error {
+	if err := l.upper.DeleteFile(ctx, key); err != nil {
+		return err
+	}
+	return l.lower.DeleteFile(ctx, key)
+}
+
+// List lists the objects on either backend, preferring the upper backend's copy of objects on both
+func (l *layeredStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objects, err := l.upper.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	lowerObjects, err := l.lower.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		seen[object.Key] = true
+	}
+	for _, object := range lowerObjects {
+		if !seen[object.Key] {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// MigratingStorage serves a deployment while its objects are moved from one backend to another.
+// New objects are written to the target. Reads try the target first and fall back to the source
+// for objects that have not been copied yet, and deletes remove both copies, so the API keeps
+// working for every file at every point of the migration.
+type MigratingStorage struct {
+	layeredStorage
+}
+
+// NewMigratingStorage creates a new instance of MigratingStorage.
+func NewMigratingStorage(source, target Storage) *MigratingStorage {
+	return &MigratingStorage{layeredStorage{upper: target, lower: source}}
+}
+
+// Source returns the backend objects are being moved from.
+func (m *MigratingStorage) Source() Storage {
+	return m.lower
+}
+
+// Target returns the backend objects are being moved to.
+func (m *MigratingStorage) Target() Storage {
+	return m.upper
+}
+
+// TieredStorage pairs the hot backend new objects are written to with a cheaper cold backend
+// that lifecycle rules move old objects to. Cold objects are read through it like any other.
+type TieredStorage struct {
+	layeredStorage
+}
+
+// NewTieredStorage creates a new instance of TieredStorage.
+func NewTieredStorage(hot, cold Storage) *TieredStorage {
+	return &TieredStorage{layeredStorage{upper: hot, lower: cold}}
+}
+
+// Hot returns the backend new objects are written to.
+func (t *TieredStorage) Hot() Storage {
+	return t.upper
+}
+
+// Cold returns the backend objects are moved to when they cool down.
+func (t *TieredStorage) Cold() Storage {
+	return t.lower
+}
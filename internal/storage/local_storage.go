@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tempPrefix marks files that are still being written, so List skips them.
+const tempPrefix = ".tmp-"
+
+// LocalStorage keeps objects as files under a directory, e.g. a disk or network mount
+// used as a replica of a cloud bucket.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage creates the directory if needed. Object URLs are built from baseURL,
+// or are file:// URLs when it is empty.
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	if dir == "" {
+		return nil, errors.New("local storage directory is not set")
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create local storage directory: %v", err)
+	}
+	return &LocalStorage{dir: abs, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Put writes content read from r to a temporary file and renames it into place, so a
+// failed or mismatched write never leaves a partial object behind. The content type and
+// metadata are not stored; Stat guesses the content type from the key's extension.
+func (l *LocalStorage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to local storage: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*")
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to local storage: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if opts.Checksums.SHA256 != "" {
+		r = NewVerifyingReader(r, opts.Checksums.SHA256)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to local storage: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to local storage: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to local storage: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to local storage: %v", err)
+	}
+
+	info, err := l.Stat(ctx, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info.Checksums = opts.Checksums
+	info.URL = l.objectURL(key, path)
+	return info, nil
+}
+
+// objectURL builds the URL of the file an object is stored in
+func (l *LocalStorage) objectURL(key, path string) string {
+	if l.baseURL == "" {
+		return "file://" + filepath.ToSlash(path)
+	}
+	return l.baseURL + "/" + key
+}
+
+// Open streams a file from the local disk
+func (l *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from local storage: %w", localError(err))
+	}
+	return f, nil
+}
+
+// Stat describes a file on the local disk. The ETag is derived from the modification time and size.
+func (l *LocalStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat file in local storage: %w", localError(err))
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, fmt.Errorf("failed to stat file in local storage: %w: %s is a directory", ErrObjectNotFound, key)
+	}
+
+	return ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+		ContentType:  mime.TypeByExtension(filepath.Ext(path)),
+		ETag:         fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()),
+	}, nil
+}
+
+// Exists reports whether a file is stored on the local disk
+func (l *LocalStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return exists(l.Stat(ctx, key))
+}
+
+// Copy duplicates a file on the local disk
+func (l *LocalStorage) Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error) {
+	rc, err := l.Open(ctx, srcKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer rc.Close()
+
+	return l.Put(ctx, dstKey, rc, PutOptions{Size: -1})
+}
+
+// DeleteFile deletes a file from the local disk
+func (l *LocalStorage) DeleteFile(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file from local storage: %v", err)
+	}
+	return nil
+}
+
+// List lists the files on the local disk under a prefix
+func (l *LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), tempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in local storage: %v", err)
+	}
+	return objects, nil
+}
+
+// path maps an object key to a file inside the storage directory, rejecting keys that
+// would escape it.
+func (l *LocalStorage) path(key string) (string, error) {
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(l.dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return path, nil
+}
+
+// localError maps the error for missing files to ErrObjectNotFound
+func localError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return err
+}
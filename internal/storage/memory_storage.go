@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStorage keeps objects in memory, for tests and trying the API out without a bucket.
+// Everything stored is lost when the process exits.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	version int64
+}
+
+// memoryObject is an object's content and description. The content is never modified once
+// stored, so readers can share it.
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// NewMemoryStorage creates a new, empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: map[string]memoryObject{}}
+}
+
+// Put stores content read from r after checking it against every checksum and the size given
+func (m *MemoryStorage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to memory storage: %v", err)
+	}
+
+	sums, n, err := ComputeChecksums(bytes.NewReader(data))
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to memory storage: %v", err)
+	}
+	expected := opts.Checksums
+	if (expected.SHA256 != "" && !strings.EqualFold(expected.SHA256, sums.SHA256)) ||
+		(expected.MD5 != "" && (!strings.EqualFold(expected.MD5, sums.MD5) || expected.CRC32C != sums.CRC32C)) ||
+		(opts.Size >= 0 && opts.Size != n) {
+		return ObjectInfo{}, fmt.Errorf("failed to write file to memory storage: %w", ErrChecksumMismatch)
+	}
+
+	return m.store(key, data, opts.ContentType, opts.Metadata, sums), nil
+}
+
+// store records an object under key, replacing any object already there.
+func (m *MemoryStorage) store(key string, data []byte, contentType string, metadata map[string]string, sums Checksums) ObjectInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.version++
+	info := ObjectInfo{
+		Key:          key,
+		Size:         int64(len(data)),
+		LastModified: time.Now(),
+		ContentType:  contentType,
+		ETag:         sums.MD5,
+		Checksums:    sums,
+		Version:      strconv.FormatInt(m.version, 10),
+		Metadata:     copyMetadata(metadata),
+	}
+	m.objects[key] = memoryObject{data: data, info: info}
+
+	info.URL = "memory://" + key
+	return info
+}
+
+// Open streams an object from memory
+func (m *MemoryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := m.get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from memory storage: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+// Stat describes an object in memory
+func (m *MemoryStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	object, err := m.get(key)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat file in memory storage: %w", err)
+	}
+	info := object.info
+	info.Metadata = copyMetadata(info.Metadata)
+	return info, nil
+}
+
+// Exists reports whether an object is stored in memory
+func (m *MemoryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return exists(m.Stat(ctx, key))
+}
+
+// Copy duplicates an object in memory
+func (m *MemoryStorage) Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error) {
+	object, err := m.get(srcKey)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to copy file in memory storage: %w", err)
+	}
+	return m.store(dstKey, object.data, object.info.ContentType, object.info.Metadata, object.info.Checksums), nil
+}
+
+// DeleteFile deletes an object from memory
+func (m *MemoryStorage) DeleteFile(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.objects, key)
+	return nil
+}
+
+// List lists the objects in memory under a prefix, in key order
+func (m *MemoryStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var objects []ObjectInfo
+	for key, object := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, ObjectInfo{Key: key, Size: object.info.Size, LastModified: object.info.LastModified})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// get looks up an object by key.
+func (m *MemoryStorage) get(key string) (memoryObject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, ok := m.objects[key]
+	if !ok {
+		return memoryObject{}, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return object, nil
+}
+
+// copyMetadata copies user metadata, so callers cannot change a stored object's copy.
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+	return c
+}
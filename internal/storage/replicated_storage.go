@@ -0,0 +1,418 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/souvik03-136/Go-Store/internal/metrics"
+	"github.com/souvik03-136/Go-Store/internal/models"
+)
+
+// How long a replica that failed is tried after the others, and how retries of missed
+// operations back off.
+const (
+	replicaCooldown   = 30 * time.Second
+	replicaRetryDelay = time.Minute
+	replicaRetryMax   = 6 * time.Hour
+)
+
+// Replica is one named backend of a ReplicatedStorage. The name identifies it in the retry queue.
+type Replica struct {
+	Name    string
+	Storage Storage
+}
+
+// ReplicaQueue persists operations that a replica missed so they survive restarts.
+type ReplicaQueue interface {
+	EnqueueReplicaTask(task *models.ReplicaTask) error
+	ListDueReplicaTasks(now time.Time, limit int) ([]*models.ReplicaTask, error)
+	CompleteReplicaTask(task *models.ReplicaTask) error
+	RescheduleReplicaTask(task *models.ReplicaTask) error
+	CancelReplicaTasks(storageKey string) error
+}
+
+// ReplicatedStorage writes every object to several backends for disaster recovery. A write
+// succeeds once the write quorum of replicas has stored it; the replicas that failed are queued
+// and brought up to date by RetryPending. Reads use the first replica, in configured order, that
+// has not failed recently and fall back to the others.
+type ReplicatedStorage struct {
+	replicas []Replica
+	quorum   int
+	queue    ReplicaQueue
+
+	mu        sync.Mutex
+	unhealthy map[string]time.Time // Replica name to the end of its cooldown
+}
+
+// NewReplicatedStorage creates a composite over the replicas. A quorum of zero requires every
+// replica to accept each write.
+func NewReplicatedStorage(replicas []Replica, quorum int, queue ReplicaQueue) (*ReplicatedStorage, error) {
+	if len(replicas) == 0 {
+		return nil, errors.New("replicated storage needs at least one replica")
+	}
+	if queue == nil {
+		return nil, errors.New("replicated storage needs a retry queue")
+	}
+	if quorum == 0 {
+		quorum = len(replicas)
+	}
+	if quorum < 0 || quorum > len(replicas) {
+		return nil, fmt.Errorf("write quorum must be between 1 and %d", len(replicas))
+	}
+
+	seen := map[string]bool{}
+	for _, replica := range replicas {
+		if seen[replica.Name] {
+			return nil, fmt.Errorf("duplicate replica: %s", replica.Name)
+		}
+		seen[replica.Name] = true
+	}
+
+	return &ReplicatedStorage{replicas: replicas, quorum: quorum, queue: queue, unhealthy: map[string]time.Time{}}, nil
+}
+
+// replicaResult is the outcome of writing to one replica.
+type replicaResult struct {
+	index int
+	info  ObjectInfo
+	err   error
+}
+
+// Put streams content read from r to every replica in parallel. It returns the object as stored
+// by the first replica, in configured order, that stored it.
+func (s *ReplicatedStorage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	results := make(chan replicaResult, len(s.replicas))
+	writers := make([]io.Writer, len(s.replicas))
+	pipes := make([]*io.PipeWriter, len(s.replicas))
+
+	for i, replica := range s.replicas {
+		pr, pw := io.Pipe()
+		writers[i], pipes[i] = pw, pw
+
+		go func(i int, backend Storage, pr *io.PipeReader) {
+			info, err := backend.Put(ctx, key, pr, opts)
+			// Keep consuming so a replica that gave up does not stall the others
+			io.Copy(io.Discard, pr)
+			results <- replicaResult{index: i, info: info, err: err}
+		}(i, replica.Storage, pr)
+	}
+
+	_, copyErr := io.Copy(io.MultiWriter(writers...), r)
+	for _, pw := range pipes {
+		pw.CloseWithError(copyErr)
+	}
+
+	infos := make([]ObjectInfo, len(s.replicas))
+	errs := make([]error, len(s.replicas))
+	for range s.replicas {
+		result := <-results
+		infos[result.index], errs[result.index] = result.info, result.err
+	}
+
+	if copyErr != nil {
+		return ObjectInfo{}, copyErr
+	}
+	return s.settleWrite(key, infos, errs)
+}
+
+// Copy duplicates an object on every replica in parallel. A replica that is missing the source
+// object is queued to receive the copy from another one.
+func (s *ReplicatedStorage) Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error) {
+	infos := make([]ObjectInfo, len(s.replicas))
+	errs := make([]error, len(s.replicas))
+	var wg sync.WaitGroup
+	for i, replica := range s.replicas {
+		wg.Add(1)
+		go func(i int, backend Storage) {
+			defer wg.Done()
+			infos[i], errs[i] = backend.Copy(ctx, srcKey, dstKey)
+		}(i, replica.Storage)
+	}
+	wg.Wait()
+
+	return s.settleWrite(dstKey, infos, errs)
+}
+
+// settleWrite checks that a write reached the quorum and queues the replicas that missed it.
+// It returns the object as stored by the first replica, in configured order, that stored it.
+func (s *ReplicatedStorage) settleWrite(key string, infos []ObjectInfo, errs []error) (ObjectInfo, error) {
+	var stored *ObjectInfo
+	succeeded := 0
+	for i, replica := range s.replicas {
+		s.recordHealth(replica.Name, healthError(errs[i]))
+		if errs[i] == nil {
+			succeeded++
+			if stored == nil {
+				stored = &infos[i]
+			}
+		}
+	}
+	if succeeded < s.quorum {
+		return ObjectInfo{}, fmt.Errorf("write reached %d of %d required replicas: %v", succeeded, s.quorum, joinReplicaErrors(s.replicas, errs))
+	}
+
+	for i, replica := range s.replicas {
+		if errs[i] != nil {
+			s.enqueue(models.NewReplicaTask(uuid.New().String(), key, replica.Name, models.ReplicaOpWrite, stored.Size, stored.Checksums.SHA256, errs[i].Error()))
+		}
+	}
+	return *stored, nil
+}
+
+// Open streams an object from the first healthy replica that can serve it
+func (s *ReplicatedStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	var lastErr error
+	for _, replica := range s.readOrder() {
+		rc, err := replica.Storage.Open(ctx, key)
+		s.recordHealth(replica.Name, healthError(err))
+		if err == nil {
+			return rc, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no replica could serve %s: %w", key, lastErr)
+}
+
+// Stat describes an object as stored on the first healthy replica that has it
+func (s *ReplicatedStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	var lastErr error
+	for _, replica := range s.readOrder() {
+		info, err := replica.Storage.Stat(ctx, key)
+		s.recordHealth(replica.Name, healthError(err))
+		if err == nil {
+			return info, nil
+		}
+		lastErr = err
+	}
+	return ObjectInfo{}, fmt.Errorf("no replica could describe %s: %w", key, lastErr)
+}
+
+// Exists reports whether an object is stored on any replica
+func (s *ReplicatedStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return exists(s.Stat(ctx, key))
+}
+
+// DeleteFile deletes an object from every replica. Replicas that fail are queued to be retried,
+// so the delete only fails if none of them succeeded.
+func (s *ReplicatedStorage) DeleteFile(ctx context.Context, key string) error {
+	// Writes still queued for the object would otherwise bring it back
+	if err := s.queue.CancelReplicaTasks(key); err != nil {
+		return fmt.Errorf("failed to cancel queued replica writes: %v", err)
+	}
+
+	errs := make([]error, len(s.replicas))
+	var wg sync.WaitGroup
+	for i, replica := range s.replicas {
+		wg.Add(1)
+		go func(i int, backend Storage) {
+			defer wg.Done()
+			errs[i] = backend.DeleteFile(ctx, key)
+		}(i, replica.Storage)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, replica := range s.replicas {
+		s.recordHealth(replica.Name, errs[i])
+		if errs[i] == nil {
+			succeeded++
+		}
+	}
+	if succeeded == 0 {
+		return fmt.Errorf("delete failed on every replica: %v", joinReplicaErrors(s.replicas, errs))
+	}
+
+	for i, replica := range s.replicas {
+		if errs[i] != nil {
+			s.enqueue(models.NewReplicaTask(uuid.New().String(), key, replica.Name, models.ReplicaOpDelete, -1, "", errs[i].Error()))
+		}
+	}
+	return nil
+}
+
+// List lists objects on the first healthy replica that can be listed
+func (s *ReplicatedStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var lastErr error
+	for _, replica := range s.readOrder() {
+		objects, err := replica.Storage.List(ctx, prefix)
+		s.recordHealth(replica.Name, err)
+		if err == nil {
+			return objects, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("no replica could be listed: %v", lastErr)
+}
+
+// RetryPending repeats up to limit queued operations that are due, copying missing objects from
+// a replica that has them. Failed attempts are rescheduled with exponential backoff.
+func (s *ReplicatedStorage) RetryPending(ctx context.Context, limit int) error {
+	tasks, err := s.queue.ListDueReplicaTasks(time.Now(), limit)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		target, ok := s.replica(task.Backend)
+		if !ok {
+			// The replica was removed from the configuration, so there is nothing left to repair
+			log.Printf("Dropping replica task %s for unknown replica %s", task.ID, task.Backend)
+			if err := s.queue.CompleteReplicaTask(task); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch task.Operation {
+		case models.ReplicaOpDelete:
+			err = target.Storage.DeleteFile(ctx, task.StorageKey)
+		default:
+			err = s.copyTo(ctx, target, task)
+		}
+		s.recordHealth(target.Name, err)
+
+		if err == nil {
+			metrics.ReplicaTasksCompleted.Add(1)
+			if err := s.queue.CompleteReplicaTask(task); err != nil {
+				return err
+			}
+			continue
+		}
+
+		metrics.ReplicaTasksFailed.Add(1)
+		log.Printf("Replica %s %s of %s failed (attempt %d): %v", task.Backend, task.Operation, task.StorageKey, task.Attempts+1, err)
+		task.Attempts++
+		task.LastError = err.Error()
+		task.NextAttemptAt = time.Now().Add(retryBackoff(task.Attempts))
+		if err := s.queue.RescheduleReplicaTask(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTo writes an object to a replica that missed it, reading it from any other replica.
+func (s *ReplicatedStorage) copyTo(ctx context.Context, target Replica, task *models.ReplicaTask) error {
+	lastErr := errors.New("no other replica to copy from")
+	for _, source := range s.readOrder() {
+		if source.Name == target.Name {
+			continue
+		}
+
+		info, err := source.Storage.Stat(ctx, task.StorageKey)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		rc, err := source.Storage.Open(ctx, task.StorageKey)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, err = target.Storage.Put(ctx, task.StorageKey, rc, PutOptions{
+			Size:        task.Size,
+			ContentType: info.ContentType,
+			Metadata:    info.Metadata,
+			Checksums:   Checksums{SHA256: task.ChecksumSHA256},
+		})
+		rc.Close()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// enqueue records an operation a replica missed. If even that fails the replica stays behind
+// until the object is written again, so it is logged loudly.
+func (s *ReplicatedStorage) enqueue(task *models.ReplicaTask) {
+	if err := s.queue.EnqueueReplicaTask(task); err != nil {
+		log.Printf("ERROR: replica %s is missing the %s of %s and it could not be queued: %v", task.Backend, task.Operation, task.StorageKey, err)
+		return
+	}
+	metrics.ReplicaTasksQueued.Add(1)
+}
+
+// replica finds a replica by name.
+func (s *ReplicatedStorage) replica(name string) (Replica, bool) {
+	for _, replica := range s.replicas {
+		if replica.Name == name {
+			return replica, true
+		}
+	}
+	return Replica{}, false
+}
+
+// readOrder returns the healthy replicas in configured order, followed by the ones cooling
+// down after a failure, which are still better than failing the read.
+func (s *ReplicatedStorage) readOrder() []Replica {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]Replica, 0, len(s.replicas))
+	var cooling []Replica
+	for _, replica := range s.replicas {
+		if now.Before(s.unhealthy[replica.Name]) {
+			cooling = append(cooling, replica)
+		} else {
+			healthy = append(healthy, replica)
+		}
+	}
+	return append(healthy, cooling...)
+}
+
+// recordHealth starts a cooldown for a replica that failed and ends it when one succeeds.
+func (s *ReplicatedStorage) recordHealth(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.unhealthy[name] = time.Now().Add(replicaCooldown)
+	} else {
+		delete(s.unhealthy, name)
+	}
+}
+
+// healthError ignores missing objects, which mean a replica is behind rather than failing.
+func healthError(err error) error {
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+// retryBackoff doubles the delay after every failed attempt, up to replicaRetryMax.
+func retryBackoff(attempts int) time.Duration {
+	delay := replicaRetryDelay
+	for i := 1; i < attempts && delay < replicaRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > replicaRetryMax {
+		delay = replicaRetryMax
+	}
+	return delay
+}
+
+// joinReplicaErrors describes the replicas that failed.
+func joinReplicaErrors(replicas []Replica, errs []error) string {
+	var parts []string
+	for i, err := range errs {
+		if err != nil {
+			parts = append(parts, replicas[i].Name+": "+err.Error())
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -2,64 +2,281 @@ package storage
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"mime/multipart"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/souvik03-136/Go-Store/internal/config"
 )
 
 // S3Storage struct to hold the S3 client and bucket details
 type S3Storage struct {
-	client *s3.S3
-	bucket string
+	client    *s3.S3
+	bucket    string
+	pathStyle bool
 }
 
-// NewS3Storage initializes a new S3 client
-func NewS3Storage(accessKey, secretKey, region, bucket string) (*S3Storage, error) {
-	sess, err := session.NewSession(&aws.Config{
-		Region:      aws.String(region),
-		Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
+// NewS3Storage initializes a new S3 client. Static keys are used when configured, otherwise
+// credentials come from the default AWS chain: environment, shared profile, then instance or
+// task role. A custom endpoint points the client at an S3-compatible service such as MinIO or Ceph.
+func NewS3Storage(cfg config.AWSConfig) (*S3Storage, error) {
+	awsConfig := aws.NewConfig().WithRegion(cfg.Region).WithS3ForcePathStyle(cfg.ForcePathStyle)
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+	if cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(cfg.Endpoint)
+	}
+	if cfg.InsecureSkipVerify {
+		log.Println("WARNING: TLS certificate verification is disabled for S3")
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		awsConfig = awsConfig.WithHTTPClient(&http.Client{Transport: transport})
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AWS session: %v", err)
 	}
 
 	svc := s3.New(sess)
-	return &S3Storage{client: svc, bucket: bucket}, nil
+	return &S3Storage{client: svc, bucket: cfg.BucketName, pathStyle: cfg.ForcePathStyle}, nil
 }
 
-// UploadFile uploads a file to S3
-func (s *S3Storage) UploadFile(ctx context.Context, file *multipart.FileHeader, destination string) (string, error) {
-	f, err := file.Open()
+// Put uploads content read from r to S3. Seekable content is sent in a single request with
+// Content-MD5, so S3 rejects the upload if the bytes it receives differ; anything else is streamed
+// as a multipart upload and checked against the SHA-256 as it is read, since S3 cannot verify an
+// MD5 across parts.
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error) {
+	info := ObjectInfo{Key: key, Size: opts.Size, ContentType: opts.ContentType, Checksums: opts.Checksums, Metadata: opts.Metadata}
+	var contentType *string
+	if opts.ContentType != "" {
+		contentType = aws.String(opts.ContentType)
+	}
+
+	if body, ok := r.(io.ReadSeeker); ok {
+		input := &s3.PutObjectInput{
+			Bucket:      aws.String(s.bucket),
+			Key:         aws.String(key),
+			Body:        body,
+			ContentType: contentType,
+			Metadata:    aws.StringMap(opts.Metadata),
+		}
+		if opts.Size >= 0 {
+			input.ContentLength = aws.Int64(opts.Size)
+		}
+		if md5, err := hex.DecodeString(opts.Checksums.MD5); err == nil && len(md5) > 0 {
+			input.ContentMD5 = aws.String(base64.StdEncoding.EncodeToString(md5))
+		}
+
+		out, err := s.client.PutObjectWithContext(ctx, input)
+		if err != nil {
+			return ObjectInfo{}, fmt.Errorf("failed to upload file to S3: %v", err)
+		}
+		info.ETag = unquoteETag(out.ETag)
+		info.Version = aws.StringValue(out.VersionId)
+	} else {
+		if opts.Checksums.SHA256 != "" {
+			r = NewVerifyingReader(r, opts.Checksums.SHA256)
+		}
+		counter := &countingReader{r: r}
+
+		uploader := s3manager.NewUploaderWithClient(s.client)
+		out, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+			Bucket:      aws.String(s.bucket),
+			Key:         aws.String(key),
+			Body:        counter,
+			ContentType: contentType,
+			Metadata:    aws.StringMap(opts.Metadata),
+		})
+		if err != nil {
+			return ObjectInfo{}, fmt.Errorf("failed to upload file to S3: %v", err)
+		}
+		info.Size = counter.n
+		info.ETag = unquoteETag(out.ETag)
+		info.Version = aws.StringValue(out.VersionID)
+	}
+
+	fileURL, err := s.objectURL(key)
 	if err != nil {
-		return "", err
+		return ObjectInfo{}, err
 	}
-	defer f.Close()
+	info.URL = fileURL
+	info.LastModified = time.Now()
+	return info, nil
+}
 
-	_, err = s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+// objectURL builds an object's URL from the endpoint the client actually uses, in the same
+// addressing style as its requests.
+func (s *S3Storage) objectURL(key string) (string, error) {
+	u, err := url.Parse(s.client.Endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid S3 endpoint: %v", err)
+	}
+
+	if s.pathStyle {
+		u.Path = path.Join("/", u.Path, s.bucket, key)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, key)
+	}
+	return u.String(), nil
+}
+
+// Open streams a file from S3
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from S3: %w", s3Error(err))
+	}
+	return out.Body, nil
+}
+
+// Stat describes an object in S3. The ETag of an object uploaded in a single request without
+// KMS encryption is its MD5, so it is reported as one.
+func (s *S3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	out, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(destination),
-		Body:   f,
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file to S3: %v", err)
+		return ObjectInfo{}, fmt.Errorf("failed to stat file in S3: %w", s3Error(err))
 	}
 
-	fileURL := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, destination)
-	return fileURL, nil
+	info := ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		LastModified: aws.TimeValue(out.LastModified),
+		ContentType:  aws.StringValue(out.ContentType),
+		ETag:         unquoteETag(out.ETag),
+		Version:      aws.StringValue(out.VersionId),
+		Metadata:     aws.StringValueMap(out.Metadata),
+	}
+	if !strings.Contains(info.ETag, "-") && out.SSEKMSKeyId == nil {
+		info.Checksums.MD5 = info.ETag
+	}
+	return info, nil
+}
+
+// Exists reports whether an object is stored in S3
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	return exists(s.Stat(ctx, key))
+}
+
+// Copy duplicates an object within the bucket on the server side. A single copy request is
+// limited to objects of up to 5 GB.
+func (s *S3Storage) Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error) {
+	_, err := s.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(dstKey),
+		CopySource:        aws.String(s.copySource(srcKey)),
+		MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+	})
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to copy file in S3: %w", s3Error(err))
+	}
+
+	info, err := s.Stat(ctx, dstKey)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if info.URL, err = s.objectURL(dstKey); err != nil {
+		return ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+// SetStorageClass moves an object to another S3 storage class by copying it onto itself.
+// Archive classes are refused, since their objects cannot be read without a restore.
+func (s *S3Storage) SetStorageClass(ctx context.Context, filePath, class string) error {
+	switch class {
+	case s3.StorageClassGlacier, s3.StorageClassDeepArchive:
+		return fmt.Errorf("storage class %s cannot be read without a restore", class)
+	}
+
+	_, err := s.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(filePath),
+		CopySource:        aws.String(s.copySource(filePath)),
+		StorageClass:      aws.String(class),
+		MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change storage class in S3: %v", err)
+	}
+	return nil
+}
+
+// copySource names an object of the bucket as the source of a copy request
+func (s *S3Storage) copySource(key string) string {
+	return s.bucket + "/" + (&url.URL{Path: key}).EscapedPath()
 }
 
 // DeleteFile deletes a file from S3
-func (s *S3Storage) DeleteFile(ctx context.Context, filePath string) error {
+func (s *S3Storage) DeleteFile(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(filePath),
+		Key:    aws.String(key),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete file from S3: %v", err)
 	}
 	return nil
 }
+
+// List lists the objects in the S3 bucket under a prefix
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.StringValue(obj.Key),
+				Size:         aws.Int64Value(obj.Size),
+				LastModified: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in S3: %v", err)
+	}
+	return objects, nil
+}
+
+// s3Error maps the errors S3 returns for missing objects to ErrObjectNotFound
+func s3Error(err error) error {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound {
+		return fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return err
+}
+
+// unquoteETag strips the quotes S3 puts around ETags
+func unquoteETag(etag *string) string {
+	return strings.Trim(aws.StringValue(etag), `"`)
+}
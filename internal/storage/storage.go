@@ -2,11 +2,156 @@ package storage
 
 import (
 	"context"
-	"mime/multipart"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/souvik03-136/Go-Store/internal/config"
 )
 
-// Storage interface that both S3 and GC3 will implement
+// ErrObjectNotFound is returned, possibly wrapped, for keys that have no object
+var ErrObjectNotFound = errors.New("object not found")
+
+// PutOptions describes the content passed to Put
+type PutOptions struct {
+	Size        int64             // Content length, or -1 when it is not known in advance
+	ContentType string            // MIME type stored with the object
+	Metadata    map[string]string // User metadata stored with the object, on backends that keep it
+	Checksums   Checksums         // Expected digests of the content, empty when unknown
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ContentType  string
+	ETag         string            // Opaque identifier of the object's content
+	Checksums    Checksums         // Digests the backend reports or verified the content against
+	Version      string            // Generation or version ID, on backends that keep one
+	Metadata     map[string]string // User metadata, on backends that keep it
+	URL          string            // Only set for objects that were just written or copied
+}
+
+// Storage interface that the S3, GCS, local, memory and replicated backends will implement. Keys name
+// objects relative to the bucket or directory. Listing returns only the key, size and modification
+// time of each object; use Stat for the rest.
 type Storage interface {
-	UploadFile(ctx context.Context, file *multipart.FileHeader, destination string) (string, error)
-	DeleteFile(ctx context.Context, filePath string) error
+	// Put stores content read from r and describes the stored object. Backends check the content
+	// against the checksums they support and fail rather than store content that differs from them.
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (ObjectInfo, error)
+	// Open streams an object's content
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat describes an object without reading it
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Exists reports whether an object is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Copy duplicates an object, with its content type and metadata, under another key
+	Copy(ctx context.Context, srcKey, dstKey string) (ObjectInfo, error)
+	// DeleteFile removes an object. Deleting an object that does not exist is not an error.
+	DeleteFile(ctx context.Context, key string) error
+	// List returns every object whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// StorageClassSetter is implemented by backends that can move an object to another storage
+// class in place, such as from STANDARD to STANDARD_IA on S3.
+type StorageClassSetter interface {
+	SetStorageClass(ctx context.Context, filePath, class string) error
+}
+
+// NewStorage creates the storage backend selected by STORAGE_PROVIDER. The queue records
+// failed replica writes and is only used by the "replicated" provider. While STORAGE_MIGRATE_FROM
+// is set, the backend is wrapped so objects not yet moved are still read from the old provider,
+// and with LIFECYCLE_COLD_PROVIDER set it is paired with the cold tier.
+func NewStorage(ctx context.Context, cfg *config.Config, queue ReplicaQueue) (Storage, error) {
+	store, err := newProvider(ctx, cfg, queue)
+	if err != nil {
+		return nil, err
+	}
+
+	if source := cfg.Migration.Source; source != "" {
+		if source == cfg.StorageProvider {
+			return nil, errors.New("storage migration source must differ from STORAGE_PROVIDER")
+		}
+		backend, err := newBackend(ctx, cfg, source)
+		if err != nil {
+			return nil, fmt.Errorf("migration source %s: %v", source, err)
+		}
+		store = NewMigratingStorage(backend, store)
+	}
+
+	if cold := cfg.Lifecycle.ColdProvider; cold != "" {
+		if cold == cfg.StorageProvider || cold == cfg.Migration.Source {
+			return nil, errors.New("cold storage provider must differ from the other storage providers")
+		}
+		backend, err := newBackend(ctx, cfg, cold)
+		if err != nil {
+			return nil, fmt.Errorf("cold storage %s: %v", cold, err)
+		}
+		store = NewTieredStorage(store, backend)
+	}
+
+	return store, nil
+}
+
+// newProvider creates the backend new objects are written to
+func newProvider(ctx context.Context, cfg *config.Config, queue ReplicaQueue) (Storage, error) {
+	if cfg.StorageProvider == "replicated" {
+		replicas := make([]Replica, 0, len(cfg.Replication.Backends))
+		for _, name := range cfg.Replication.Backends {
+			backend, err := newBackend(ctx, cfg, name)
+			if err != nil {
+				return nil, fmt.Errorf("replica %s: %v", name, err)
+			}
+			replicas = append(replicas, Replica{Name: name, Storage: backend})
+		}
+		return NewReplicatedStorage(replicas, int(cfg.Replication.WriteQuorum), queue)
+	}
+	return newBackend(ctx, cfg, cfg.StorageProvider)
+}
+
+// newBackend creates a single storage backend by provider name
+func newBackend(ctx context.Context, cfg *config.Config, provider string) (Storage, error) {
+	switch provider {
+	case "s3":
+		// Initialize AWS S3 Storage
+		return NewS3Storage(cfg.AWS)
+	case "gcs":
+		// Initialize Google Cloud Storage
+		return NewGC3Storage(ctx, cfg.GoogleCloud)
+	case "local":
+		// Initialize storage on the local disk
+		return NewLocalStorage(cfg.Local.Dir, cfg.Local.BaseURL)
+	case "memory":
+		// Keep objects in memory, which is lost on restart
+		return NewMemoryStorage(), nil
+	default:
+		// Return a custom error message
+		return nil, errors.New("unsupported storage provider")
+	}
+}
+
+// DeletePrefix deletes every object whose key starts with prefix, such as the objects derived
+// from a file. It stops at the first failure.
+func DeletePrefix(ctx context.Context, s Storage, prefix string) error {
+	objects, err := s.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := s.DeleteFile(ctx, object.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exists turns the result of Stat into the result of Exists
+func exists(_ ObjectInfo, err error) (bool, error) {
+	if errors.Is(err, ErrObjectNotFound) {
+		return false, nil
+	}
+	return err == nil, err
 }
@@ -10,7 +10,8 @@ type BaseResponse struct {
 }
 
 type Error struct {
-	Code    int    `json:"code"`
-	Type    string `json:"type"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Type    string      `json:"type"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
 }